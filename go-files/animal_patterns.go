@@ -18,7 +18,7 @@ type AnimalPattern struct {
 }
 
 // GetRandomAnimal returns a random animal pattern with randomized colors
-func GetRandomAnimal() AnimalPattern {
+func GetRandomAnimal(r *rand.Rand) AnimalPattern {
 	animals := []AnimalPattern{
 		catPattern(),
 		dogPattern(),
@@ -32,17 +32,17 @@ func GetRandomAnimal() AnimalPattern {
 		elephantPattern(),
 	}
 
-	animal := animals[rand.IntN(len(animals))]
+	animal := animals[r.IntN(len(animals))]
 
 	// Randomize colors for variety
-	animal.Primary = randomBrightColor()
-	animal.Secondary = randomBrightColor()
+	animal.Primary = randomBrightColor(r)
+	animal.Secondary = randomBrightColor(r)
 	animal.Accent = color.RGBA{0, 0, 0, 255} // Keep accent as black for eyes/details
 
 	return animal
 }
 
-func randomBrightColor() color.RGBA {
+func randomBrightColor(r *rand.Rand) color.RGBA {
 	colors := []color.RGBA{
 		{255, 107, 107, 255}, // Red
 		{255, 159, 67, 255},  // Orange
@@ -57,7 +57,7 @@ func randomBrightColor() color.RGBA {
 		{255, 165, 2, 255},   // Gold
 		{255, 127, 80, 255},  // Coral
 	}
-	return colors[rand.IntN(len(colors))]
+	return colors[r.IntN(len(colors))]
 }
 
 func catPattern() AnimalPattern {
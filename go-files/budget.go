@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"sort"
+	"sync"
+)
+
+// minSizeStream is the PCG stream used to measure the smallest file of
+// each extension
+const minSizeStream = ^uint64(0) - 2
+
+// planBudget picks the size of every file so the run writes exactly
+// cfg.TotalSize bytes. Sizes come from each file's own stream, exactly as
+// generateFile would pick them; only the last file is shortened to land on
+// the budget. Files are never planned below the smallest size their
+// generator can write, and the bytes that costs are taken from the other
+// files. With a file cap that is reached first, the missing bytes are
+// spread over the files, up to each extension's maximum size.
+func planBudget(cfg *Config) error {
+	smallest, _ := cfg.Extensions[0].Sizes.Range()
+	for _, ext := range cfg.Extensions[1:] {
+		lo, _ := ext.Sizes.Range()
+		smallest = min(smallest, lo)
+	}
+
+	floors := newSizeFloors(cfg)
+	var sizes, limits, lows []int64
+	remaining := cfg.TotalSize
+	for remaining > 0 && (cfg.NumFiles == 0 || len(sizes) < cfg.NumFiles) {
+		index := len(sizes) + 1
+		ext, size := pickFile(cfg, newFileRand(cfg.Seed, index), index)
+		// A leftover too small for any file is merged into this one
+		if size >= remaining || remaining-size < smallest {
+			size = remaining
+		}
+		floor, err := floors.floor(index, ext, size)
+		if err != nil {
+			return err
+		}
+		lo, limit := ext.Sizes.Range()
+		size = max(size, floor)
+		sizes = append(sizes, size)
+		limits = append(limits, max(limit, size))
+		lows = append(lows, max(floor, min(lo, size)))
+		remaining -= size
+	}
+
+	// Files raised to their generator's minimum went over the budget
+	for remaining < 0 {
+		var open []int
+		for i := range sizes {
+			if sizes[i] > lows[i] {
+				open = append(open, i)
+			}
+		}
+		if len(open) == 0 {
+			return fmt.Errorf("a budget of %s is too small for %d files of the configured formats",
+				formatSize(cfg.TotalSize), len(sizes))
+		}
+		share := max(1, -remaining/int64(len(open)))
+		for _, i := range open {
+			shrink := min(share, sizes[i]-lows[i], -remaining)
+			sizes[i] -= shrink
+			remaining += shrink
+		}
+	}
+
+	for remaining > 0 {
+		var open []int
+		for i := range sizes {
+			if sizes[i] < limits[i] {
+				open = append(open, i)
+			}
+		}
+		if len(open) == 0 {
+			return fmt.Errorf("a budget of %s cannot be reached with %d files of the configured sizes",
+				formatSize(cfg.TotalSize), cfg.NumFiles)
+		}
+		share := max(1, remaining/int64(len(open)))
+		for _, i := range open {
+			grow := min(share, limits[i]-sizes[i], remaining)
+			sizes[i] += grow
+			remaining -= grow
+		}
+	}
+
+	cfg.sizes = sizes
+	cfg.NumFiles = len(sizes)
+	return nil
+}
+
+// sizeFloors finds the smallest size each planned file can be written at
+type sizeFloors struct {
+	cfg      *Config
+	baseline map[string]int64 // the smallest file of each extension
+}
+
+func newSizeFloors(cfg *Config) *sizeFloors {
+	return &sizeFloors{cfg: cfg, baseline: make(map[string]int64)}
+}
+
+// floor returns a size file index can be written at exactly with any
+// planned size from it up. Only files planned near their extension's
+// smallest file are measured; the minimum hardly differs between files,
+// so twice the smallest file is safe for the others.
+func (f *sizeFloors) floor(index int, ext ExtensionConfig, size int64) (int64, error) {
+	base, ok := f.baseline[ext.Name]
+	if !ok {
+		var err error
+		base, err = minExactSize(ext, func() *rand.Rand {
+			return rand.New(rand.NewPCG(f.cfg.Seed, minSizeStream))
+		})
+		if err != nil {
+			return 0, err
+		}
+		f.baseline[ext.Name] = base
+	}
+	if size >= 2*base {
+		return 2 * base, nil
+	}
+	// Replay the file's stream up to where generateFile hands it over
+	return minExactSize(ext, func() *rand.Rand {
+		r := newFileRand(f.cfg.Seed, index)
+		pickFile(f.cfg, r, index)
+		return r
+	})
+}
+
+// minExactSize returns the smallest size the generator of ext writes
+// exactly with the stream from newRand. Generators write their minimal
+// file when asked for less, but how much structure that takes can grow
+// with the requested size, so the size is raised until it fits.
+func minExactSize(ext ExtensionConfig, newRand func() *rand.Rand) (int64, error) {
+	var size int64
+	for {
+		generator := NewGenerator(ext.Name)
+		if err := configureGenerator(generator, ext.Options); err != nil {
+			return 0, err
+		}
+		eg, ok := generator.(ExactGenerator)
+		if !ok {
+			return 0, nil
+		}
+		n, err := eg.GenerateExactTo(io.Discard, newRand(), size)
+		if err != nil {
+			return 0, fmt.Errorf("measuring the smallest %s file: %w", ext.Name, err)
+		}
+		if n <= size {
+			return size, nil
+		}
+		size = n
+	}
+}
+
+// volumeReport counts the files and bytes written per extension
+type volumeReport struct {
+	mu    sync.Mutex
+	files map[string]int
+	bytes map[string]int64
+}
+
+// Add records one written file
+func (rep *volumeReport) Add(ext string, size int64) {
+	rep.mu.Lock()
+	defer rep.mu.Unlock()
+	if rep.files == nil {
+		rep.files = make(map[string]int)
+		rep.bytes = make(map[string]int64)
+	}
+	rep.files[ext]++
+	rep.bytes[ext] += size
+}
+
+// Print lists the totals per extension and compares them to the budget
+func (rep *volumeReport) Print(budget int64) {
+	exts := make([]string, 0, len(rep.files))
+	var total int64
+	for ext := range rep.files {
+		exts = append(exts, ext)
+		total += rep.bytes[ext]
+	}
+	sort.Strings(exts)
+
+	if total == budget {
+		fmt.Printf("Budget: wrote exactly %s (%d bytes)\n", formatSize(total), total)
+	} else {
+		fmt.Printf("Budget: wrote %d of %d bytes (off by %d)\n", total, budget, total-budget)
+	}
+	for _, ext := range exts {
+		fmt.Printf("  %s: %d file(s), %s\n", ext, rep.files[ext], formatSize(rep.bytes[ext]))
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"io/fs"
+	"math/rand/v2"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPlanBudget(t *testing.T) {
+	tests := []struct {
+		name     string
+		exts     string
+		total    int64
+		numFiles int // cap, 0 for none
+		valid    bool
+	}{
+		{"every format", strings.Join(SupportedExtensions(), ","), 3 << 20, 0, true},
+		{"large minimums", "pptx,ods,odp", 200 << 10, 0, true},
+		{"capped", "txt,csv,pdf", 1 << 20, 20, true},
+		{"cap too low", "txt", 1 << 20, 5, false},
+		{"budget too small", "pptx", 10 << 10, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(t, tt.exts, tt.numFiles, 1024, 64*1024)
+			cfg.TotalSize, cfg.ExactSize = tt.total, true
+			err := planBudget(cfg)
+			if (err == nil) != tt.valid {
+				t.Fatalf("planBudget error = %v, want valid %v", err, tt.valid)
+			}
+			if err != nil {
+				return
+			}
+			var sum int64
+			for _, size := range cfg.sizes {
+				sum += size
+			}
+			if sum != tt.total || len(cfg.sizes) != cfg.NumFiles {
+				t.Errorf("planned %d bytes in %d sizes for %d files, want %d bytes", sum, len(cfg.sizes), cfg.NumFiles, tt.total)
+			}
+			for i, size := range cfg.sizes {
+				index := i + 1
+				ext, _ := pickFile(cfg, newFileRand(cfg.Seed, index), index)
+				smallest, err := minExactSize(ext, func() *rand.Rand {
+					r := newFileRand(cfg.Seed, index)
+					pickFile(cfg, r, index)
+					return r
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if size < smallest {
+					t.Errorf("file %d planned at %d bytes, below the %s minimum of %d", index, size, ext.Name, smallest)
+				}
+			}
+		})
+	}
+}
+
+func TestBudgetWrittenExactly(t *testing.T) {
+	for _, exts := range []string{strings.Join(SupportedExtensions(), ","), "pptx,ods,csv"} {
+		cfg := testConfig(t, exts, 0, 1024, 64*1024)
+		cfg.TotalSize, cfg.ExactSize = 3<<20, true
+		if err := execute(cfg); err != nil {
+			t.Fatal(err)
+		}
+
+		var written int64
+		filepath.WalkDir(cfg.OutDir, func(p string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				info, _ := d.Info()
+				written += info.Size()
+			}
+			return err
+		})
+		if written != cfg.TotalSize {
+			t.Errorf("%s: wrote %d bytes, want %d", exts, written, cfg.TotalSize)
+		}
+	}
+}
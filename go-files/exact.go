@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ExactGenerator is implemented by generators that can produce a file of
+// exactly sizeBytes bytes while keeping it structurally valid
+type ExactGenerator interface {
+	FileGenerator
+	GenerateExactTo(w io.Writer, r *rand.Rand, sizeBytes int64) (int64, error)
+}
+
+// exactSlack is the room kept free at the end of record-based formats so a
+// final record can always be padded to land exactly on the target size
+const exactSlack = 64
+
+// randomText generates exactly n bytes of random words separated by spaces
+func randomText(r *rand.Rand, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	var sb strings.Builder
+	for sb.Len() < n {
+		if sb.Len() > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(randomWord(r))
+	}
+	text := []byte(sb.String()[:n])
+	if text[n-1] == ' ' {
+		text[n-1] = charset[r.IntN(len(charset))]
+	}
+	return string(text)
+}
+
+// exactReport collects the files whose size differs from the requested one
+type exactReport struct {
+	mu     sync.Mutex
+	misses map[string][]string
+}
+
+// Add records the outcome for one file
+func (rep *exactReport) Add(ext, filename string, requested, written int64) {
+	if requested == written {
+		return
+	}
+	rep.mu.Lock()
+	defer rep.mu.Unlock()
+	if rep.misses == nil {
+		rep.misses = make(map[string][]string)
+	}
+	rep.misses[ext] = append(rep.misses[ext],
+		fmt.Sprintf("%s (requested %d bytes, wrote %d)", filename, requested, written))
+}
+
+// Print lists every format that could not honour the exact size
+func (rep *exactReport) Print() {
+	if len(rep.misses) == 0 {
+		fmt.Println("Exact size: every file matches its requested size")
+		return
+	}
+	exts := make([]string, 0, len(rep.misses))
+	for ext := range rep.misses {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+
+	fmt.Println("Exact size: some formats could not honour the requested size")
+	for _, ext := range exts {
+		files := rep.misses[ext]
+		sort.Strings(files)
+		fmt.Printf("  %s: %d file(s)\n", ext, len(files))
+		for _, f := range files {
+			fmt.Printf("    %s\n", f)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerateExactSize(t *testing.T) {
+	sizes := []int64{1024, 4097, 20000, 65543, 300001}
+	for _, ext := range SupportedExtensions() {
+		t.Run(ext, func(t *testing.T) {
+			config := ExtensionConfig{Name: ext}
+			smallest, err := minExactSize(config, func() *rand.Rand { return newFileRand(1, 1) })
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, size := range append([]int64{smallest}, sizes...) {
+				if size < smallest {
+					continue
+				}
+				g, ok := NewGenerator(ext).(ExactGenerator)
+				if !ok {
+					t.Fatalf("%s has no exact generator", ext)
+				}
+				var buf bytes.Buffer
+				n, err := g.GenerateExactTo(&buf, newFileRand(1, i+1), size)
+				if err != nil {
+					t.Fatalf("%d bytes: %v", size, err)
+				}
+				if n != size || int64(buf.Len()) != size {
+					t.Errorf("%d bytes: reported %d, wrote %d", size, n, buf.Len())
+					continue
+				}
+
+				name := filepath.Join(t.TempDir(), "file."+ext)
+				if err := os.WriteFile(name, buf.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+				if err := verifyFile(name); err != nil {
+					t.Errorf("%d bytes: %v", size, err)
+				}
+			}
+		})
+	}
+}
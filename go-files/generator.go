@@ -1,27 +1,38 @@
 package main
 
 import (
+	"encoding/json"
+	"flag"
 	"fmt"
 	"math/rand/v2"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 )
 
 const (
-	minSizeKB = 1
-	charset   = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	defaultMinSize = "1KB"
+	charset        = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 )
 
-// FileGenerator defines the interface for generating different file types
+// FileGenerator defines the interface for generating different file types.
+// All randomness must be drawn from r so that output is reproducible.
 type FileGenerator interface {
-	Generate(sizeBytes int) ([]byte, error)
+	Generate(r *rand.Rand, sizeBytes int) ([]byte, error)
 	Extension() string
 }
 
+// MetadataProvider is implemented by generators that can describe the file
+// they last generated, e.g. the animal drawn in a PNG. The values end up in
+// the manifest, so they must be JSON friendly.
+type MetadataProvider interface {
+	Metadata() map[string]any
+}
+
 // SupportedExtensions returns list of all supported file extensions
 func SupportedExtensions() []string {
-	return []string{"txt", "csv", "json", "xml", "html", "md", "log", "pdf", "docx", "xlsx", "png"}
+	return []string{"txt", "csv", "json", "xml", "html", "md", "log", "pdf", "docx", "xlsx", "pptx", "odt", "ods", "odp", "png"}
 }
 
 // NewGenerator returns the appropriate generator for the given extension
@@ -47,6 +58,14 @@ func NewGenerator(ext string) FileGenerator {
 		return &DocxGenerator{}
 	case "xlsx":
 		return &XlsxGenerator{}
+	case "pptx":
+		return &PptxGenerator{}
+	case "odt":
+		return &OdtGenerator{}
+	case "ods":
+		return &OdsGenerator{}
+	case "odp":
+		return &OdpGenerator{}
 	case "png":
 		return &PngGenerator{}
 	default:
@@ -54,111 +73,357 @@ func NewGenerator(ext string) FileGenerator {
 	}
 }
 
+// newFileRand returns the random source for the file with the given index.
+// Each file gets its own stream derived from (seed, index), so any single
+// file can be regenerated without generating the files before it.
+func newFileRand(seed uint64, index int) *rand.Rand {
+	return rand.New(rand.NewPCG(seed, uint64(index)))
+}
+
 // randomString generates a random string of the specified length
-func randomString(length int) string {
+func randomString(r *rand.Rand, length int) string {
 	b := make([]byte, length)
 	for i := range b {
-		b[i] = charset[rand.IntN(len(charset))]
+		b[i] = charset[r.IntN(len(charset))]
 	}
 	return string(b)
 }
 
 // randomWord generates a random word of 3-10 characters
-func randomWord() string {
-	return randomString(3 + rand.IntN(8))
+func randomWord(r *rand.Rand) string {
+	return randomString(r, 3+r.IntN(8))
 }
 
 // randomSentence generates a random sentence with 5-15 words
-func randomSentence() string {
-	wordCount := 5 + rand.IntN(11)
+func randomSentence(r *rand.Rand) string {
+	wordCount := 5 + r.IntN(11)
 	words := make([]string, wordCount)
 	for i := range words {
-		words[i] = randomWord()
+		words[i] = randomWord(r)
 	}
 	return strings.Join(words, " ") + "."
 }
 
 // randomParagraph generates a random paragraph with 3-7 sentences
-func randomParagraph() string {
-	sentenceCount := 3 + rand.IntN(5)
+func randomParagraph(r *rand.Rand) string {
+	sentenceCount := 3 + r.IntN(5)
 	sentences := make([]string, sentenceCount)
 	for i := range sentences {
-		sentences[i] = randomSentence()
+		sentences[i] = randomSentence(r)
 	}
 	return strings.Join(sentences, " ")
 }
 
+// printUsage prints the command line help
+func printUsage(fs *flag.FlagSet) {
+	fmt.Println("Usage: generator [options] <number_of_files> <max_size> [extensions]")
+	fmt.Println("  number_of_files: Total number of files to generate (with --total: the most files, 0 for no limit)")
+	fmt.Println("  max_size: Maximum size of each file, e.g. 100 (KB), 512B, 64KB, 1.5MB, 2GB")
+	fmt.Println("  extensions: Comma-separated list of extensions (optional)")
+	fmt.Printf("  Supported extensions: %s\n", strings.Join(SupportedExtensions(), ", "))
+	fmt.Println("\nOptions:")
+	fs.SetOutput(os.Stdout)
+	fs.PrintDefaults()
+	fmt.Println("\nExample: generator --seed 42 100 100 txt,csv,json")
+	fmt.Println("\nTo generate a corpus described by a spec file: generator run spec.yaml")
+	fmt.Println("To check that generated files are valid: generator verify [--manifest FILE] DIR")
+}
+
+// parseArgs parses flags and positional arguments, allowing flags to
+// appear before, between or after the positional arguments
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// parseTreeOptions parses the tree mode flags
+func parseTreeOptions(depth, fanout, filesPerDir, dirNames string) (*TreeOptions, error) {
+	var opts TreeOptions
+	var err error
+	if opts.Depth, err = parseIntRange(depth); err != nil {
+		return nil, fmt.Errorf("depth: %w", err)
+	}
+	if opts.Fanout, err = parseIntRange(fanout); err != nil {
+		return nil, fmt.Errorf("fanout: %w", err)
+	}
+	if opts.FilesPerDir, err = parseIntRange(filesPerDir); err != nil {
+		return nil, fmt.Errorf("files per directory: %w", err)
+	}
+	opts.DirNames = dirNames
+	return &opts, nil
+}
+
+// runSpec implements "generator run spec.yaml"
+func runSpec(args []string) {
+	fs := flag.NewFlagSet("generator run", flag.ContinueOnError)
+	seedFlag := fs.String("seed", "", "Override the seed from the spec")
+	only := fs.Int("only", 0, "Generate only the file with this index")
+	fs.Usage = func() {
+		fmt.Println("Usage: generator run [options] <spec.yaml|spec.json>")
+		fmt.Println("\nOptions:")
+		fs.SetOutput(os.Stdout)
+		fs.PrintDefaults()
+	}
+
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		os.Exit(1)
+	}
+	if len(args) != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	spec, err := loadSpec(args[0])
+	if err != nil {
+		fmt.Printf("Error: Cannot read spec '%s': %v\n", args[0], err)
+		os.Exit(1)
+	}
+	if *seedFlag != "" {
+		seed, err := strconv.ParseUint(*seedFlag, 10, 64)
+		if err != nil {
+			fmt.Printf("Error: Invalid seed '%s'. Must be a non-negative integer.\n", *seedFlag)
+			os.Exit(1)
+		}
+		spec.Seed = &seed
+	}
+
+	cfg, err := spec.Config()
+	if err != nil {
+		fmt.Printf("Error: Invalid spec '%s': %v\n", args[0], err)
+		os.Exit(1)
+	}
+	cfg.Only = *only
+
+	if err := execute(cfg); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runVerify implements "generator verify DIR"
+func runVerify(args []string) {
+	fs := flag.NewFlagSet("generator verify", flag.ContinueOnError)
+	manifestFlag := fs.String("manifest", "", "Also check sizes and SHA-256 hashes against this manifest (.jsonl or .csv)")
+	fs.Usage = func() {
+		fmt.Println("Usage: generator verify [options] <directory>")
+		fmt.Println("\nRe-parses every generated file and exits with status 1 if any is invalid.")
+		fmt.Println("\nOptions:")
+		fs.SetOutput(os.Stdout)
+		fs.PrintDefaults()
+	}
+
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		os.Exit(1)
+	}
+	if len(args) != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	dir := args[0]
+
+	var entries []manifestEntry
+	manifestPath := ""
+	if *manifestFlag != "" {
+		if entries, err = loadManifest(*manifestFlag); err != nil {
+			fmt.Printf("Error: Cannot read manifest '%s': %v\n", *manifestFlag, err)
+			os.Exit(1)
+		}
+		manifestPath, _ = filepath.Abs(*manifestFlag)
+	}
+
+	// The manifest may live inside the directory, but is not a generated file
+	sum, err := verifyDir(dir, func(p string) bool {
+		abs, _ := filepath.Abs(p)
+		return abs == manifestPath
+	})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("\nVerified %d files: %d passed, %d failed, %d skipped (unknown format)\n",
+		sum.Passed+sum.Failed, sum.Passed, sum.Failed, sum.Skipped)
+
+	failed := sum.Failed
+	if *manifestFlag != "" {
+		mismatches := verifyManifest(dir, entries)
+		fmt.Printf("Manifest: %d entries checked, %d mismatched\n", len(entries), mismatches)
+		failed += mismatches
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "run":
+			runSpec(os.Args[2:])
+			return
+		case "verify":
+			runVerify(os.Args[2:])
+			return
+		}
+	}
+
+	fs := flag.NewFlagSet("generator", flag.ContinueOnError)
+	seedFlag := fs.String("seed", "", "Seed for reproducible output (random if omitted)")
+	only := fs.Int("only", 0, "Generate only the file with this index (use with --seed)")
+	workers := fs.Int("workers", 1, "Number of files to generate concurrently")
+	exactSize := fs.Bool("exact-size", false, "Make every file exactly the chosen size while keeping it valid")
+	totalFlag := fs.String("total", "",
+		"Generate this many bytes in total, e.g. 10GB; number_of_files becomes a cap (0 for none) and --exact-size is implied")
+	outDir := fs.String("out", ".", "Directory to write files into (created if missing)")
+	nameFlag := fs.String("name", defaultNameTemplate,
+		"File name template; fields: {index}, {ext}, {size}, {kb}, {animal}, {uuid}, {random}, e.g. {index:06d}_{animal}.{ext}")
+	minSizeFlag := fs.String("min-size", defaultMinSize, "Minimum file size, e.g. 512B, 4KB, 1MB")
+	distFlag := fs.String("dist", "uniform",
+		"Size distribution: uniform, lognormal[:median=SIZE,sigma=N], pareto[:alpha=N,scale=SIZE], buckets:SIZE=WEIGHT,... or histogram:MIN-MAX=WEIGHT,...")
+	pngPadding := fs.String("png-padding", "text", "How PNG files reach their size: "+strings.Join(pngPaddings, ", "))
+	manifestFlag := fs.String("manifest", "", "Write a manifest of the generated files; .jsonl or .csv, comma-separated for several")
+	force := fs.Bool("force", false, "Overwrite existing files")
+	tree := fs.Bool("tree", false, "Spread files over a nested directory tree")
+	depth := fs.String("depth", "1-3", "Tree mode: depth of each branch, N or MIN-MAX")
+	fanout := fs.String("fanout", "2-4", "Tree mode: subdirectories per directory, N or MIN-MAX")
+	filesPerDir := fs.String("files-per-dir", "1-10", "Tree mode: files per directory, N or MIN-MAX")
+	dirNames := fs.String("dir-names", "words", "Tree mode: directory names, words or random")
+	fs.Usage = func() { printUsage(fs) }
+
 	// Check command line arguments
-	if len(os.Args) < 3 {
-		fmt.Println("Usage: generator <number_of_files> <max_size_kb> [extensions]")
-		fmt.Println("  number_of_files: Total number of files to generate")
-		fmt.Println("  max_size_kb: Maximum size of each file in KB (minimum is 1KB)")
-		fmt.Println("  extensions: Comma-separated list of extensions (optional)")
-		fmt.Printf("  Supported extensions: %s\n", strings.Join(SupportedExtensions(), ", "))
-		fmt.Println("\nExample: generator 100 100 txt,csv,json")
+	args, err := parseArgs(fs, os.Args[1:])
+	if err != nil {
+		os.Exit(1)
+	}
+	if len(args) < 2 {
+		printUsage(fs)
 		os.Exit(1)
 	}
 
 	// Parse arguments
-	numFiles, err := strconv.Atoi(os.Args[1])
-	if err != nil || numFiles <= 0 {
-		fmt.Printf("Error: Invalid number of files '%s'. Must be a positive integer.\n", os.Args[1])
+	numFiles, err := strconv.Atoi(args[0])
+	if err != nil || numFiles < 0 || (numFiles == 0 && *totalFlag == "") {
+		fmt.Printf("Error: Invalid number of files '%s'. Must be a positive integer.\n", args[0])
 		os.Exit(1)
 	}
 
-	maxSizeKB, err := strconv.Atoi(os.Args[2])
-	if err != nil || maxSizeKB < minSizeKB {
-		fmt.Printf("Error: Invalid max size '%s'. Must be at least %d KB.\n", os.Args[2], minSizeKB)
+	var totalSize int64
+	if *totalFlag != "" {
+		totalSize, err = parseSize(*totalFlag)
+		if err != nil || totalSize < 1 {
+			fmt.Printf("Error: Invalid total size '%s'. Must be at least 1 byte.\n", *totalFlag)
+			os.Exit(1)
+		}
+	}
+
+	minSize, err := parseSize(*minSizeFlag)
+	if err != nil || minSize < 1 {
+		fmt.Printf("Error: Invalid min size '%s'. Must be at least 1 byte.\n", *minSizeFlag)
+		os.Exit(1)
+	}
+
+	maxSize, err := parseSize(args[1])
+	if err != nil || maxSize < minSize {
+		fmt.Printf("Error: Invalid max size '%s'. Must be at least %s.\n", args[1], formatSize(minSize))
+		os.Exit(1)
+	}
+
+	sizes, err := newSizeDist(*distFlag, minSize, maxSize)
+	if err != nil {
+		fmt.Printf("Error: Invalid size distribution: %v\n", err)
 		os.Exit(1)
 	}
 
 	// Parse extensions (use all if not specified)
 	extensions := SupportedExtensions()
-	if len(os.Args) >= 4 {
-		extensions = strings.Split(os.Args[3], ",")
+	if len(args) >= 3 {
+		extensions = strings.Split(args[2], ",")
 		for i := range extensions {
 			extensions[i] = strings.TrimSpace(extensions[i])
 		}
 	}
 
-	// Generate files with random extensions
-	fmt.Printf("Generating %d files with random sizes between %d KB and %d KB...\n",
-		numFiles, minSizeKB, maxSizeKB)
+	// Use the given seed, or pick one so the run can still be replayed
+	seed := rand.Uint64()
+	if *seedFlag != "" {
+		seed, err = strconv.ParseUint(*seedFlag, 10, 64)
+		if err != nil {
+			fmt.Printf("Error: Invalid seed '%s'. Must be a non-negative integer.\n", *seedFlag)
+			os.Exit(1)
+		}
+	}
 
-	for i := 1; i <= numFiles; i++ {
-		// Pick a random extension
-		ext := extensions[rand.IntN(len(extensions))]
-		generator := NewGenerator(ext)
+	if *workers < 1 {
+		fmt.Printf("Error: Invalid number of workers %d. Must be at least 1.\n", *workers)
+		os.Exit(1)
+	}
 
-		// Generate random size between minSizeKB and maxSizeKB
-		var fileSizeKB int
-		if maxSizeKB == minSizeKB {
-			fileSizeKB = minSizeKB
-		} else {
-			fileSizeKB = minSizeKB + rand.IntN(maxSizeKB-minSizeKB+1)
-		}
-		fileSizeBytes := fileSizeKB * 1024
+	names, err := parseNameTemplate(*nameFlag)
+	if err != nil {
+		fmt.Printf("Error: Invalid name template: %v\n", err)
+		os.Exit(1)
+	}
 
-		// Create filename
-		filename := fmt.Sprintf("file_%d.%s", i, generator.Extension())
+	var manifests []string
+	if *manifestFlag != "" {
+		for _, path := range strings.Split(*manifestFlag, ",") {
+			path = strings.TrimSpace(path)
+			if err := checkManifestPath(path); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			manifests = append(manifests, path)
+		}
+	}
 
-		// Generate content using the appropriate generator
-		content, err := generator.Generate(fileSizeBytes)
-		if err != nil {
-			fmt.Printf("Error generating content for %s: %v\n", filename, err)
-			continue
+	exts := uniformExtensions(extensions, sizes)
+	if *pngPadding != "text" {
+		options, _ := json.Marshal(map[string]string{"padding": *pngPadding})
+		if err := configureGenerator(&PngGenerator{}, options); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		for i := range exts {
+			if exts[i].Name == "png" {
+				exts[i].Options = options
+			}
 		}
+	}
 
-		// Write file
-		err = os.WriteFile(filename, content, 0644)
+	cfg := &Config{
+		NumFiles:   numFiles,
+		Extensions: exts,
+		Seed:       seed,
+		Only:       *only,
+		Workers:    *workers,
+		ExactSize:  *exactSize || totalSize > 0, // the budget is only met with exact sizes
+		OutDir:     *outDir,
+		Names:      names,
+		Force:      *force,
+		TotalSize:  totalSize,
+		Manifests:  manifests,
+	}
+	if *tree {
+		cfg.Tree, err = parseTreeOptions(*depth, *fanout, *filesPerDir, *dirNames)
 		if err != nil {
-			fmt.Printf("Error creating file %s: %v\n", filename, err)
-			continue
+			fmt.Printf("Error: Invalid tree options: %v\n", err)
+			os.Exit(1)
 		}
-
-		fmt.Printf("Created %s (size: %d KB)\n", filename, len(content)/1024)
 	}
 
-	fmt.Println("\nFile generation completed!")
+	if err := execute(cfg); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSeedReproducible(t *testing.T) {
+	for _, ext := range SupportedExtensions() {
+		t.Run(ext, func(t *testing.T) {
+			generate := func(seed uint64, index int) []byte {
+				data, err := NewGenerator(ext).Generate(newFileRand(seed, index), 20*1024)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return data
+			}
+
+			first := generate(42, 7)
+			if !bytes.Equal(first, generate(42, 7)) {
+				t.Error("same seed and index generated different content")
+			}
+			if bytes.Equal(first, generate(42, 8)) {
+				t.Error("different indices generated the same content")
+			}
+			if bytes.Equal(first, generate(43, 7)) {
+				t.Error("different seeds generated the same content")
+			}
+		})
+	}
+}
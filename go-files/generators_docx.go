@@ -0,0 +1,464 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"image/png"
+	"io"
+	"math/rand/v2"
+	"strings"
+)
+
+// DocxGenerator generates valid DOCX files (Office Open XML) that look like
+// real Word documents: a title and numbered headings, paragraphs with bold
+// and italic runs, bulleted and numbered lists, tables, a header and
+// footer, and pixel art animals embedded as PNG images
+type DocxGenerator struct {
+	OfficeProperties
+
+	props   officeProperties // the properties of the last generated document
+	animals []string         // its embedded images
+}
+
+// Document layout
+const (
+	docxMaxImages   = 8         // distinct images in word/media; later figures reuse them
+	docxImageBytes  = 32 * 1024 // document size per embedded image
+	docxImagePixels = 128       // width and height of an image in pixels
+	docxImageEMU    = 1828800   // width and height of an image on the page (2 inches)
+	docxTableWidth  = 9000      // table width in twentieths of a point
+	docxBulletNum   = 1         // w:numId of bulleted lists; numbered lists follow
+	docxImageRel    = 10        // relationship id of the first image
+	docxMainNS      = `xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"`
+	docxRelsNS      = `xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"`
+	docxDrawingNS   = `xmlns:wp="http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing"`
+)
+
+// docxTableColumns are the person data columns of tables
+var docxTableColumns = []string{"name", "department", "city", "salary"}
+
+func (g *DocxGenerator) Extension() string {
+	return "docx"
+}
+
+// Metadata reports the document properties and the animals of the
+// embedded images
+func (g *DocxGenerator) Metadata() map[string]any {
+	metadata := map[string]any{"images": len(g.animals), "animals": g.animals}
+	g.props.addMetadata(metadata)
+	return metadata
+}
+
+func (g *DocxGenerator) Generate(r *rand.Rand, sizeBytes int) ([]byte, error) {
+	return generateBuffered(g, r, sizeBytes)
+}
+
+func (g *DocxGenerator) GenerateTo(w io.Writer, r *rand.Rand, sizeBytes int64) (int64, error) {
+	images, err := g.prepare(r, sizeBytes)
+	if err != nil {
+		return 0, err
+	}
+	return g.writePackage(w, zip.Deflate, r, images, func(body *sizedWriter, doc *docxBody) {
+		// Add blocks until we reach target size
+		for body.Len() < sizeBytes/2 && body.err == nil {
+			doc.write(body, doc.block())
+		}
+	})
+}
+
+// GenerateExactTo stores word/document.xml and word/numbering.xml
+// uncompressed, so every byte of body, and of the numbering instance each
+// numbered list adds, adds exactly one byte to the package. The package
+// overhead is measured first by writing it with an empty body.
+func (g *DocxGenerator) GenerateExactTo(w io.Writer, r *rand.Rand, sizeBytes int64) (int64, error) {
+	images, err := g.prepare(r, sizeBytes)
+	if err != nil {
+		return 0, err
+	}
+	overhead, err := g.writePackage(io.Discard, zip.Store, r, images, func(*sizedWriter, *docxBody) {})
+	if err != nil {
+		return 0, err
+	}
+	return g.writePackage(w, zip.Store, r, images, func(body *sizedWriter, doc *docxBody) {
+		budget := sizeBytes - overhead
+		for body.err == nil {
+			block := doc.block()
+			if body.Len()+doc.numbering+block.size() > budget {
+				break
+			}
+			doc.write(body, block)
+		}
+		writeRepeated(body, ' ', budget-doc.numbering-body.Len())
+	})
+}
+
+// prepare picks the document properties and draws the images, which are
+// shared by both passes of GenerateExactTo. Larger documents get more
+// distinct images.
+func (g *DocxGenerator) prepare(r *rand.Rand, sizeBytes int64) ([]docxImage, error) {
+	g.props = g.resolve(r, docxHeadingText(r))
+	images := make([]docxImage, min(docxMaxImages, int(sizeBytes/docxImageBytes)))
+	g.animals = g.animals[:0]
+	for i := range images {
+		animal := GetRandomAnimal(r)
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, drawAnimal(animal, randomPastelColor(r), docxImagePixels, docxImagePixels)); err != nil {
+			return nil, err
+		}
+		images[i] = docxImage{animal: animal.Name, data: buf.Bytes()}
+		g.animals = append(g.animals, animal.Name)
+	}
+	return images, nil
+}
+
+// docxImage is a PNG in word/media
+type docxImage struct {
+	animal string
+	data   []byte
+}
+
+// writePackage writes the DOCX package. fill writes the blocks of
+// word/document.xml, which is compressed with the given method like
+// word/numbering.xml, whose numbering instances depend on the blocks.
+func (g *DocxGenerator) writePackage(w io.Writer, method uint16, r *rand.Rand, images []docxImage, fill func(body *sizedWriter, doc *docxBody)) (int64, error) {
+	title := xmlEscape(g.props.title)
+	sw := newSizedWriter(w, -1)
+	zipWriter := zip.NewWriter(sw)
+
+	// [Content_Types].xml
+	contentTypes := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+  <Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+  <Default Extension="xml" ContentType="application/xml"/>
+  <Default Extension="png" ContentType="image/png"/>
+  <Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>
+  <Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>
+  <Override PartName="/word/numbering.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.numbering+xml"/>
+  <Override PartName="/word/header1.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.header+xml"/>
+  <Override PartName="/word/footer1.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.footer+xml"/>` + officePropsContentTypes + `
+</Types>`
+	err := writeZipFile(zipWriter, "[Content_Types].xml", contentTypes)
+
+	// _rels/.rels
+	rels := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>` + officePropsRelationships + `
+</Relationships>`
+	if err == nil {
+		err = writeZipFile(zipWriter, "_rels/.rels", rels)
+	}
+	if err == nil {
+		err = writeDocProps(zipWriter, &g.props, "Microsoft Office Word", `
+  <Template>Normal.dotm</Template>`)
+	}
+
+	if err == nil {
+		err = writeZipFile(zipWriter, "word/styles.xml", docxStyles)
+	}
+	if err == nil {
+		err = writeZipFile(zipWriter, "word/header1.xml", fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:hdr %s>
+  <w:p><w:pPr><w:pStyle w:val="Header"/></w:pPr><w:r><w:t>%s</w:t></w:r></w:p>
+</w:hdr>`, docxMainNS, title))
+	}
+	if err == nil {
+		err = writeZipFile(zipWriter, "word/footer1.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:ftr `+docxMainNS+`>
+  <w:p><w:pPr><w:pStyle w:val="Footer"/><w:jc w:val="center"/></w:pPr><w:r><w:t xml:space="preserve">Page </w:t></w:r><w:r><w:fldChar w:fldCharType="begin"/></w:r><w:r><w:instrText xml:space="preserve"> PAGE </w:instrText></w:r><w:r><w:fldChar w:fldCharType="separate"/></w:r><w:r><w:t>1</w:t></w:r><w:r><w:fldChar w:fldCharType="end"/></w:r></w:p>
+</w:ftr>`)
+	}
+	if err != nil {
+		return sw.Len(), err
+	}
+
+	// word/media, stored since PNG is already compressed
+	var docRels strings.Builder
+	docRels.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+  <Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/numbering" Target="numbering.xml"/>
+  <Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/header" Target="header1.xml"/>
+  <Relationship Id="rId4" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/footer" Target="footer1.xml"/>`)
+	for i, img := range images {
+		mediaWriter, err := zipWriter.CreateHeader(&zip.FileHeader{Name: fmt.Sprintf("word/media/image%d.png", i+1), Method: zip.Store})
+		if err != nil {
+			return sw.Len(), err
+		}
+		if _, err := mediaWriter.Write(img.data); err != nil {
+			return sw.Len(), err
+		}
+		fmt.Fprintf(&docRels, `
+  <Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/image" Target="media/image%d.png"/>`,
+			docxImageRel+i, i+1)
+	}
+	docRels.WriteString("\n</Relationships>")
+	if err := writeZipFile(zipWriter, "word/_rels/document.xml.rels", docRels.String()); err != nil {
+		return sw.Len(), err
+	}
+
+	// Generate document content, streamed straight into the zip entry
+	docWriter, err := zipWriter.CreateHeader(&zip.FileHeader{Name: "word/document.xml", Method: method})
+	if err != nil {
+		return sw.Len(), err
+	}
+	_, err = fmt.Fprintf(docWriter, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document %s %s %s>
+  <w:body>
+    <w:p><w:pPr><w:pStyle w:val="Title"/></w:pPr><w:r><w:t>%s</w:t></w:r></w:p>`, docxMainNS, docxRelsNS, docxDrawingNS, title)
+	if err != nil {
+		return sw.Len(), err
+	}
+
+	doc := &docxBody{r: r, images: images}
+	body := newSizedWriter(docWriter, -1)
+	fill(body, doc)
+	if body.err != nil {
+		return sw.Len(), body.err
+	}
+
+	_, err = io.WriteString(docWriter, `
+    <w:sectPr><w:headerReference w:type="default" r:id="rId3"/><w:footerReference w:type="default" r:id="rId4"/><w:pgSz w:w="12240" w:h="15840"/><w:pgMar w:top="1440" w:right="1440" w:bottom="1440" w:left="1440" w:header="720" w:footer="720" w:gutter="0"/></w:sectPr>
+  </w:body>
+</w:document>`)
+	if err != nil {
+		return sw.Len(), err
+	}
+
+	// word/numbering.xml: one instance for bullets and one per numbered
+	// list, so every list starts at 1
+	numWriter, err := zipWriter.CreateHeader(&zip.FileHeader{Name: "word/numbering.xml", Method: method})
+	if err != nil {
+		return sw.Len(), err
+	}
+	_, err = io.WriteString(numWriter, docxAbstractNumbering)
+	for list := 1; list <= doc.lists && err == nil; list++ {
+		_, err = io.WriteString(numWriter, docxNumberedList(list))
+	}
+	if err == nil {
+		_, err = io.WriteString(numWriter, "\n</w:numbering>")
+	}
+	if err != nil {
+		return sw.Len(), err
+	}
+
+	err = zipWriter.Close()
+	return sw.Len(), err
+}
+
+// docxBody generates the blocks of the document body and counts what the
+// other parts depend on
+type docxBody struct {
+	r      *rand.Rand
+	images []docxImage
+
+	lists     int   // numbered lists written, each with its own numbering instance
+	numbering int64 // bytes their numbering instances add to word/numbering.xml
+	blocks    int   // blocks generated
+	sections  int   // level 1 headings
+	tables    int
+	figures   int
+}
+
+// docxBlock is a heading, paragraph, list, table or figure
+type docxBlock struct {
+	xml      string
+	numbered bool // a numbered list, which needs a numbering instance
+	numId    int
+}
+
+// size returns the bytes the block adds to the package when stored
+func (b docxBlock) size() int64 {
+	if b.numbered {
+		return int64(len(b.xml) + len(docxNumberedList(b.numId-docxBulletNum)))
+	}
+	return int64(len(b.xml))
+}
+
+// write writes a block generated by block
+func (d *docxBody) write(body *sizedWriter, b docxBlock) {
+	body.WriteString(b.xml)
+	if b.numbered {
+		d.lists++
+		d.numbering += int64(len(docxNumberedList(d.lists)))
+	}
+}
+
+// block generates the next block. Sections start with a level 1 heading
+// and have subsections with level 2 and sometimes level 3 headings.
+func (d *docxBody) block() docxBlock {
+	r := d.r
+	d.blocks++
+	switch {
+	case d.blocks%12 == 1:
+		d.sections++
+		return docxBlock{xml: docxStyled("Heading1", fmt.Sprintf("%d %s", d.sections, docxHeadingText(r)))}
+	case d.blocks%4 == 1:
+		style := "Heading2"
+		if r.IntN(3) == 0 {
+			style = "Heading3"
+		}
+		return docxBlock{xml: docxStyled(style, docxHeadingText(r))}
+	}
+
+	switch n := r.IntN(20); {
+	case n < 11:
+		return docxBlock{xml: docxRichParagraph(r)}
+	case n < 13:
+		return docxBlock{xml: docxList(r, docxBulletNum)}
+	case n < 15:
+		numId := docxBulletNum + d.lists + 1
+		return docxBlock{xml: docxList(r, numId), numbered: true, numId: numId}
+	case n < 17 || len(d.images) == 0:
+		d.tables++
+		return docxBlock{xml: docxTable(r) + docxStyled("Caption", fmt.Sprintf("Table %d: %s", d.tables, docxHeadingText(r)))}
+	default:
+		// Every image is shown once before any is shown again
+		d.figures++
+		index := d.figures - 1
+		if index >= len(d.images) {
+			index = r.IntN(len(d.images))
+		}
+		return docxBlock{xml: docxDrawing(d.figures, index, d.images[index].animal) +
+			docxStyled("Caption", fmt.Sprintf("Figure %d: %s", d.figures, d.images[index].animal))}
+	}
+}
+
+func docxHeadingText(r *rand.Rand) string {
+	return strings.Title(randomWord(r) + " " + randomWord(r))
+}
+
+// docxStyled is a paragraph of the given style with a single run
+func docxStyled(style, text string) string {
+	return fmt.Sprintf("\n    <w:p><w:pPr><w:pStyle w:val=\"%s\"/></w:pPr><w:r><w:t>%s</w:t></w:r></w:p>", style, text)
+}
+
+// docxRichParagraph is a paragraph of sentences, some of them bold,
+// italic or both
+func docxRichParagraph(r *rand.Rand) string {
+	var b strings.Builder
+	b.WriteString("\n    <w:p>")
+	for i := 3 + r.IntN(5); i > 0; i-- {
+		b.WriteString(docxRun(r, randomSentence(r)+" "))
+	}
+	b.WriteString("</w:p>")
+	return b.String()
+}
+
+// docxRun is a run of text, formatted at random
+func docxRun(r *rand.Rand, text string) string {
+	props := ""
+	switch r.IntN(10) {
+	case 0:
+		props = "<w:rPr><w:b/></w:rPr>"
+	case 1:
+		props = "<w:rPr><w:i/></w:rPr>"
+	case 2:
+		props = "<w:rPr><w:b/><w:i/></w:rPr>"
+	}
+	return "<w:r>" + props + "<w:t xml:space=\"preserve\">" + text + "</w:t></w:r>"
+}
+
+// docxList is a list of items with numbering instance numId, some of them
+// indented to the second level
+func docxList(r *rand.Rand, numId int) string {
+	var b strings.Builder
+	for i := 3 + r.IntN(4); i > 0; i-- {
+		level := 0
+		if b.Len() > 0 && r.IntN(4) == 0 {
+			level = 1
+		}
+		fmt.Fprintf(&b, "\n    <w:p><w:pPr><w:pStyle w:val=\"ListParagraph\"/><w:numPr><w:ilvl w:val=\"%d\"/><w:numId w:val=\"%d\"/></w:numPr></w:pPr>%s</w:p>",
+			level, numId, docxRun(r, strings.TrimSuffix(randomSentence(r), ".")))
+	}
+	return b.String()
+}
+
+// docxTable is a table of person records with a repeated header row
+func docxTable(r *rand.Rand) string {
+	colWidth := docxTableWidth / len(docxTableColumns)
+	cell := func(text string, bold bool) string {
+		props := ""
+		if bold {
+			props = "<w:rPr><w:b/></w:rPr>"
+		}
+		return fmt.Sprintf("<w:tc><w:tcPr><w:tcW w:w=\"%d\" w:type=\"dxa\"/></w:tcPr><w:p><w:r>%s<w:t>%s</w:t></w:r></w:p></w:tc>", colWidth, props, text)
+	}
+
+	var b strings.Builder
+	b.WriteString("\n    <w:tbl><w:tblPr><w:tblStyle w:val=\"TableGrid\"/><w:tblW w:w=\"0\" w:type=\"auto\"/></w:tblPr><w:tblGrid>")
+	for range docxTableColumns {
+		fmt.Fprintf(&b, "<w:gridCol w:w=\"%d\"/>", colWidth)
+	}
+	b.WriteString("</w:tblGrid>\n      <w:tr><w:trPr><w:tblHeader/></w:trPr>")
+	for _, col := range docxTableColumns {
+		b.WriteString(cell(strings.Title(col), true))
+	}
+	b.WriteString("</w:tr>")
+	for i := 2 + r.IntN(6); i > 0; i-- {
+		b.WriteString("\n      <w:tr>")
+		for _, col := range docxTableColumns {
+			b.WriteString(cell(csvColumns[col](r, i), false))
+		}
+		b.WriteString("</w:tr>")
+	}
+	b.WriteString("\n    </w:tbl>")
+	return b.String()
+}
+
+// docxDrawing is a paragraph with image index of word/media shown inline.
+// id numbers the drawings of the document.
+func docxDrawing(id, index int, animal string) string {
+	return fmt.Sprintf(`
+    <w:p><w:pPr><w:jc w:val="center"/></w:pPr><w:r><w:drawing><wp:inline distT="0" distB="0" distL="0" distR="0"><wp:extent cx="%[1]d" cy="%[1]d"/><wp:docPr id="%[2]d" name="Picture %[2]d" descr="%[3]s"/>`+
+		`<a:graphic xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main"><a:graphicData uri="http://schemas.openxmlformats.org/drawingml/2006/picture">`+
+		`<pic:pic xmlns:pic="http://schemas.openxmlformats.org/drawingml/2006/picture"><pic:nvPicPr><pic:cNvPr id="%[2]d" name="image%[4]d.png"/><pic:cNvPicPr/></pic:nvPicPr>`+
+		`<pic:blipFill><a:blip r:embed="rId%[5]d"/><a:stretch><a:fillRect/></a:stretch></pic:blipFill>`+
+		`<pic:spPr><a:xfrm><a:off x="0" y="0"/><a:ext cx="%[1]d" cy="%[1]d"/></a:xfrm><a:prstGeom prst="rect"><a:avLst/></a:prstGeom></pic:spPr></pic:pic>`+
+		`</a:graphicData></a:graphic></wp:inline></w:drawing></w:r></w:p>`,
+		docxImageEMU, id, animal, index+1, docxImageRel+index)
+}
+
+// docxNumberedList is the numbering instance of the list-th numbered list,
+// which restarts the decimal numbering
+func docxNumberedList(list int) string {
+	return fmt.Sprintf(`
+  <w:num w:numId="%d"><w:abstractNumId w:val="1"/><w:lvlOverride w:ilvl="0"><w:startOverride w:val="1"/></w:lvlOverride></w:num>`,
+		docxBulletNum+list)
+}
+
+// docxAbstractNumbering is word/numbering.xml up to the numbering
+// instances of the numbered lists: bullets (abstract 0, instance 1) and
+// decimal numbers (abstract 1)
+const docxAbstractNumbering = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:numbering ` + docxMainNS + `>
+  <w:abstractNum w:abstractNumId="0"><w:multiLevelType w:val="hybridMultilevel"/>
+    <w:lvl w:ilvl="0"><w:start w:val="1"/><w:numFmt w:val="bullet"/><w:lvlText w:val="•"/><w:lvlJc w:val="left"/><w:pPr><w:ind w:left="720" w:hanging="360"/></w:pPr></w:lvl>
+    <w:lvl w:ilvl="1"><w:start w:val="1"/><w:numFmt w:val="bullet"/><w:lvlText w:val="o"/><w:lvlJc w:val="left"/><w:pPr><w:ind w:left="1440" w:hanging="360"/></w:pPr></w:lvl>
+  </w:abstractNum>
+  <w:abstractNum w:abstractNumId="1"><w:multiLevelType w:val="hybridMultilevel"/>
+    <w:lvl w:ilvl="0"><w:start w:val="1"/><w:numFmt w:val="decimal"/><w:lvlText w:val="%1."/><w:lvlJc w:val="left"/><w:pPr><w:ind w:left="720" w:hanging="360"/></w:pPr></w:lvl>
+    <w:lvl w:ilvl="1"><w:start w:val="1"/><w:numFmt w:val="lowerLetter"/><w:lvlText w:val="%2."/><w:lvlJc w:val="left"/><w:pPr><w:ind w:left="1440" w:hanging="360"/></w:pPr></w:lvl>
+  </w:abstractNum>
+  <w:num w:numId="1"><w:abstractNumId w:val="0"/></w:num>`
+
+// docxStyles is word/styles.xml: the default fonts, and the paragraph,
+// character and table styles the body uses
+const docxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:styles ` + docxMainNS + `>
+  <w:docDefaults>
+    <w:rPrDefault><w:rPr><w:rFonts w:ascii="Calibri" w:hAnsi="Calibri" w:eastAsia="Calibri" w:cs="Calibri"/><w:sz w:val="22"/><w:szCs w:val="22"/><w:lang w:val="en-US"/></w:rPr></w:rPrDefault>
+    <w:pPrDefault><w:pPr><w:spacing w:after="160" w:line="259" w:lineRule="auto"/></w:pPr></w:pPrDefault>
+  </w:docDefaults>
+  <w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/><w:qFormat/></w:style>
+  <w:style w:type="paragraph" w:styleId="Title"><w:name w:val="Title"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:spacing w:after="240"/></w:pPr><w:rPr><w:sz w:val="56"/><w:szCs w:val="56"/></w:rPr></w:style>
+  <w:style w:type="paragraph" w:styleId="Heading1"><w:name w:val="heading 1"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="360" w:after="120"/><w:outlineLvl w:val="0"/></w:pPr><w:rPr><w:b/><w:color w:val="2F5496"/><w:sz w:val="32"/><w:szCs w:val="32"/></w:rPr></w:style>
+  <w:style w:type="paragraph" w:styleId="Heading2"><w:name w:val="heading 2"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="240" w:after="80"/><w:outlineLvl w:val="1"/></w:pPr><w:rPr><w:b/><w:color w:val="2F5496"/><w:sz w:val="26"/><w:szCs w:val="26"/></w:rPr></w:style>
+  <w:style w:type="paragraph" w:styleId="Heading3"><w:name w:val="heading 3"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="160" w:after="40"/><w:outlineLvl w:val="2"/></w:pPr><w:rPr><w:b/><w:color w:val="1F3763"/><w:sz w:val="24"/><w:szCs w:val="24"/></w:rPr></w:style>
+  <w:style w:type="paragraph" w:styleId="ListParagraph"><w:name w:val="List Paragraph"/><w:basedOn w:val="Normal"/><w:qFormat/><w:pPr><w:spacing w:after="40"/><w:ind w:left="720"/><w:contextualSpacing/></w:pPr></w:style>
+  <w:style w:type="paragraph" w:styleId="Caption"><w:name w:val="caption"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:jc w:val="center"/></w:pPr><w:rPr><w:i/><w:color w:val="44546A"/><w:sz w:val="18"/><w:szCs w:val="18"/></w:rPr></w:style>
+  <w:style w:type="paragraph" w:styleId="Header"><w:name w:val="header"/><w:basedOn w:val="Normal"/><w:pPr><w:spacing w:after="0"/></w:pPr><w:rPr><w:color w:val="808080"/><w:sz w:val="18"/></w:rPr></w:style>
+  <w:style w:type="paragraph" w:styleId="Footer"><w:name w:val="footer"/><w:basedOn w:val="Normal"/><w:pPr><w:spacing w:after="0"/></w:pPr><w:rPr><w:color w:val="808080"/><w:sz w:val="18"/></w:rPr></w:style>
+  <w:style w:type="table" w:default="1" w:styleId="TableNormal"><w:name w:val="Normal Table"/><w:tblPr><w:tblInd w:w="0" w:type="dxa"/><w:tblCellMar><w:left w:w="108" w:type="dxa"/><w:right w:w="108" w:type="dxa"/></w:tblCellMar></w:tblPr></w:style>
+  <w:style w:type="table" w:styleId="TableGrid"><w:name w:val="Table Grid"/><w:basedOn w:val="TableNormal"/><w:pPr><w:spacing w:after="0"/></w:pPr><w:tblPr><w:tblBorders><w:top w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:left w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:bottom w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:right w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:insideH w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:insideV w:val="single" w:sz="4" w:space="0" w:color="auto"/></w:tblBorders></w:tblPr></w:style>
+</w:styles>`
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"image/png"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var (
+	docxStyleRefPattern = regexp.MustCompile(`<w:(?:pStyle|tblStyle) w:val="([^"]+)"/>`)
+	docxStylePattern    = regexp.MustCompile(`w:styleId="([^"]+)"`)
+	docxNumIdPattern    = regexp.MustCompile(`<w:numId w:val="(\d+)"/>`)
+	docxNumPattern      = regexp.MustCompile(`<w:num w:numId="(\d+)"><w:abstractNumId w:val="(\d+)"/>`)
+	docxAbstractPattern = regexp.MustCompile(`<w:abstractNum w:abstractNumId="(\d+)">`)
+)
+
+func TestDocxPackage(t *testing.T) {
+	g := &DocxGenerator{}
+	testOoxml(t, g, 400*1024, func(t *testing.T, pkg ooxmlPackage, contentTypes map[string]string) {
+		doc := pkg["word/document.xml"]
+		if !strings.HasSuffix(contentTypes["word/document.xml"], "wordprocessingml.document.main+xml") {
+			t.Errorf("word/document.xml has content type %q", contentTypes["word/document.xml"])
+		}
+
+		// The images in word/media are the ones the metadata lists, and the
+		// document shows each of them
+		images := 0
+		for target := range pkg {
+			if !strings.HasPrefix(target, "word/media/") {
+				continue
+			}
+			images++
+			if _, err := png.DecodeConfig(strings.NewReader(pkg[target])); err != nil {
+				t.Errorf("%s: %v", target, err)
+			}
+		}
+		if want := g.Metadata()["images"]; images != want || images == 0 {
+			t.Errorf("%d images in word/media, metadata says %v", images, want)
+		}
+		for id, target := range pkg.rels(t, "word/document.xml") {
+			if strings.HasPrefix(target, "word/media/") && !strings.Contains(doc, `r:embed="`+id+`"`) {
+				t.Errorf("%s is never shown", target)
+			}
+		}
+
+		// Styles and numbering instances are defined
+		styles := make(map[string]bool)
+		for _, m := range docxStylePattern.FindAllStringSubmatch(pkg["word/styles.xml"], -1) {
+			styles[m[1]] = true
+		}
+		for _, part := range []string{"word/document.xml", "word/header1.xml", "word/footer1.xml"} {
+			for _, m := range docxStyleRefPattern.FindAllStringSubmatch(pkg[part], -1) {
+				if !styles[m[1]] {
+					t.Errorf("%s uses the undefined style %s", part, m[1])
+				}
+			}
+		}
+		numbering := pkg["word/numbering.xml"]
+		abstract := make(map[string]bool)
+		for _, m := range docxAbstractPattern.FindAllStringSubmatch(numbering, -1) {
+			abstract[m[1]] = true
+		}
+		nums := make(map[string]bool)
+		for _, m := range docxNumPattern.FindAllStringSubmatch(numbering, -1) {
+			nums[m[1]] = true
+			if !abstract[m[2]] {
+				t.Errorf("numbering instance %s uses the undefined abstract numbering %s", m[1], m[2])
+			}
+		}
+		lists := docxNumIdPattern.FindAllStringSubmatch(doc, -1)
+		if len(lists) == 0 {
+			t.Error("the document has no lists")
+		}
+		for _, m := range lists {
+			if !nums[m[1]] {
+				t.Errorf("list uses the undefined numbering instance %s", m[1])
+			}
+		}
+	})
+}
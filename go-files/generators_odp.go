@@ -0,0 +1,268 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"image/png"
+	"io"
+	"math/rand/v2"
+	"strings"
+)
+
+// OdpGenerator generates valid ODP files (OpenDocument presentation) with
+// the deck of PptxGenerator: a title slide, section title slides, bullet
+// slides and slides with pixel art animals, each with speaker notes. The
+// size is reached with the number of slides.
+type OdpGenerator struct {
+	OfficeProperties
+
+	props   officeProperties // the properties of the last generated deck
+	cover   string           // its title slide
+	slides  int              // its slides
+	animals []string         // its embedded images
+}
+
+// Deck layout. Slides are 16:9, 28cm by 15.75cm, and frames are placed
+// in cm.
+const (
+	odpImageSize = "10.16cm" // width and height of an image on a slide (4 inches)
+	odpTitleArea = `svg:x="1.4cm" svg:y="0.63cm" svg:width="25.2cm" svg:height="2.63cm"`
+	odpBodyArea  = `svg:x="1.4cm" svg:y="3.69cm" svg:width="25.2cm" svg:height="10.4cm"`
+	odpCoverArea = `svg:x="2.1cm" svg:y="2.58cm" svg:width="23.8cm" svg:height="5.48cm"` // title of a title slide
+	odpSubArea   = `svg:x="3.5cm" svg:y="8.27cm" svg:width="21cm" svg:height="3.8cm"`    // its subtitle
+	odpImageArea = `svg:x="8.92cm" svg:y="3.69cm" svg:width="` + odpImageSize + `" svg:height="` + odpImageSize + `"`
+	odpThumbArea = `svg:x="2.1cm" svg:y="2.24cm" svg:width="16.8cm" svg:height="9.45cm"` // slide on its notes page
+	odpNotesArea = `svg:x="2.1cm" svg:y="13.21cm" svg:width="16.8cm" svg:height="13.6cm"`
+)
+
+func (g *OdpGenerator) Extension() string {
+	return "odp"
+}
+
+// Metadata reports the document properties, the number of slides, and the
+// animals of the embedded images
+func (g *OdpGenerator) Metadata() map[string]any {
+	metadata := map[string]any{"slides": g.slides, "images": len(g.animals), "animals": g.animals}
+	g.props.addMetadata(metadata)
+	return metadata
+}
+
+func (g *OdpGenerator) Generate(r *rand.Rand, sizeBytes int) ([]byte, error) {
+	return generateBuffered(g, r, sizeBytes)
+}
+
+// GenerateTo compresses the slides in content.xml until the package
+// reaches the target size. The package overhead is measured first by
+// writing it with the title slide only, which every deck has.
+func (g *OdpGenerator) GenerateTo(w io.Writer, r *rand.Rand, sizeBytes int64) (int64, error) {
+	images, err := g.prepare(r, sizeBytes)
+	if err != nil {
+		return 0, err
+	}
+	overhead, err := g.writePackage(io.Discard, zip.Deflate, r, images, func(*sizedWriter, *odpDeck, func() int64) {})
+	if err != nil {
+		return 0, err
+	}
+	return g.writePackage(w, zip.Deflate, r, images, func(body *sizedWriter, deck *odpDeck, compressed func() int64) {
+		odfFill(body, deck, compressed, sizeBytes-overhead)
+	})
+}
+
+// GenerateExactTo stores content.xml uncompressed, so the size of every
+// slide is known before it is added, and pads the presentation with the
+// bytes left after the last slide that fits
+func (g *OdpGenerator) GenerateExactTo(w io.Writer, r *rand.Rand, sizeBytes int64) (int64, error) {
+	images, err := g.prepare(r, sizeBytes)
+	if err != nil {
+		return 0, err
+	}
+	overhead, err := g.writePackage(io.Discard, zip.Store, r, images, func(*sizedWriter, *odpDeck, func() int64) {})
+	if err != nil {
+		return 0, err
+	}
+	return g.writePackage(w, zip.Store, r, images, func(body *sizedWriter, deck *odpDeck, _ func() int64) {
+		odfFillExact(body, deck, sizeBytes-overhead)
+	})
+}
+
+// prepare picks the document properties, generates the title slide and
+// draws the images, which are shared by both passes of GenerateTo and
+// GenerateExactTo. Larger decks get more distinct images.
+func (g *OdpGenerator) prepare(r *rand.Rand, sizeBytes int64) ([]docxImage, error) {
+	g.props = g.resolve(r, docxHeadingText(r))
+	g.cover = (&odpDeck{r: r, props: &g.props}).next()
+	images := make([]docxImage, min(pptxMaxImages, int(sizeBytes/pptxImageBytes)))
+	g.animals = g.animals[:0]
+	for i := range images {
+		animal := GetRandomAnimal(r)
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, drawAnimal(animal, randomPastelColor(r), pptxImagePixels, pptxImagePixels)); err != nil {
+			return nil, err
+		}
+		images[i] = docxImage{animal: animal.Name, data: buf.Bytes()}
+		g.animals = append(g.animals, animal.Name)
+	}
+	return images, nil
+}
+
+// writePackage writes the ODP package. fill adds the slides after the
+// title slide to content.xml, which is compressed with the given method.
+func (g *OdpGenerator) writePackage(w io.Writer, method uint16, r *rand.Rand, images []docxImage, fill func(body *sizedWriter, deck *odpDeck, compressed func() int64)) (int64, error) {
+	pkg, err := newOdfPackage(w, "odp")
+	if err == nil {
+		err = pkg.addMeta(&g.props)
+	}
+	if err == nil {
+		err = pkg.add("styles.xml", odpStyles)
+	}
+	if err == nil {
+		err = pkg.addImages(images)
+	}
+	if err != nil {
+		return pkg.sw.Len(), err
+	}
+
+	// Generate the slides, streamed straight into the zip entry
+	contentWriter, err := pkg.create("content.xml", method)
+	if err != nil {
+		return pkg.sw.Len(), err
+	}
+	io.WriteString(contentWriter, odpContentStart+g.cover)
+
+	deck := &odpDeck{r: r, props: &g.props, images: images, slides: 1}
+	body := newSizedWriter(contentWriter, -1)
+	fill(body, deck, pkg.compressed)
+	if body.err != nil {
+		return pkg.sw.Len(), body.err
+	}
+	g.slides = deck.slides
+	io.WriteString(contentWriter, `
+  </office:presentation>
+ </office:body>
+</office:document-content>`)
+
+	return pkg.close()
+}
+
+// odpDeck generates the slides of a deck
+type odpDeck struct {
+	r      *rand.Rand
+	props  *officeProperties
+	images []docxImage
+
+	slides   int // slides written
+	sections int // section title slides generated
+	figures  int // image slides generated
+}
+
+func (d *odpDeck) write(body *sizedWriter, slide string) {
+	body.WriteString(slide)
+	d.slides++
+}
+
+// next generates the slide after the ones written: the title slide first,
+// then a section title slide at the start of every section, and bullet
+// slides with an image slide every pptxImageEvery slides
+func (d *odpDeck) next() string {
+	r := d.r
+	index := d.slides + 1
+	layout := "Content"
+	var frames string
+	switch {
+	case index == 1:
+		layout = "Title"
+		frames = odpTextFrame("title", odpCoverArea, odpParagraph(xmlEscape(d.props.title))) +
+			odpTextFrame("subtitle", odpSubArea, odpParagraph(xmlEscape(d.props.author))+odpParagraph(d.props.created.Format("January 2, 2006")))
+	case index%pptxSectionSize == 1:
+		d.sections++
+		layout = "Title"
+		frames = odpTextFrame("title", odpCoverArea, odpParagraph(fmt.Sprintf("%d. %s", d.sections, docxHeadingText(r)))) +
+			odpTextFrame("subtitle", odpSubArea, odpParagraph(strings.TrimSuffix(randomSentence(r), ".")))
+	case index%pptxImageEvery == 0 && len(d.images) > 0:
+		// Every image is shown once before any is shown again
+		d.figures++
+		layout = "TitleOnly"
+		image := d.figures - 1
+		if image >= len(d.images) {
+			image = r.IntN(len(d.images))
+		}
+		frames = odpTextFrame("title", odpTitleArea, odpParagraph(d.images[image].animal)) +
+			fmt.Sprintf(`
+     <draw:frame draw:name="Picture %d" draw:layer="layout" %s><draw:image xlink:href="Pictures/image%d.png" xlink:type="simple" xlink:show="embed" xlink:actuate="onLoad" draw:mime-type="image/png"/><svg:desc>%s</svg:desc></draw:frame>`,
+				d.figures, odpImageArea, image+1, d.images[image].animal)
+	default:
+		bullets := odfList(r, ` text:style-name="L1"`, func() string {
+			return odpParagraph(strings.TrimSuffix(randomSentence(r), "."))
+		})
+		frames = odpTextFrame("title", odpTitleArea, odpParagraph(docxHeadingText(r))) +
+			odpTextFrame("outline", odpBodyArea, bullets)
+	}
+
+	var notes strings.Builder
+	for i := 1 + r.IntN(2); i > 0; i-- {
+		notes.WriteString(odpParagraph(randomParagraph(r)))
+	}
+	return fmt.Sprintf(`
+   <draw:page draw:name="page%[1]d" draw:master-page-name="Default" presentation:presentation-page-layout-name="%[2]s">%[3]s
+     <presentation:notes><draw:page-thumbnail draw:layer="layout" %[4]s draw:page-number="%[1]d" presentation:class="page"/>%[5]s</presentation:notes>
+   </draw:page>`,
+		index, layout, frames, odpThumbArea, odpTextFrame("notes", odpNotesArea, notes.String()))
+}
+
+// odpTextFrame is a text frame of the given presentation class, with the
+// presentation style of the master for the class
+func odpTextFrame(class, area, text string) string {
+	return fmt.Sprintf(`
+     <draw:frame presentation:style-name="Default-%[1]s" draw:layer="layout" %[2]s presentation:class="%[1]s"><draw:text-box>%[3]s</draw:text-box></draw:frame>`,
+		class, area, text)
+}
+
+func odpParagraph(text string) string {
+	return "<text:p>" + text + "</text:p>"
+}
+
+// odpContentStart is content.xml up to the first slide: the automatic
+// list style of the bullets
+const odpContentStart = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content ` + odfNS + ` office:version="` + odfVersion + `">` + odfFontFaces + `
+ <office:automatic-styles>
+  <text:list-style style:name="L1">
+   <text:list-level-style-bullet text:level="1" text:bullet-char="•"><style:list-level-properties text:space-before="0.3cm" text:min-label-width="0.9cm"/></text:list-level-style-bullet>
+   <text:list-level-style-bullet text:level="2" text:bullet-char="–"><style:list-level-properties text:space-before="1.6cm" text:min-label-width="0.8cm"/></text:list-level-style-bullet>
+  </text:list-style>
+ </office:automatic-styles>
+ <office:body>
+  <office:presentation>`
+
+// odpStyles is styles.xml: the presentation styles of the title, subtitle,
+// outline and notes frames, the Title, Title and Content and Title Only
+// layouts, and the master with its notes page
+const odpStyles = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-styles ` + odfNS + ` office:version="` + odfVersion + `">` + odfFontFaces + `
+ <office:styles>
+  <style:default-style style:family="graphic"><style:graphic-properties draw:stroke="none" draw:fill="none"/><style:text-properties style:font-name="Liberation Sans" fo:font-size="18pt" fo:language="en" fo:country="US"/></style:default-style>
+  <style:style style:name="Default-title" style:family="presentation"><style:graphic-properties draw:textarea-vertical-align="middle"/><style:paragraph-properties fo:text-align="center"/><style:text-properties fo:color="#1f3864" fo:font-size="40pt"/></style:style>
+  <style:style style:name="Default-subtitle" style:family="presentation"><style:graphic-properties draw:textarea-vertical-align="top"/><style:paragraph-properties fo:text-align="center"/><style:text-properties fo:color="#595959" fo:font-size="24pt"/></style:style>
+  <style:style style:name="Default-outline" style:family="presentation"><style:paragraph-properties fo:margin-top="0.3cm"/><style:text-properties fo:font-size="28pt"/></style:style>
+  <style:style style:name="Default-notes" style:family="presentation"><style:paragraph-properties fo:margin-bottom="0.2cm"/><style:text-properties fo:font-size="12pt"/></style:style>
+  <style:presentation-page-layout style:name="Title"><presentation:placeholder presentation:object="title" ` + odpCoverArea + `/><presentation:placeholder presentation:object="subtitle" ` + odpSubArea + `/></style:presentation-page-layout>
+  <style:presentation-page-layout style:name="Content"><presentation:placeholder presentation:object="title" ` + odpTitleArea + `/><presentation:placeholder presentation:object="outline" ` + odpBodyArea + `/></style:presentation-page-layout>
+  <style:presentation-page-layout style:name="TitleOnly"><presentation:placeholder presentation:object="title" ` + odpTitleArea + `/></style:presentation-page-layout>
+ </office:styles>
+ <office:automatic-styles>
+  <style:page-layout style:name="PM1"><style:page-layout-properties fo:margin-top="0cm" fo:margin-bottom="0cm" fo:margin-left="0cm" fo:margin-right="0cm" fo:page-width="28cm" fo:page-height="15.75cm" style:print-orientation="landscape"/></style:page-layout>
+  <style:page-layout style:name="PM2"><style:page-layout-properties fo:margin-top="0cm" fo:margin-bottom="0cm" fo:margin-left="0cm" fo:margin-right="0cm" fo:page-width="21cm" fo:page-height="29.7cm" style:print-orientation="portrait"/></style:page-layout>
+  <style:style style:name="Mdp1" style:family="drawing-page"><style:drawing-page-properties draw:background-size="full" draw:fill="solid" draw:fill-color="#ffffff"/></style:style>
+ </office:automatic-styles>
+ <office:master-styles>
+  <style:master-page style:name="Default" style:page-layout-name="PM1" draw:style-name="Mdp1">
+   <draw:frame presentation:style-name="Default-title" draw:layer="backgroundobjects" ` + odpTitleArea + ` presentation:class="title" presentation:placeholder="true"><draw:text-box/></draw:frame>
+   <draw:frame presentation:style-name="Default-outline" draw:layer="backgroundobjects" ` + odpBodyArea + ` presentation:class="outline" presentation:placeholder="true"><draw:text-box/></draw:frame>
+   <presentation:notes style:page-layout-name="PM2">
+    <draw:page-thumbnail draw:layer="backgroundobjects" ` + odpThumbArea + ` presentation:class="page"/>
+    <draw:frame presentation:style-name="Default-notes" draw:layer="backgroundobjects" ` + odpNotesArea + ` presentation:class="notes" presentation:placeholder="true"><draw:text-box/></draw:frame>
+   </presentation:notes>
+  </style:master-page>
+ </office:master-styles>
+</office:document-styles>`
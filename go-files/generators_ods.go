@@ -0,0 +1,649 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OdsGenerator generates valid ODS files (OpenDocument spreadsheet) with
+// the workbook of XlsxGenerator: an employee list, a sales ledger and a
+// summary, with date, currency and percent formats, SUM, VLOOKUP and
+// cross-sheet formulas with cached values, merged title cells and frozen
+// header rows. The ledger is streamed, and continues on further sheets
+// when one is full. content.xml holds the sheets in order, so the summary
+// comes last, once the sales totals are known; the workbook opens on it.
+// Optionally, the employees and sales are database ranges with
+// autofilters.
+type OdsGenerator struct {
+	OfficeProperties
+	Tables bool `json:"tables"` // a database range over the employees and over each sales sheet
+
+	props     officeProperties // the properties of the last generated workbook
+	sheets    []string         // its sheet names
+	employees int              // its employees
+	rows      int              // its sales rows
+	netSales  string           // the cached total of its sales
+	tables    int              // its database ranges
+}
+
+// odsColumnWidths are the column widths of the sheets, in characters like
+// those of XLSX, each with a column style co<width>
+var odsColumnWidths = []int{8, 10, 12, 16, 18, 24, 32}
+
+func (g *OdsGenerator) Extension() string {
+	return "ods"
+}
+
+// Metadata reports the workbook properties, the sheets, the number of
+// employees and sales rows, the net sales total, and the tables
+func (g *OdsGenerator) Metadata() map[string]any {
+	metadata := map[string]any{"sheets": g.sheets, "employees": g.employees, "rows": g.rows, "net_sales": g.netSales}
+	g.props.addMetadata(metadata)
+	if g.Tables {
+		metadata["tables"] = g.tables
+	}
+	return metadata
+}
+
+func (g *OdsGenerator) Generate(r *rand.Rand, sizeBytes int) ([]byte, error) {
+	return generateBuffered(g, r, sizeBytes)
+}
+
+// GenerateTo compresses the sales rows until the package reaches the
+// target size. How many more rows fit is estimated from how well the rows
+// so far compressed, and measured again after writing half of them.
+func (g *OdsGenerator) GenerateTo(w io.Writer, r *rand.Rand, sizeBytes int64) (int64, error) {
+	wb := g.prepare(r, sizeBytes)
+	overhead, err := g.overhead(wb, zip.Deflate, 1)
+	if err != nil {
+		return 0, err
+	}
+	return g.writePackage(w, zip.Deflate, wb, func(sales *odsSales) {
+		target := sizeBytes - overhead
+		start, transitions := sales.compressed(), int64(0)
+		for sales.rows.err == nil {
+			written := sales.compressed() - start - transitions
+			ratio := 1.0
+			if sales.count > 0 && written > 0 {
+				ratio = float64(sales.rows.Len()) / float64(written)
+			}
+			more := int64(float64(target-written) * ratio)
+			if more <= 0 || (sales.count > 0 && more < sales.rows.Len()/int64(sales.count)) {
+				break
+			}
+			end := sales.rows.Len() + min(more/2, odfFlushBytes)
+			for sales.rows.Len() < end && sales.rows.err == nil {
+				sale := sales.next()
+				if !sale.newSheet {
+					sales.write(sale)
+					continue
+				}
+				// Every sheet adds to settings.xml, so the switch to a new
+				// sheet is measured apart from the rows
+				if overhead, err = g.overhead(wb, zip.Deflate, len(sales.sheets)+1); err != nil {
+					sales.rows.err = err
+					return
+				}
+				target = sizeBytes - overhead
+				before := sales.compressed()
+				sales.write(sale)
+				transitions += sales.compressed() - before
+				break
+			}
+		}
+	})
+}
+
+// GenerateExactTo stores content.xml uncompressed, so every byte of sales
+// rows adds exactly one byte to the package. The package overhead is
+// measured first by writing it without sales rows, and again for every
+// further sales sheet. The totals rows, the summary and the database
+// ranges grow with the digits of the row counts and net totals, which the
+// sales rows leave room for.
+func (g *OdsGenerator) GenerateExactTo(w io.Writer, r *rand.Rand, sizeBytes int64) (int64, error) {
+	wb := g.prepare(r, sizeBytes)
+	overhead, err := g.overhead(wb, zip.Store, 1)
+	if err != nil {
+		return 0, err
+	}
+	return g.writePackage(w, zip.Store, wb, func(sales *odsSales) {
+		budget := sizeBytes - overhead
+		growth := xlsxTotalsGrowth(wb.totalsSize)
+		for sales.rows.err == nil {
+			sale := sales.next()
+			fits := budget
+			if sale.newSheet {
+				more, err := g.overhead(wb, zip.Store, len(sales.sheets)+1)
+				if err != nil {
+					sales.rows.err = err
+					return
+				}
+				fits = sizeBytes - more
+			}
+			if sales.rows.Len()+int64(len(sale.xml))+growth(sales.totalsWith(sale)) > fits {
+				break
+			}
+			budget = fits
+			sales.write(sale)
+		}
+		writeRepeated(sales.rows, ' ', budget-sales.rows.Len()-growth(sales.sheets))
+	})
+}
+
+// overhead returns the size of the package with the given number of empty
+// sales sheets
+func (g *OdsGenerator) overhead(wb *odsWorkbook, method uint16, sheets int) (int64, error) {
+	return g.writePackage(io.Discard, method, wb, func(sales *odsSales) {
+		for len(sales.sheets) < sheets {
+			sales.newSheet()
+		}
+	})
+}
+
+// prepare picks the workbook properties, departments, regions and
+// employees, which are shared by all passes of GenerateTo and
+// GenerateExactTo. Larger workbooks have more employees.
+func (g *OdsGenerator) prepare(r *rand.Rand, sizeBytes int64) *odsWorkbook {
+	g.props = g.resolve(r, "")
+	wb := &odsWorkbook{r: r, props: &g.props, tables: g.Tables}
+	departments := xlsxPool(r, 3+r.IntN(4))
+	wb.regions = xlsxPool(r, 4+r.IntN(5))
+
+	wb.employees = make([]xlsxEmployee, max(3, min(xlsxMaxEmployees, int(sizeBytes/xlsxEmployeeBytes))))
+	for i := range wb.employees {
+		wb.employees[i] = xlsxEmployee{
+			name:       csvColumns["name"](r, i+1),
+			email:      csvColumns["email"](r, i+1),
+			department: departments[r.IntN(len(departments))],
+			start:      randomDate(r),
+			salary:     30000 + r.IntN(70000),
+			bonus:      r.IntN(16),
+		}
+		wb.employees[i].cached = xmlEscape(wb.employees[i].name)
+		wb.payroll += wb.employees[i].salary
+	}
+	wb.lookup = fmt.Sprintf("];[$Employees.$A$%d:.$B$%d];2;0)\" office:value-type=\"string\" office:string-value=\"",
+		xlsxFirstRow, xlsxFirstRow+len(wb.employees)-1)
+	g.employees = len(wb.employees)
+	return wb
+}
+
+// writePackage writes the ODS package. fill writes the sales rows into
+// content.xml, which is compressed with the given method and ends with the
+// summary, whose cached values depend on the sales. settings.xml, which
+// lists the sheets, is written last.
+func (g *OdsGenerator) writePackage(w io.Writer, method uint16, wb *odsWorkbook, fill func(sales *odsSales)) (int64, error) {
+	pkg, err := newOdfPackage(w, "ods")
+	if err == nil {
+		err = pkg.addMeta(&g.props)
+	}
+	if err == nil {
+		err = pkg.add("styles.xml", odsStyles)
+	}
+	if err != nil {
+		return pkg.sw.Len(), err
+	}
+
+	content, err := pkg.create("content.xml", method)
+	if err != nil {
+		return pkg.sw.Len(), err
+	}
+	io.WriteString(content, odsContentStart())
+	io.WriteString(content, wb.employeeSheet())
+
+	// The sales sheets, with the rows streamed straight into the zip entry
+	sales := &odsSales{wb: wb, content: content, compressed: pkg.compressed}
+	sales.rows = newSizedWriter(content, -1)
+	sales.newSheet()
+	fill(sales)
+	sales.closeSheet()
+	if sales.rows.err != nil {
+		return pkg.sw.Len(), sales.rows.err
+	}
+	grand := xlsxGrandTotal(sales.sheets)
+	g.sheets = odsSheetNames(len(sales.sheets))
+	g.rows, g.netSales = grand.rows, xlsxCents(grand.net)
+	g.tables = 0
+	if wb.tables {
+		g.tables = 1 + len(sales.sheets)
+	}
+
+	io.WriteString(content, wb.summarySheet(sales.sheets)+wb.databaseRanges(sales.sheets)+`
+  </office:spreadsheet>
+ </office:body>
+</office:document-content>`)
+
+	if err := pkg.add("settings.xml", odsSettings(g.sheets)); err != nil {
+		return pkg.sw.Len(), err
+	}
+	return pkg.close()
+}
+
+// odsWorkbook is what the sheets of a workbook share: the employees that
+// sales refer to
+type odsWorkbook struct {
+	r     *rand.Rand
+	props *officeProperties
+
+	employees []xlsxEmployee
+	payroll   int
+	regions   []string
+	lookup    string // the VLOOKUP of sales rows after the row number, up to the cached value
+
+	tables bool
+}
+
+// titleRow is row 1 with the sheet's title, merged across its columns
+func (wb *odsWorkbook) titleRow(title string, columns int) string {
+	return fmt.Sprintf(`
+    <table:table-row table:style-name="ro1"><table:table-cell%s table:number-columns-spanned="%d" table:number-rows-spanned="1" office:value-type="string"><text:p>%s</text:p></table:table-cell><table:covered-table-cell table:number-columns-repeated="%d"/></table:table-row>`,
+		odsStyle(xlsxStyleTitle), columns, xmlEscape(title), columns-1)
+}
+
+// headerRow is row 2 with the column labels
+func (wb *odsWorkbook) headerRow(labels ...string) string {
+	cells := make([]string, len(labels))
+	for i, label := range labels {
+		cells[i] = odsString(label, xlsxStyleHeader)
+	}
+	return odsRow(cells...)
+}
+
+// employeeSheet is the Employees sheet, with a total payroll row
+func (wb *odsWorkbook) employeeSheet() string {
+	var b strings.Builder
+	b.WriteString(odsSheetStart("Employees", []int{8, 24, 32, 16, 12, 12, 10}))
+	b.WriteString(wb.titleRow("Employees", len(xlsxEmployeeColumns)))
+	b.WriteString(wb.headerRow(xlsxEmployeeColumns...))
+	for i, e := range wb.employees {
+		b.WriteString(odsRow(
+			odsNumber(i+1),
+			odsString(e.name, xlsxStyleDefault),
+			odsString(e.email, xlsxStyleDefault),
+			odsString(e.department, xlsxStyleDefault),
+			odsDate(e.start),
+			odsCurrency("", int64(e.salary)*100, xlsxStyleCurrency),
+			odsCell(xlsxStylePercent, fmt.Sprintf(` office:value-type="percentage" office:value="%s"`, xlsxPercent(e.bonus)), fmt.Sprintf("%d%%", e.bonus))))
+	}
+	total := xlsxFirstRow + len(wb.employees)
+	b.WriteString(odsRow(
+		odsString("Total", xlsxStyleHeader),
+		odsEmpty(4),
+		odsCurrency(fmt.Sprintf("SUM([.F%d:.F%d])", xlsxFirstRow, total-1), int64(wb.payroll)*100, xlsxStyleTotal)))
+	b.WriteString("\n   </table:table>")
+	return b.String()
+}
+
+// salesStart is a sales sheet up to its first sales row
+func (wb *odsWorkbook) salesStart(name string) string {
+	return odsSheetStart(name, []int{12, 12, 24, 16, 12, 10, 12}) +
+		wb.titleRow("Sales", len(xlsxSalesColumns)) +
+		wb.headerRow(xlsxSalesColumns...)
+}
+
+// salesTotals is the end of a sales sheet: the net total row. The sum
+// starts at the header row, so its range is never empty, and SUM skips the
+// label.
+func (wb *odsWorkbook) salesTotals(t xlsxTotals) string {
+	total := xlsxFirstRow + t.rows
+	return odsRow(
+		odsString("Total", xlsxStyleHeader),
+		odsEmpty(5),
+		odsCurrency(fmt.Sprintf("SUM([.G%d:.G%d])", xlsxFirstRow-1, total-1), t.net, xlsxStyleTotal)) +
+		"\n   </table:table>"
+}
+
+// summarySheet is the Summary sheet, whose formulas refer to the other
+// sheets
+func (wb *odsWorkbook) summarySheet(sheets []xlsxTotals) string {
+	employees := len(wb.employees)
+	lastEmployee := xlsxFirstRow + employees - 1
+	names := odsSheetNames(len(sheets))[1 : 1+len(sheets)]
+	counts := make([]string, len(sheets))
+	nets := make([]string, len(sheets))
+	for i, t := range sheets {
+		counts[i] = fmt.Sprintf("COUNT([%s.A%d:.A%d])", odsSheetRef(names[i]), xlsxFirstRow-1, xlsxFirstRow+t.rows-1)
+		nets[i] = fmt.Sprintf("[%s.G%d]", odsSheetRef(names[i]), xlsxFirstRow+t.rows)
+	}
+	grand := xlsxGrandTotal(sheets)
+	average := float64(wb.payroll) / float64(employees)
+
+	var b strings.Builder
+	b.WriteString(odsSheetStart("Summary", []int{24, 18}))
+	b.WriteString(wb.titleRow(wb.props.title, 2))
+	b.WriteString(wb.headerRow("Metric", "Value"))
+	metric := func(label, value string) {
+		b.WriteString(odsRow(odsString(label, xlsxStyleDefault), value))
+	}
+	metric("Employees", odsCell(xlsxStyleDefault, fmt.Sprintf(` table:formula="of:=COUNTA([$Employees.A%d:.A%d])" office:value-type="float" office:value="%d"`,
+		xlsxFirstRow, lastEmployee, employees), strconv.Itoa(employees)))
+	metric("Total payroll", odsCurrency(fmt.Sprintf("[$Employees.F%d]", lastEmployee+1), int64(wb.payroll)*100, xlsxStyleCurrency))
+	metric("Average salary", odsCell(xlsxStyleCurrency, fmt.Sprintf(` table:formula="of:=AVERAGE([$Employees.F%d:.F%d])" office:value-type="currency" office:currency="USD" office:value="%s"`,
+		xlsxFirstRow, lastEmployee, strconv.FormatFloat(average, 'f', -1, 64)), string(odsAppendDollars(nil, int64(average*100+0.5)))))
+	metric("Sales", odsCell(xlsxStyleDefault, fmt.Sprintf(` table:formula="of:=%s" office:value-type="float" office:value="%d"`,
+		strings.Join(counts, "+"), grand.rows), strconv.Itoa(grand.rows)))
+	metric("Net sales", odsCurrency(strings.Join(nets, "+"), grand.net, xlsxStyleCurrency))
+	metric("Report date", odsDate(wb.props.modified))
+	metric("Prepared by", odsString(wb.props.author, xlsxStyleDefault))
+	b.WriteString("\n   </table:table>")
+	return b.String()
+}
+
+// databaseRanges are the database ranges with autofilters over the
+// employees and each sales sheet, if tables are enabled, named like the
+// tables of XLSX
+func (wb *odsWorkbook) databaseRanges(sheets []xlsxTotals) string {
+	if !wb.tables {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\n   <table:database-ranges>")
+	dbRange := func(name, sheet string, lastRow int) {
+		fmt.Fprintf(&b, `
+    <table:database-range table:name="%s" table:target-range-address="%s.$A$%d:.$G$%d" table:display-filter-buttons="true"/>`,
+			name, odsSheetRef(sheet), xlsxFirstRow-1, lastRow)
+	}
+	dbRange("EmployeeTable", "Employees", xlsxFirstRow+len(wb.employees)-1)
+	for i, t := range sheets {
+		name := "SalesTable"
+		if i > 0 {
+			name = fmt.Sprintf("SalesTable%d", i+1)
+		}
+		dbRange(name, odsSheetNames(len(sheets))[1+i], xlsxFirstRow-1+max(t.rows, 1))
+	}
+	b.WriteString("\n   </table:database-ranges>")
+	return b.String()
+}
+
+// totalsSize returns the size of the parts of content.xml that depend on
+// the sales totals
+func (wb *odsWorkbook) totalsSize(sheets []xlsxTotals) int64 {
+	size := len(wb.summarySheet(sheets)) + len(wb.databaseRanges(sheets))
+	for _, t := range sheets {
+		size += len(wb.salesTotals(t))
+	}
+	return int64(size)
+}
+
+// odsSales streams the sales ledger into content.xml, starting a new sheet
+// when one is full, and keeps the totals of each sheet
+type odsSales struct {
+	wb      *odsWorkbook
+	content io.Writer
+	sheets  []xlsxTotals // the last one is the current sheet
+	count   int          // sales rows over all sheets
+
+	rows       *sizedWriter // writes to content.xml and counts the rows' bytes over all sheets
+	compressed func() int64 // flushes the compressor and returns the size of the package so far
+	buf        []byte
+	scratch    []xlsxTotals
+}
+
+// newSheet closes the current sheet, if any, and starts the next one
+func (s *odsSales) newSheet() {
+	if len(s.sheets) > 0 {
+		s.closeSheet()
+	}
+	s.sheets = append(s.sheets, xlsxTotals{})
+	io.WriteString(s.content, s.wb.salesStart(odsSheetNames(len(s.sheets))[len(s.sheets)]))
+}
+
+// closeSheet writes the totals row of the current sheet
+func (s *odsSales) closeSheet() {
+	if s.rows.err == nil {
+		io.WriteString(s.content, s.wb.salesTotals(s.sheets[len(s.sheets)-1]))
+	}
+}
+
+// next generates the next sales row: a whole dollar amount sold by an
+// employee, looked up by ID, with a discount of up to 20%. The row is
+// built in a buffer that is reused by the next call.
+func (s *odsSales) next() xlsxSale {
+	wb, r := s.wb, s.wb.r
+	sale := xlsxSale{newSheet: s.sheets[len(s.sheets)-1].rows == xlsxSheetRows}
+	row := int64(xlsxFirstRow + s.sheets[len(s.sheets)-1].rows)
+	if sale.newSheet {
+		row = xlsxFirstRow
+	}
+	employee := r.IntN(len(wb.employees))
+	amount := 50 + r.IntN(4951)
+	discount := 5 * r.IntN(5)
+	sale.net = int64(amount * (100 - discount))
+
+	b := append(s.buf[:0], "\n    <table:table-row><table:table-cell table:style-name=\"ce3\" office:value-type=\"date\" office:date-value=\""...)
+	date := len(b)
+	b = randomDate(r).AppendFormat(b, time.DateOnly)
+	b = append(b, `"><text:p>`...)
+	b = append(b, b[date:date+len(time.DateOnly)]...)
+	b = append(b, `</text:p></table:table-cell><table:table-cell office:value-type="float" office:value="`...)
+	b = strconv.AppendInt(b, int64(employee+1), 10)
+	b = append(b, `"><text:p>`...)
+	b = strconv.AppendInt(b, int64(employee+1), 10)
+	b = append(b, `</text:p></table:table-cell><table:table-cell table:formula="of:=VLOOKUP([.B`...)
+	b = strconv.AppendInt(b, row, 10)
+	b = append(b, wb.lookup...)
+	b = append(b, wb.employees[employee].cached...)
+	b = append(b, `"><text:p>`...)
+	b = append(b, wb.employees[employee].cached...)
+	b = append(b, `</text:p></table:table-cell><table:table-cell office:value-type="string"><text:p>`...)
+	b = append(b, wb.regions[r.IntN(len(wb.regions))]...)
+	b = append(b, `</text:p></table:table-cell><table:table-cell table:style-name="ce4" office:value-type="currency" office:currency="USD" office:value="`...)
+	b = strconv.AppendInt(b, int64(amount), 10)
+	b = append(b, `"><text:p>`...)
+	b = odsAppendDollars(b, int64(amount)*100)
+	b = append(b, `</text:p></table:table-cell><table:table-cell table:style-name="ce5" office:value-type="percentage" office:value="`...)
+	b = strconv.AppendFloat(b, float64(discount)/100, 'f', -1, 64)
+	b = append(b, `"><text:p>`...)
+	b = strconv.AppendInt(b, int64(discount), 10)
+	b = append(b, `%</text:p></table:table-cell><table:table-cell table:style-name="ce4" table:formula="of:=[.E`...)
+	b = strconv.AppendInt(b, row, 10)
+	b = append(b, "]*(1-[.F"...)
+	b = strconv.AppendInt(b, row, 10)
+	b = append(b, `])" office:value-type="currency" office:currency="USD" office:value="`...)
+	b = xlsxAppendCents(b, sale.net)
+	b = append(b, `"><text:p>`...)
+	b = odsAppendDollars(b, sale.net)
+	b = append(b, "</text:p></table:table-cell></table:table-row>"...)
+	s.buf = b
+	sale.xml = b
+	return sale
+}
+
+// write writes a row generated by next, in a new sheet if it is the first
+// of one
+func (s *odsSales) write(sale xlsxSale) {
+	if sale.newSheet {
+		s.newSheet()
+	}
+	s.rows.Write(sale.xml)
+	t := &s.sheets[len(s.sheets)-1]
+	t.rows++
+	t.net += sale.net
+	s.count++
+}
+
+// totalsWith returns the totals of the sheets as they would be after
+// writing sale
+func (s *odsSales) totalsWith(sale xlsxSale) []xlsxTotals {
+	s.scratch = append(s.scratch[:0], s.sheets...)
+	if sale.newSheet {
+		s.scratch = append(s.scratch, xlsxTotals{})
+	}
+	t := &s.scratch[len(s.scratch)-1]
+	t.rows++
+	t.net += sale.net
+	return s.scratch
+}
+
+// odsSheetNames returns the names of the sheets of a workbook with the
+// given number of sales sheets, in the order of content.xml
+func odsSheetNames(sales int) []string {
+	return append(xlsxSheetNames(sales)[1:], "Summary")
+}
+
+// odsSheetRef is the absolute reference to a sheet in formulas and
+// addresses
+func odsSheetRef(name string) string {
+	if strings.Contains(name, " ") {
+		return "$'" + name + "'"
+	}
+	return "$" + name
+}
+
+// odsSheetStart is a sheet up to its first row, with the given column
+// widths
+func odsSheetStart(name string, widths []int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n   <table:table table:name=\"%s\" table:style-name=\"ta1\">", name)
+	for _, width := range widths {
+		fmt.Fprintf(&b, `<table:table-column table:style-name="co%d" table:default-cell-style-name="Default"/>`, width)
+	}
+	return b.String()
+}
+
+func odsRow(cells ...string) string {
+	return "\n    <table:table-row>" + strings.Join(cells, "") + "</table:table-row>"
+}
+
+// odsStyle refers to the automatic cell style of content.xml with the
+// index of the XLSX cell style
+func odsStyle(style int) string {
+	if style == xlsxStyleDefault {
+		return ""
+	}
+	return fmt.Sprintf(` table:style-name="ce%d"`, style)
+}
+
+// odsCell is a cell with the given value attributes and displayed text
+func odsCell(style int, attrs, text string) string {
+	return fmt.Sprintf(`<table:table-cell%s%s><text:p>%s</text:p></table:table-cell>`, odsStyle(style), attrs, text)
+}
+
+func odsString(s string, style int) string {
+	return odsCell(style, ` office:value-type="string"`, xmlEscape(s))
+}
+
+func odsNumber(n int) string {
+	return odsCell(xlsxStyleDefault, fmt.Sprintf(` office:value-type="float" office:value="%d"`, n), strconv.Itoa(n))
+}
+
+func odsDate(t time.Time) string {
+	date := t.Format(time.DateOnly)
+	return odsCell(xlsxStyleDate, ` office:value-type="date" office:date-value="`+date+`"`, date)
+}
+
+// odsCurrency is an amount in cents, the cached value of formula unless
+// it is empty
+func odsCurrency(formula string, cents int64, style int) string {
+	attrs := ""
+	if formula != "" {
+		attrs = ` table:formula="of:=` + formula + `"`
+	}
+	return odsCell(style, attrs+` office:value-type="currency" office:currency="USD" office:value="`+xlsxCents(cents)+`"`,
+		string(odsAppendDollars(nil, cents)))
+}
+
+// odsEmpty is n empty cells
+func odsEmpty(n int) string {
+	return fmt.Sprintf(`<table:table-cell table:number-columns-repeated="%d"/>`, n)
+}
+
+// odsAppendDollars appends an amount in cents as it is displayed, with
+// thousands separators
+func odsAppendDollars(b []byte, cents int64) []byte {
+	var digits [20]byte
+	dollars := strconv.AppendInt(digits[:0], cents/100, 10)
+	b = append(b, '$')
+	for i, digit := range dollars {
+		if i > 0 && (len(dollars)-i)%3 == 0 {
+			b = append(b, ',')
+		}
+		b = append(b, digit)
+	}
+	return append(b, '.', byte('0'+cents%100/10), byte('0'+cents%10))
+}
+
+// odsContentStart is content.xml up to the first sheet: the automatic
+// styles of the columns, the title row and the cells, whose indexes are
+// those of the XLSX cell styles, and the date, currency and percent
+// formats they use
+func odsContentStart() string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content ` + odfNS + ` office:version="` + odfVersion + `">` + odfFontFaces + `
+ <office:automatic-styles>`)
+	for _, width := range odsColumnWidths {
+		fmt.Fprintf(&b, `
+  <style:style style:name="co%d" style:family="table-column"><style:table-column-properties fo:break-before="auto" style:column-width="%.3fin"/></style:style>`,
+			width, float64(width)*0.08)
+	}
+	b.WriteString(`
+  <style:style style:name="ro1" style:family="table-row"><style:table-row-properties style:row-height="0.29in" fo:break-before="auto" style:use-optimal-row-height="false"/></style:style>
+  <style:style style:name="ta1" style:family="table" style:master-page-name="Default"><style:table-properties table:display="true" style:writing-mode="lr-tb"/></style:style>
+  <number:date-style style:name="N1"><number:year number:style="long"/><number:text>-</number:text><number:month number:style="long"/><number:text>-</number:text><number:day number:style="long"/></number:date-style>
+  <number:currency-style style:name="N2"><number:currency-symbol number:language="en" number:country="US">$</number:currency-symbol><number:number number:decimal-places="2" number:min-decimal-places="2" number:min-integer-digits="1" number:grouping="true"/></number:currency-style>
+  <number:percentage-style style:name="N3"><number:number number:decimal-places="0" number:min-decimal-places="0" number:min-integer-digits="1"/><number:text>%</number:text></number:percentage-style>
+  <style:style style:name="ce1" style:family="table-cell" style:parent-style-name="Default"><style:text-properties fo:color="#1f3864" fo:font-size="16pt" fo:font-weight="bold"/></style:style>
+  <style:style style:name="ce2" style:family="table-cell" style:parent-style-name="Default"><style:table-cell-properties fo:background-color="#d9e1f2"/><style:text-properties fo:font-weight="bold"/></style:style>
+  <style:style style:name="ce3" style:family="table-cell" style:parent-style-name="Default" style:data-style-name="N1"/>
+  <style:style style:name="ce4" style:family="table-cell" style:parent-style-name="Default" style:data-style-name="N2"/>
+  <style:style style:name="ce5" style:family="table-cell" style:parent-style-name="Default" style:data-style-name="N3"/>
+  <style:style style:name="ce6" style:family="table-cell" style:parent-style-name="Default" style:data-style-name="N2"><style:table-cell-properties fo:border-top="0.5pt solid #000000"/><style:text-properties fo:font-weight="bold"/></style:style>
+ </office:automatic-styles>
+ <office:body>
+  <office:spreadsheet>`)
+	return b.String()
+}
+
+// odsSettings is settings.xml: the workbook opens on the summary, the
+// last sheet, and the title and header rows of the other sheets are
+// frozen
+func odsSettings(sheets []string) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<office:document-settings ` + odfNS + ` office:version="` + odfVersion + `">
+ <office:settings>
+  <config:config-item-set config:name="ooo:view-settings">
+   <config:config-item-map-indexed config:name="Views">
+    <config:config-item-map-entry>
+     <config:config-item config:name="ViewId" config:type="string">view1</config:config-item>
+     <config:config-item-map-named config:name="Tables">`)
+	for _, name := range sheets[:len(sheets)-1] {
+		fmt.Fprintf(&b, `
+      <config:config-item-map-entry config:name="%s"><config:config-item config:name="VerticalSplitMode" config:type="short">2</config:config-item><config:config-item config:name="VerticalSplitPosition" config:type="int">%[2]d</config:config-item><config:config-item config:name="ActiveSplitRange" config:type="short">2</config:config-item><config:config-item config:name="PositionTop" config:type="int">0</config:config-item><config:config-item config:name="PositionBottom" config:type="int">%[2]d</config:config-item></config:config-item-map-entry>`,
+			name, xlsxFirstRow-1)
+	}
+	fmt.Fprintf(&b, `
+     </config:config-item-map-named>
+     <config:config-item config:name="ActiveTable" config:type="string">%s</config:config-item>
+    </config:config-item-map-entry>
+   </config:config-item-map-indexed>
+  </config:config-item-set>
+ </office:settings>
+</office:document-settings>`, sheets[len(sheets)-1])
+	return b.String()
+}
+
+// odsStyles is styles.xml: the default cell style, and the page with the
+// sheet name in its header and the page number in its footer
+const odsStyles = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-styles ` + odfNS + ` office:version="` + odfVersion + `">` + odfFontFaces + `
+ <office:styles>
+  <style:default-style style:family="table-cell"><style:paragraph-properties style:tab-stop-distance="0.5in"/><style:text-properties style:font-name="Liberation Sans" fo:font-size="10pt" fo:language="en" fo:country="US"/></style:default-style>
+  <style:style style:name="Default" style:family="table-cell"/>
+ </office:styles>
+ <office:automatic-styles>
+  <style:page-layout style:name="pm1"><style:page-layout-properties fo:page-width="8.5in" fo:page-height="11in" style:print-orientation="portrait" fo:margin-top="0.75in" fo:margin-bottom="0.75in" fo:margin-left="0.7in" fo:margin-right="0.7in"/><style:header-style><style:header-footer-properties fo:min-height="0.3in"/></style:header-style><style:footer-style><style:header-footer-properties fo:min-height="0.3in"/></style:footer-style></style:page-layout>
+ </office:automatic-styles>
+ <office:master-styles>
+  <style:master-page style:name="Default" style:page-layout-name="pm1">
+   <style:header><text:p><text:sheet-name>???</text:sheet-name></text:p></style:header>
+   <style:footer><text:p>Page <text:page-number>1</text:page-number></text:p></style:footer>
+  </style:master-page>
+ </office:master-styles>
+</office:document-styles>`
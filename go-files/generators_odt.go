@@ -0,0 +1,332 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"image/png"
+	"io"
+	"math/rand/v2"
+	"strings"
+)
+
+// OdtGenerator generates valid ODT files (OpenDocument text) that look like
+// real Writer documents, with the content of DocxGenerator: a title and
+// numbered headings, paragraphs with bold and italic spans, bulleted and
+// numbered lists, tables, a header and footer, and pixel art animals
+// embedded as PNG images
+type OdtGenerator struct {
+	OfficeProperties
+
+	props   officeProperties // the properties of the last generated document
+	animals []string         // its embedded images
+}
+
+// Document layout
+const (
+	odtMaxImages   = 8         // distinct images in Pictures; later figures reuse them
+	odtImageBytes  = 32 * 1024 // document size per embedded image
+	odtImagePixels = 128       // width and height of an image in pixels
+	odtImageSize   = "2in"     // width and height of an image on the page
+	odtColumnWidth = "1.625in" // width of a table column, of 6.5in over four columns
+)
+
+func (g *OdtGenerator) Extension() string {
+	return "odt"
+}
+
+// Metadata reports the document properties and the animals of the
+// embedded images
+func (g *OdtGenerator) Metadata() map[string]any {
+	metadata := map[string]any{"images": len(g.animals), "animals": g.animals}
+	g.props.addMetadata(metadata)
+	return metadata
+}
+
+func (g *OdtGenerator) Generate(r *rand.Rand, sizeBytes int) ([]byte, error) {
+	return generateBuffered(g, r, sizeBytes)
+}
+
+// GenerateTo compresses the body of content.xml until the package reaches
+// the target size. The package overhead is measured first by writing it
+// with an empty body.
+func (g *OdtGenerator) GenerateTo(w io.Writer, r *rand.Rand, sizeBytes int64) (int64, error) {
+	images, err := g.prepare(r, sizeBytes)
+	if err != nil {
+		return 0, err
+	}
+	overhead, err := g.writePackage(io.Discard, zip.Deflate, r, images, func(*sizedWriter, *odtBody, func() int64) {})
+	if err != nil {
+		return 0, err
+	}
+	return g.writePackage(w, zip.Deflate, r, images, func(body *sizedWriter, doc *odtBody, compressed func() int64) {
+		odfFill(body, doc, compressed, sizeBytes-overhead)
+	})
+}
+
+// GenerateExactTo stores content.xml uncompressed, so every byte of body
+// adds exactly one byte to the package, and pads the body with the bytes
+// left after the last block that fits
+func (g *OdtGenerator) GenerateExactTo(w io.Writer, r *rand.Rand, sizeBytes int64) (int64, error) {
+	images, err := g.prepare(r, sizeBytes)
+	if err != nil {
+		return 0, err
+	}
+	overhead, err := g.writePackage(io.Discard, zip.Store, r, images, func(*sizedWriter, *odtBody, func() int64) {})
+	if err != nil {
+		return 0, err
+	}
+	return g.writePackage(w, zip.Store, r, images, func(body *sizedWriter, doc *odtBody, _ func() int64) {
+		odfFillExact(body, doc, sizeBytes-overhead)
+	})
+}
+
+// prepare picks the document properties and draws the images, which are
+// shared by both passes of GenerateTo and GenerateExactTo. Larger
+// documents get more distinct images.
+func (g *OdtGenerator) prepare(r *rand.Rand, sizeBytes int64) ([]docxImage, error) {
+	g.props = g.resolve(r, docxHeadingText(r))
+	images := make([]docxImage, min(odtMaxImages, int(sizeBytes/odtImageBytes)))
+	g.animals = g.animals[:0]
+	for i := range images {
+		animal := GetRandomAnimal(r)
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, drawAnimal(animal, randomPastelColor(r), odtImagePixels, odtImagePixels)); err != nil {
+			return nil, err
+		}
+		images[i] = docxImage{animal: animal.Name, data: buf.Bytes()}
+		g.animals = append(g.animals, animal.Name)
+	}
+	return images, nil
+}
+
+// writePackage writes the ODT package. fill writes the blocks of the body
+// of content.xml, which is compressed with the given method.
+func (g *OdtGenerator) writePackage(w io.Writer, method uint16, r *rand.Rand, images []docxImage, fill func(body *sizedWriter, doc *odtBody, compressed func() int64)) (int64, error) {
+	title := xmlEscape(g.props.title)
+	pkg, err := newOdfPackage(w, "odt")
+	if err == nil {
+		err = pkg.addMeta(&g.props)
+	}
+	if err == nil {
+		err = pkg.add("styles.xml", fmt.Sprintf(odtStyles, title))
+	}
+	if err == nil {
+		err = pkg.addImages(images)
+	}
+	if err != nil {
+		return pkg.sw.Len(), err
+	}
+
+	// Generate document content, streamed straight into the zip entry
+	contentWriter, err := pkg.create("content.xml", method)
+	if err != nil {
+		return pkg.sw.Len(), err
+	}
+	io.WriteString(contentWriter, odtContentStart)
+	fmt.Fprintf(contentWriter, "\n   <text:p text:style-name=\"Title\">%s</text:p>", title)
+
+	doc := &odtBody{r: r, images: images}
+	body := newSizedWriter(contentWriter, -1)
+	fill(body, doc, pkg.compressed)
+	if body.err != nil {
+		return pkg.sw.Len(), body.err
+	}
+	io.WriteString(contentWriter, `
+  </office:text>
+ </office:body>
+</office:document-content>`)
+
+	return pkg.close()
+}
+
+// odtBody generates the blocks of the document body
+type odtBody struct {
+	r      *rand.Rand
+	images []docxImage
+
+	blocks   int // blocks generated
+	sections int // level 1 headings
+	tables   int
+	figures  int
+}
+
+func (d *odtBody) write(body *sizedWriter, block string) {
+	body.WriteString(block)
+}
+
+// next generates the next block. Sections start with a level 1 heading
+// and have subsections with level 2 and sometimes level 3 headings.
+func (d *odtBody) next() string {
+	r := d.r
+	d.blocks++
+	switch {
+	case d.blocks%12 == 1:
+		d.sections++
+		return odtHeading(1, fmt.Sprintf("%d %s", d.sections, docxHeadingText(r)))
+	case d.blocks%4 == 1:
+		level := 2
+		if r.IntN(3) == 0 {
+			level = 3
+		}
+		return odtHeading(level, docxHeadingText(r))
+	}
+
+	switch n := r.IntN(20); {
+	case n < 11:
+		return odtRichParagraph(r)
+	case n < 13:
+		return odtList(r, "Bullets")
+	case n < 15:
+		// Every list starts its numbering at 1 unless it continues another
+		return odtList(r, "Numbering")
+	case n < 17 || len(d.images) == 0:
+		d.tables++
+		return odtTable(r, d.tables) + odtStyled("Caption", fmt.Sprintf("Table %d: %s", d.tables, docxHeadingText(r)))
+	default:
+		// Every image is shown once before any is shown again
+		d.figures++
+		index := d.figures - 1
+		if index >= len(d.images) {
+			index = r.IntN(len(d.images))
+		}
+		return odtFrame(d.figures, index, d.images[index].animal) +
+			odtStyled("Caption", fmt.Sprintf("Figure %d: %s", d.figures, d.images[index].animal))
+	}
+}
+
+// odtHeading is a heading of the given outline level
+func odtHeading(level int, text string) string {
+	return fmt.Sprintf("\n   <text:h text:style-name=\"Heading_20_%d\" text:outline-level=\"%d\">%s</text:h>", level, level, text)
+}
+
+// odtStyled is a paragraph of the given style
+func odtStyled(style, text string) string {
+	return fmt.Sprintf("\n   <text:p text:style-name=\"%s\">%s</text:p>", style, text)
+}
+
+// odtRichParagraph is a paragraph of sentences, some of them bold, italic
+// or both
+func odtRichParagraph(r *rand.Rand) string {
+	var b strings.Builder
+	b.WriteString("\n   <text:p text:style-name=\"Text_20_body\">")
+	for i := 3 + r.IntN(5); i > 0; i-- {
+		b.WriteString(odtSpan(r, randomSentence(r)+" "))
+	}
+	b.WriteString("</text:p>")
+	return b.String()
+}
+
+// odtSpan is text formatted at random with the automatic text styles of
+// content.xml
+func odtSpan(r *rand.Rand, text string) string {
+	switch r.IntN(10) {
+	case 0:
+		return `<text:span text:style-name="T1">` + text + "</text:span>"
+	case 1:
+		return `<text:span text:style-name="T2">` + text + "</text:span>"
+	case 2:
+		return `<text:span text:style-name="T3">` + text + "</text:span>"
+	}
+	return text
+}
+
+// odtList is a list of the given list style
+func odtList(r *rand.Rand, style string) string {
+	return "\n   " + odfList(r, ` text:style-name="`+style+`"`, func() string {
+		return `<text:p text:style-name="List_20_Paragraph">` + odtSpan(r, strings.TrimSuffix(randomSentence(r), ".")) + "</text:p>"
+	})
+}
+
+// odtTable is table number n, of person records with a repeated header
+// row
+func odtTable(r *rand.Rand, n int) string {
+	cell := func(text, style string) string {
+		return fmt.Sprintf(`<table:table-cell table:style-name="Table.A1" office:value-type="string"><text:p text:style-name="%s">%s</text:p></table:table-cell>`, style, text)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n   <table:table table:name=\"Table%d\" table:style-name=\"Table\"><table:table-column table:style-name=\"Table.A\" table:number-columns-repeated=\"%d\"/>",
+		n, len(docxTableColumns))
+	b.WriteString("\n    <table:table-header-rows><table:table-row>")
+	for _, col := range docxTableColumns {
+		b.WriteString(cell(strings.Title(col), "Table_20_Heading"))
+	}
+	b.WriteString("</table:table-row></table:table-header-rows>")
+	for i := 2 + r.IntN(6); i > 0; i-- {
+		b.WriteString("\n    <table:table-row>")
+		for _, col := range docxTableColumns {
+			b.WriteString(cell(csvColumns[col](r, i), "Table_20_Contents"))
+		}
+		b.WriteString("</table:table-row>")
+	}
+	b.WriteString("\n   </table:table>")
+	return b.String()
+}
+
+// odtFrame is a paragraph with image index of Pictures shown as a
+// character. id numbers the frames of the document.
+func odtFrame(id, index int, animal string) string {
+	return fmt.Sprintf(`
+   <text:p text:style-name="Figure"><draw:frame draw:style-name="fr1" draw:name="Image%[1]d" text:anchor-type="as-char" svg:width="%[2]s" svg:height="%[2]s" draw:z-index="0">`+
+		`<draw:image xlink:href="Pictures/image%[3]d.png" xlink:type="simple" xlink:show="embed" xlink:actuate="onLoad" draw:mime-type="image/png"/><svg:desc>%[4]s</svg:desc></draw:frame></text:p>`,
+		id, odtImageSize, index+1, animal)
+}
+
+// odtContentStart is content.xml up to the body: the automatic styles of
+// the spans, tables and frames
+const odtContentStart = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content ` + odfNS + ` office:version="` + odfVersion + `">` + odfFontFaces + `
+ <office:automatic-styles>
+  <style:style style:name="T1" style:family="text"><style:text-properties fo:font-weight="bold"/></style:style>
+  <style:style style:name="T2" style:family="text"><style:text-properties fo:font-style="italic"/></style:style>
+  <style:style style:name="T3" style:family="text"><style:text-properties fo:font-weight="bold" fo:font-style="italic"/></style:style>
+  <style:style style:name="Table" style:family="table"><style:table-properties style:width="6.5in" table:align="margins" fo:margin-bottom="0.08in"/></style:style>
+  <style:style style:name="Table.A" style:family="table-column"><style:table-column-properties style:column-width="` + odtColumnWidth + `"/></style:style>
+  <style:style style:name="Table.A1" style:family="table-cell"><style:table-cell-properties fo:padding="0.04in" fo:border="0.5pt solid #000000"/></style:style>
+  <style:style style:name="fr1" style:family="graphic" style:parent-style-name="Graphics"><style:graphic-properties style:vertical-pos="top" style:vertical-rel="baseline"/></style:style>
+ </office:automatic-styles>
+ <office:body>
+  <office:text>`
+
+// odtStyles is styles.xml: the default fonts, the paragraph styles the
+// body uses, the bulleted and numbered list styles, and the page with the
+// title in its header and the page number in its footer
+const odtStyles = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-styles ` + odfNS + ` office:version="` + odfVersion + `">` + odfFontFaces + `
+ <office:styles>
+  <style:default-style style:family="paragraph"><style:paragraph-properties fo:margin-bottom="0.11in" fo:line-height="108%%"/><style:text-properties style:font-name="Liberation Serif" fo:font-size="11pt" fo:language="en" fo:country="US"/></style:default-style>
+  <style:style style:name="Standard" style:family="paragraph" style:class="text"/>
+  <style:style style:name="Text_20_body" style:display-name="Text body" style:family="paragraph" style:parent-style-name="Standard" style:class="text"/>
+  <style:style style:name="Title" style:family="paragraph" style:parent-style-name="Standard" style:next-style-name="Text_20_body" style:class="chapter"><style:paragraph-properties fo:margin-bottom="0.17in"/><style:text-properties style:font-name="Liberation Sans" fo:font-size="28pt"/></style:style>
+  <style:style style:name="Heading" style:family="paragraph" style:parent-style-name="Standard" style:next-style-name="Text_20_body" style:class="text"><style:paragraph-properties fo:keep-with-next="always"/><style:text-properties style:font-name="Liberation Sans" fo:color="#2f5496" fo:font-weight="bold"/></style:style>
+  <style:style style:name="Heading_20_1" style:display-name="Heading 1" style:family="paragraph" style:parent-style-name="Heading" style:default-outline-level="1" style:class="text"><style:paragraph-properties fo:margin-top="0.25in" fo:margin-bottom="0.08in"/><style:text-properties fo:font-size="16pt"/></style:style>
+  <style:style style:name="Heading_20_2" style:display-name="Heading 2" style:family="paragraph" style:parent-style-name="Heading" style:default-outline-level="2" style:class="text"><style:paragraph-properties fo:margin-top="0.17in" fo:margin-bottom="0.06in"/><style:text-properties fo:font-size="13pt"/></style:style>
+  <style:style style:name="Heading_20_3" style:display-name="Heading 3" style:family="paragraph" style:parent-style-name="Heading" style:default-outline-level="3" style:class="text"><style:paragraph-properties fo:margin-top="0.11in" fo:margin-bottom="0.03in"/><style:text-properties fo:color="#1f3763" fo:font-size="12pt"/></style:style>
+  <style:style style:name="List_20_Paragraph" style:display-name="List Paragraph" style:family="paragraph" style:parent-style-name="Standard" style:class="list"><style:paragraph-properties fo:margin-bottom="0.03in"/></style:style>
+  <style:style style:name="Table_20_Contents" style:display-name="Table Contents" style:family="paragraph" style:parent-style-name="Standard" style:class="extra"><style:paragraph-properties fo:margin-bottom="0in"/></style:style>
+  <style:style style:name="Table_20_Heading" style:display-name="Table Heading" style:family="paragraph" style:parent-style-name="Table_20_Contents" style:class="extra"><style:text-properties fo:font-weight="bold"/></style:style>
+  <style:style style:name="Figure" style:family="paragraph" style:parent-style-name="Standard" style:class="extra"><style:paragraph-properties fo:text-align="center"/></style:style>
+  <style:style style:name="Caption" style:family="paragraph" style:parent-style-name="Standard" style:class="extra"><style:paragraph-properties fo:text-align="center"/><style:text-properties fo:color="#44546a" fo:font-size="9pt" fo:font-style="italic"/></style:style>
+  <style:style style:name="Header" style:family="paragraph" style:parent-style-name="Standard" style:class="extra"><style:paragraph-properties fo:margin-bottom="0in"/><style:text-properties fo:color="#808080" fo:font-size="9pt"/></style:style>
+  <style:style style:name="Footer" style:family="paragraph" style:parent-style-name="Standard" style:class="extra"><style:paragraph-properties fo:text-align="center" fo:margin-bottom="0in"/><style:text-properties fo:color="#808080" fo:font-size="9pt"/></style:style>
+  <style:style style:name="Graphics" style:family="graphic"><style:graphic-properties text:anchor-type="as-char" svg:y="0in" style:wrap="none"/></style:style>
+  <text:list-style style:name="Bullets">
+   <text:list-level-style-bullet text:level="1" text:bullet-char="•"><style:list-level-properties text:list-level-position-and-space-mode="label-alignment"><style:list-level-label-alignment text:label-followed-by="listtab" fo:text-indent="-0.25in" fo:margin-left="0.5in"/></style:list-level-properties></text:list-level-style-bullet>
+   <text:list-level-style-bullet text:level="2" text:bullet-char="◦"><style:list-level-properties text:list-level-position-and-space-mode="label-alignment"><style:list-level-label-alignment text:label-followed-by="listtab" fo:text-indent="-0.25in" fo:margin-left="1in"/></style:list-level-properties></text:list-level-style-bullet>
+  </text:list-style>
+  <text:list-style style:name="Numbering">
+   <text:list-level-style-number text:level="1" style:num-suffix="." style:num-format="1"><style:list-level-properties text:list-level-position-and-space-mode="label-alignment"><style:list-level-label-alignment text:label-followed-by="listtab" fo:text-indent="-0.25in" fo:margin-left="0.5in"/></style:list-level-properties></text:list-level-style-number>
+   <text:list-level-style-number text:level="2" style:num-suffix="." style:num-format="a"><style:list-level-properties text:list-level-position-and-space-mode="label-alignment"><style:list-level-label-alignment text:label-followed-by="listtab" fo:text-indent="-0.25in" fo:margin-left="1in"/></style:list-level-properties></text:list-level-style-number>
+  </text:list-style>
+ </office:styles>
+ <office:automatic-styles>
+  <style:page-layout style:name="pm1"><style:page-layout-properties fo:page-width="8.5in" fo:page-height="11in" style:print-orientation="portrait" fo:margin-top="0.5in" fo:margin-bottom="0.5in" fo:margin-left="1in" fo:margin-right="1in"/><style:header-style><style:header-footer-properties fo:min-height="0in" fo:margin-bottom="0.25in"/></style:header-style><style:footer-style><style:header-footer-properties fo:min-height="0in" fo:margin-top="0.25in"/></style:footer-style></style:page-layout>
+ </office:automatic-styles>
+ <office:master-styles>
+  <style:master-page style:name="Standard" style:page-layout-name="pm1">
+   <style:header><text:p text:style-name="Header">%s</text:p></style:header>
+   <style:footer><text:p text:style-name="Footer">Page <text:page-number text:select-page="current">1</text:page-number></text:p></style:footer>
+  </style:master-page>
+ </office:master-styles>
+</office:document-styles>`
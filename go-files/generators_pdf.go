@@ -0,0 +1,555 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PdfGenerator generates valid multi-page PDF files. Text is flowed into as
+// many pages as the size requires.
+type PdfGenerator struct {
+	PageSize   string `json:"page_size"`   // letter (default) or a4
+	Headers    *bool  `json:"headers"`     // title header and page number footer, on by default
+	Images     string `json:"images"`      // none (default), raw or flate
+	ImageEvery int    `json:"image_every"` // paragraphs between images, 3 by default
+
+	Compress      bool `json:"compress"`       // FlateDecode content, object and cross-reference streams
+	XrefStreams   bool `json:"xref_streams"`   // PDF 1.5 cross-reference streams instead of tables
+	ObjectStreams bool `json:"object_streams"` // objects in object streams, implies xref_streams
+	Updates       int  `json:"updates"`        // incremental update sections appended to the document
+
+	Info        bool `json:"info"`        // /Info dictionary and XMP metadata
+	Outline     bool `json:"outline"`     // section headings, each with an outline item
+	Annotations bool `json:"annotations"` // a note, a web link and a link to the previous page on every page
+	Forms       int  `json:"forms"`       // form pages at the start, one per person
+	Prefill     bool `json:"prefill"`     // fill in the forms with the person data
+
+	Encryption    string   `json:"encryption"`     // none (default), rc4-40, rc4-128, aes-128 or aes-256
+	UserPassword  string   `json:"user_password"`  // needed to open the document, empty by default
+	OwnerPassword string   `json:"owner_password"` // needed for full access, random by default
+	Permissions   []string `json:"permissions"`    // granted to the user, all by default
+
+	pages         int
+	animals       []string
+	ownerPassword string
+	info          pdfInfo
+	headings      int
+	annotations   int
+	formFields    []pdfFormField
+}
+
+// pdfPageSizes are the supported page sizes in points
+var pdfPageSizes = map[string][2]int{
+	"letter": {612, 792},
+	"a4":     {595, 842},
+}
+
+// Page layout in points and characters
+const (
+	pdfMargin       = 50  // left margin
+	pdfBodyMargin   = 72  // space above and below the body text
+	pdfLeading      = 14  // line height of the 12pt body text
+	pdfLineChars    = 70  // characters per body line
+	pdfPagesPerNode = 64  // pages under each intermediate node of the page tree
+	pdfImageSide    = 144 // width and height of an image on the page
+	pdfImagePixels  = 96  // width and height of an image in pixels
+)
+
+// pdfImageFilters are the ways images can be embedded: not at all, as raw
+// RGB samples or compressed with FlateDecode
+var pdfImageFilters = []string{"none", "raw", "flate"}
+
+func (g *PdfGenerator) Extension() string {
+	return "pdf"
+}
+
+// Metadata reports the page count and page size of the generated document,
+// the animals of its images, in order, and the other enabled features
+func (g *PdfGenerator) Metadata() map[string]any {
+	metadata := map[string]any{"pages": g.pages, "page_size": g.pageSizeName()}
+	if g.Updates > 0 {
+		metadata["updates"] = g.Updates
+	}
+	if g.imageFilter() != "none" {
+		metadata["images"] = len(g.animals)
+		metadata["animals"] = g.animals
+	}
+	if g.encryption() != "none" {
+		metadata["encryption"] = g.encryption()
+		metadata["user_password"] = g.UserPassword
+		metadata["owner_password"] = g.ownerPassword
+		metadata["permissions"] = pdfPermissionNames(g.permissions())
+	}
+	if g.Info {
+		metadata["title"] = g.info.title
+		metadata["author"] = g.info.author
+		metadata["created"] = g.info.created.Format(time.RFC3339)
+		metadata["modified"] = g.info.modified.Format(time.RFC3339)
+	}
+	if g.Outline {
+		metadata["outline"] = g.headings
+	}
+	if g.Annotations {
+		metadata["annotations"] = g.annotations
+	}
+	if g.Forms > 0 {
+		metadata["form_fields"] = g.formFields
+	}
+	return metadata
+}
+
+// Validate checks the configured page size, images and encryption
+func (g *PdfGenerator) Validate() error {
+	if !slices.Contains(pdfImageFilters, g.imageFilter()) {
+		return fmt.Errorf("unknown pdf images %q (supported: %s)", g.Images, strings.Join(pdfImageFilters, ", "))
+	}
+	if !slices.Contains(pdfEncryptions, g.encryption()) {
+		return fmt.Errorf("unknown pdf encryption %q (supported: %s)", g.Encryption, strings.Join(pdfEncryptions, ", "))
+	}
+	for _, name := range g.Permissions {
+		if _, ok := pdfPermissions[name]; !ok {
+			return fmt.Errorf("unknown pdf permission %q (supported: %s)", name, strings.Join(pdfPermissionNames(-1), ", "))
+		}
+	}
+	if g.ImageEvery < 0 || g.Updates < 0 || g.Forms < 0 {
+		return fmt.Errorf("pdf image_every, updates and forms must not be negative")
+	}
+	if _, ok := pdfPageSizes[g.pageSizeName()]; !ok {
+		names := make([]string, 0, len(pdfPageSizes))
+		for name := range pdfPageSizes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown pdf page size %q (supported: %s)", g.PageSize, strings.Join(names, ", "))
+	}
+	return nil
+}
+
+func (g *PdfGenerator) pageSizeName() string {
+	if g.PageSize == "" {
+		return "letter"
+	}
+	return strings.ToLower(g.PageSize)
+}
+
+func (g *PdfGenerator) headers() bool {
+	return g.Headers == nil || *g.Headers
+}
+
+func (g *PdfGenerator) imageFilter() string {
+	if g.Images == "" {
+		return "none"
+	}
+	return strings.ToLower(g.Images)
+}
+
+func (g *PdfGenerator) encryption() string {
+	if g.Encryption == "" {
+		return "none"
+	}
+	return strings.ToLower(g.Encryption)
+}
+
+// permissions returns the /P value of the configured permissions
+func (g *PdfGenerator) permissions() int32 {
+	if g.Permissions == nil {
+		return pdfPermissionValue(pdfPermissionNames(-1))
+	}
+	return pdfPermissionValue(g.Permissions)
+}
+
+func (g *PdfGenerator) imageEvery() int {
+	if g.ImageEvery == 0 {
+		return 3
+	}
+	return g.ImageEvery
+}
+
+// pdfImage is an image XObject: its dictionary entries besides /Length,
+// its data and the animal it shows
+type pdfImage struct {
+	dict   string
+	data   []byte
+	animal string
+}
+
+// image draws a random animal as an image XObject
+func (g *PdfGenerator) image(r *rand.Rand) (pdfImage, error) {
+	bgColor := randomPastelColor(r)
+	animal := GetRandomAnimal(r)
+	img := drawAnimal(animal, bgColor, pdfImagePixels, pdfImagePixels)
+
+	samples := make([]byte, 0, 3*pdfImagePixels*pdfImagePixels)
+	for i := 0; i < len(img.Pix); i += 4 {
+		samples = append(samples, img.Pix[i:i+3]...)
+	}
+	dict := fmt.Sprintf(" /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8",
+		pdfImagePixels, pdfImagePixels)
+	if g.imageFilter() == "raw" {
+		return pdfImage{dict, samples, animal.Name}, nil
+	}
+
+	return pdfImage{dict + " /Filter /FlateDecode", flateEncode(samples), animal.Name}, nil
+}
+
+func (g *PdfGenerator) Generate(r *rand.Rand, sizeBytes int) ([]byte, error) {
+	return generateBuffered(g, r, sizeBytes)
+}
+
+// GenerateTo writes the pages one at a time, so only the content of the
+// current page is held in memory. The page tree is written last, once the
+// number of pages is known.
+func (g *PdfGenerator) GenerateTo(w io.Writer, r *rand.Rand, sizeBytes int64) (int64, error) {
+	// Clear what the previous document reported
+	g.pages, g.animals, g.ownerPassword, g.info = 0, nil, "", pdfInfo{}
+	g.headings, g.annotations, g.formFields = 0, 0, nil
+
+	layout := pdfLayout{xrefStreams: g.XrefStreams, objectStreams: g.ObjectStreams, compress: g.Compress}
+	catalogEntries := ""
+	if g.encryption() != "none" {
+		g.ownerPassword = g.OwnerPassword
+		if g.ownerPassword == "" {
+			g.ownerPassword = randomString(r, 12)
+		}
+		layout.crypt = newPdfCrypt(g.encryption(), g.UserPassword, g.ownerPassword, g.permissions(), randomBytes(r, 16), r)
+		if g.encryption() == "aes-256" {
+			catalogEntries = " /Extensions << /ADBE << /BaseVersion /1.7 /ExtensionLevel 8 >> >>"
+		}
+	}
+	pw := newPdfWriter(w, layout)
+	size := pdfPageSizes[g.pageSizeName()]
+	width, height := size[0], size[1]
+	title := strings.Title(randomWord(r) + " " + randomWord(r))
+
+	catalog, root, font := pw.alloc(), pw.alloc(), pw.alloc()
+	fonts := fmt.Sprintf("/F1 %d 0 R", font)
+	var bold, outline, info, xmp int
+	if g.Outline || g.Forms > 0 {
+		bold = pw.alloc()
+		fonts += fmt.Sprintf(" /F2 %d 0 R", bold)
+	}
+	if g.Outline {
+		outline = pw.alloc()
+		catalogEntries += fmt.Sprintf(" /Outlines %d 0 R /PageMode /UseOutlines", outline)
+	}
+	if g.Info {
+		info, xmp = pw.alloc(), pw.alloc()
+		pw.info = info
+		catalogEntries += fmt.Sprintf(" /Metadata %d 0 R", xmp)
+	}
+	var form *pdfForm
+	if g.Forms > 0 {
+		form = newPdfForm(pw, g.Prefill)
+		catalogEntries += fmt.Sprintf(" /AcroForm %d 0 R", form.acroForm)
+	}
+	pw.object(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R%s >>", root, catalogEntries))
+	pw.object(font, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>")
+	if bold > 0 {
+		pw.object(bold, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold >>")
+	}
+	if g.Info {
+		g.info = newPdfInfo(r, title)
+		g.info.write(pw, info, xmp)
+	}
+
+	// Body text is wrapped paragraph by paragraph, with an empty line
+	// between paragraphs and, if enabled, a section heading before and an
+	// image after every few paragraphs
+	var pending []pdfLine
+	paragraphs, sections := 0, 0
+	nextLine := func() pdfLine {
+		if len(pending) == 0 {
+			if g.Outline && paragraphs%pdfSectionParagraphs == 0 {
+				sections++
+				heading := fmt.Sprintf("%d. %s", sections, strings.Title(randomWord(r)+" "+randomWord(r)))
+				pending = append(pending, pdfLine{text: heading, heading: true})
+			}
+			for _, text := range wrapText(randomParagraph(r), pdfLineChars) {
+				pending = append(pending, pdfLine{text: text})
+			}
+			pending = append(pending, pdfLine{})
+			paragraphs++
+			if g.imageFilter() != "none" && paragraphs%g.imageEvery() == 0 {
+				pending = append(pending, pdfLine{image: true})
+			}
+		}
+		line := pending[0]
+		pending = pending[1:]
+		return line
+	}
+
+	// tail estimates what is left to write once the current page's
+	// content is: its stream wrapper and page object, the page tree,
+	// the cross-reference table, the trailer and any updates. Encryption
+	// adds the /Encrypt and /ID entries and AES padding, annotations the
+	// page's annotation objects, the outline an item per heading, and
+	// updates with metadata rewrite it.
+	var nodes []pdfPageNode
+	var headings []pdfHeading
+	overhead, perUpdate, perHeading := 320, 700, 200
+	if layout.crypt != nil {
+		overhead += 200
+		perHeading += 100
+	}
+	if g.Annotations {
+		overhead += 1000
+	}
+	if g.Info {
+		perUpdate += 2000
+	}
+	tail := func() int64 {
+		return int64(overhead + 100*len(nodes) + 12*(g.pages+1) + 20*len(pw.objects) + perUpdate*g.Updates + perHeading*len(headings))
+	}
+
+	// The pages revised by the updates are sampled as the pages are
+	// written (reservoir sampling), so not every page has to be kept
+	var revised []pdfPage
+	prevPage := 0
+
+	// newPage reserves the objects of the next page and starts its
+	// content; addPage writes the page once its content is written
+	newPage := func() (node *pdfPageNode, contents, page int, content *bytes.Buffer) {
+		if g.pages%pdfPagesPerNode == 0 {
+			nodes = append(nodes, pdfPageNode{num: pw.alloc()})
+		}
+		node = &nodes[len(nodes)-1]
+		contents, page = pw.alloc(), pw.alloc()
+		content = new(bytes.Buffer)
+		if g.headers() {
+			fmt.Fprintf(content, "BT\n/F1 9 Tf\n%d %d Td\n(%s) Tj\nET\n", pdfMargin, height-40, escapePdfString(title))
+			fmt.Fprintf(content, "BT\n/F1 9 Tf\n%d %d Td\n(Page %d) Tj\nET\n", width/2-15, 36, g.pages+1)
+		}
+		return node, contents, page, content
+	}
+	addPage := func(node *pdfPageNode, p pdfPage) {
+		if g.Annotations {
+			annots := writeAnnotations(pw, r, width, height, prevPage)
+			p.annots = append(p.annots, annots...)
+			g.annotations += len(annots)
+		}
+		pw.object(p.num, p.dict())
+		node.kids = append(node.kids, p.num)
+		g.pages++
+		prevPage = p.num
+
+		if len(revised) < g.Updates {
+			revised = append(revised, p)
+		} else if g.Updates > 0 {
+			if i := r.IntN(g.pages); i < g.Updates {
+				revised[i] = p
+			}
+		}
+	}
+
+	// Form pages come first, whatever the size
+	for i := range g.Forms {
+		node, contents, page, content := newPage()
+		annots := form.page(pw, r, content, i+1, page, height)
+		g.contentStream(pw, contents, content.Bytes())
+		addPage(node, pdfPage{num: page, parent: node.num, contents: []int{contents}, annots: annots})
+	}
+	if form != nil {
+		form.write(pw, font)
+		g.formFields = form.truth
+	}
+
+	linesPerPage := (height-2*pdfBodyMargin)/pdfLeading + 1
+	imageLines := (pdfImageSide+pdfLeading-1)/pdfLeading + 1
+	for done := false; !done && pw.err == nil; {
+		node, contents, page, content := newPage()
+		fmt.Fprintf(content, "BT\n/F1 12 Tf\n%d %d Td\n%d TL\n", pdfMargin, height-pdfBodyMargin, pdfLeading)
+
+		// lines counts the body lines used, images taking several
+		var xobjects []string
+		lines := 0
+		for lines < linesPerPage {
+			line := nextLine()
+			if line.image {
+				if lines+imageLines > linesPerPage {
+					// Moves to the top of the next page
+					pending = append([]pdfLine{line}, pending...)
+					break
+				}
+				img, err := g.image(r)
+				if err != nil {
+					return pw.Len(), err
+				}
+				if pw.size()+int64(content.Len()+len(img.data)+len(img.dict)+200)+tail() > sizeBytes {
+					continue // no room left for it, but maybe for more text
+				}
+				num := pw.alloc()
+				pw.stream(num, img.dict, img.data)
+				g.animals = append(g.animals, img.animal)
+				name := fmt.Sprintf("Im%d", len(xobjects)+1)
+				xobjects = append(xobjects, fmt.Sprintf("/%s %d 0 R", name, num))
+
+				top := height - pdfBodyMargin - lines*pdfLeading + 10
+				fmt.Fprintf(content, "ET\nq\n%d 0 0 %d %d %d cm\n/%s Do\nQ\n",
+					pdfImageSide, pdfImageSide, pdfMargin, top-pdfImageSide, name)
+				lines += imageLines
+				fmt.Fprintf(content, "BT\n/F1 12 Tf\n%d %d Td\n%d TL\n",
+					pdfMargin, height-pdfBodyMargin-lines*pdfLeading, pdfLeading)
+				continue
+			}
+			text := fmt.Sprintf("(%s) Tj T*\n", escapePdfString(line.text))
+			if line.heading {
+				text = "/F2 12 Tf\n" + text + "/F1 12 Tf\n"
+			}
+			if pw.size()+int64(content.Len()+len(text))+tail() > sizeBytes {
+				done = true
+				break
+			}
+			content.WriteString(text)
+			if line.heading {
+				headings = append(headings, pdfHeading{line.text, page, height - pdfBodyMargin - lines*pdfLeading + pdfLeading})
+			}
+			lines++
+		}
+		content.WriteString("ET")
+
+		// A page without room for a single line is left out, unless the
+		// document would have no page at all
+		if lines == 0 && g.pages > 0 {
+			pw.release(contents)
+			if len(node.kids) == 0 {
+				nodes = nodes[:len(nodes)-1]
+				pw.release(node.num)
+			}
+			break
+		}
+		g.contentStream(pw, contents, content.Bytes())
+		p := pdfPage{num: page, parent: node.num, contents: []int{contents}}
+		if len(xobjects) > 0 {
+			// Resources are inherited as a whole, so the fonts are repeated
+			p.resources = fmt.Sprintf(" /Resources << /Font << %s >> /XObject << %s >> >>", fonts, strings.Join(xobjects, " "))
+		}
+		addPage(node, p)
+	}
+
+	// Page tree: the root holds the intermediate nodes, which hold the
+	// pages. Page size and resources are inherited from the root.
+	kids := make([]int, len(nodes))
+	for i, node := range nodes {
+		kids[i] = node.num
+		pw.object(node.num, fmt.Sprintf("<< /Type /Pages /Parent %d 0 R /Kids [%s] /Count %d >>",
+			root, pdfRefs(node.kids), len(node.kids)))
+	}
+	pw.object(root, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d /MediaBox [0 0 %d %d] /Resources << /Font << %s >> >> >>",
+		pdfRefs(kids), g.pages, width, height, fonts))
+	if g.Outline {
+		writeOutline(pw, outline, headings)
+		g.headings = len(headings)
+	}
+
+	// Each incremental update stamps a revision note on a page: a new
+	// content stream, and the page object rewritten to draw it too. The
+	// metadata is rewritten with a later modification date.
+	for i := range g.Updates {
+		pw.endSection(catalog, -1)
+		p := &revised[i%len(revised)]
+		stamp := pw.alloc()
+		g.contentStream(pw, stamp, []byte(fmt.Sprintf("BT\n/F1 9 Tf\n%d %d Td\n(Revision %d: %s) Tj\nET",
+			width-200, height-40, i+1, escapePdfString(randomWord(r)+" "+randomWord(r)))))
+		p.contents = append(p.contents, stamp)
+		pw.object(p.num, p.dict())
+		if g.Info {
+			g.info.modified = g.info.modified.Add(time.Duration(1+r.IntN(7*86400)) * time.Second)
+			g.info.write(pw, info, xmp)
+		}
+	}
+	return pw.endSection(catalog, sizeBytes)
+}
+
+// contentStream writes a page content stream, compressed if configured
+func (g *PdfGenerator) contentStream(pw *pdfWriter, num int, data []byte) {
+	if g.Compress {
+		pw.stream(num, " /Filter /FlateDecode", flateEncode(data))
+	} else {
+		pw.stream(num, "", data)
+	}
+}
+
+// GenerateExactTo is the same as GenerateTo, which already pads the file to
+// sizeBytes whenever the minimal document fits
+func (g *PdfGenerator) GenerateExactTo(w io.Writer, r *rand.Rand, sizeBytes int64) (int64, error) {
+	return g.GenerateTo(w, r, sizeBytes)
+}
+
+// pdfLine is a line of body text, a section heading or an image
+type pdfLine struct {
+	text    string
+	heading bool
+	image   bool
+}
+
+// pdfPage is a page object
+type pdfPage struct {
+	num       int
+	parent    int
+	contents  []int
+	resources string // the page's own resources, if any
+	annots    []int
+}
+
+func (p *pdfPage) dict() string {
+	contents := pdfRefs(p.contents)
+	if len(p.contents) > 1 {
+		contents = "[" + contents + "]"
+	}
+	annots := ""
+	if len(p.annots) > 0 {
+		annots = " /Annots [" + pdfRefs(p.annots) + "]"
+	}
+	return fmt.Sprintf("<< /Type /Page /Parent %d 0 R /Contents %s%s%s >>", p.parent, contents, p.resources, annots)
+}
+
+// pdfPageNode is an intermediate node of the page tree
+type pdfPageNode struct {
+	num  int
+	kids []int
+}
+
+// wrapText splits text into lines of at most width characters, breaking
+// between words
+func wrapText(text string, width int) []string {
+	var lines []string
+	var line strings.Builder
+	for _, word := range strings.Fields(text) {
+		if line.Len() > 0 && line.Len()+len(word)+1 > width {
+			lines = append(lines, line.String())
+			line.Reset()
+		}
+		if line.Len() > 0 {
+			line.WriteByte(' ')
+		}
+		line.WriteString(word)
+	}
+	if line.Len() > 0 {
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+func escapePdfString(s string) string {
+	var buf bytes.Buffer
+	for _, c := range s {
+		switch c {
+		case '(', ')', '\\':
+			buf.WriteByte('\\')
+			buf.WriteRune(c)
+		default:
+			if c >= 32 && c < 127 {
+				buf.WriteRune(c)
+			} else {
+				buf.WriteByte(' ')
+			}
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+var (
+	pdfObjectPattern = regexp.MustCompile(`(?s)(\d+) 0 obj\n(.*?)\nendobj\n`)
+	pdfCountPattern  = regexp.MustCompile(`/Count (\d+)`)
+	pdfRefPattern    = regexp.MustCompile(`(\d+) 0 R`)
+	pdfFieldsPattern = regexp.MustCompile(`/Fields \[([^\]]*)\]`)
+	pdfTitlePattern  = regexp.MustCompile(`/T \(([^)]*)\)`)
+	pdfValuePattern  = regexp.MustCompile(`/V (?:\(([^)]*)\)|/([^\s/>\]]+))`)
+)
+
+// pdfObjects returns the latest revision of every object of an
+// uncompressed, unencrypted PDF without object streams
+func pdfObjects(data []byte) map[int]string {
+	objects := make(map[int]string)
+	for _, m := range pdfObjectPattern.FindAllSubmatch(data, -1) {
+		num, _ := strconv.Atoi(string(m[1]))
+		objects[num] = string(m[2])
+	}
+	return objects
+}
+
+// checkPdfMetadata compares the metadata of the last document g generated
+// with the page tree, image XObjects and form fields of data
+func checkPdfMetadata(t *testing.T, g *PdfGenerator, data []byte) {
+	t.Helper()
+	metadata := g.Metadata()
+	objects := pdfObjects(data)
+
+	pages, images := 0, 0
+	for _, body := range objects {
+		switch {
+		case bytes.Contains([]byte(body), []byte("/Type /Pages /Kids")):
+			m := pdfCountPattern.FindStringSubmatch(body)
+			if count, _ := strconv.Atoi(m[1]); count != metadata["pages"] {
+				t.Errorf("page tree /Count is %d, metadata says %v", count, metadata["pages"])
+			}
+		case bytes.Contains([]byte(body), []byte("/Type /Page ")):
+			pages++
+		case bytes.Contains([]byte(body), []byte("/Subtype /Image")):
+			images++
+		}
+	}
+	if pages != metadata["pages"] {
+		t.Errorf("%d page objects, metadata says %v pages", pages, metadata["pages"])
+	}
+	if g.imageFilter() != "none" && images != metadata["images"] {
+		t.Errorf("%d image XObjects, metadata says %v", images, metadata["images"])
+	}
+
+	if g.Forms == 0 {
+		return
+	}
+	truth := metadata["form_fields"].([]pdfFormField)
+	var fields []pdfFormField
+	for _, body := range objects {
+		m := pdfFieldsPattern.FindStringSubmatch(body)
+		if m == nil {
+			continue
+		}
+		for _, ref := range pdfRefPattern.FindAllStringSubmatch(m[1], -1) {
+			num, _ := strconv.Atoi(ref[1])
+			field := objects[num]
+			title := pdfTitlePattern.FindStringSubmatch(field)
+			if title == nil {
+				t.Fatalf("field %d has no /T: %s", num, field)
+			}
+			value := ""
+			if v := pdfValuePattern.FindStringSubmatch(field); v != nil && v[2] != "Off" {
+				value = v[1] + v[2]
+			}
+			fields = append(fields, pdfFormField{Name: title[1], Value: value})
+		}
+	}
+	if len(fields) != len(truth) {
+		t.Fatalf("/Fields has %d fields, the manifest %d", len(fields), len(truth))
+	}
+	for i, f := range fields {
+		want := truth[i].Value
+		if want == "Off" {
+			want = ""
+		}
+		if f.Name != truth[i].Name || f.Value != want {
+			t.Errorf("field %d is %s=%q, the manifest says %s=%q", i, f.Name, f.Value, truth[i].Name, truth[i].Value)
+		}
+	}
+}
+
+func TestPdfMetadataMatchesDocument(t *testing.T) {
+	tests := []struct {
+		name string
+		g    PdfGenerator
+		size int
+	}{
+		{"plain", PdfGenerator{}, 8 * 1024},
+		{"many pages", PdfGenerator{PageSize: "a4"}, 600 * 1024},
+		{"raw images", PdfGenerator{Images: "raw", ImageEvery: 1}, 200 * 1024},
+		{"flate images", PdfGenerator{Images: "flate", Updates: 2}, 100 * 1024},
+		{"blank forms", PdfGenerator{Forms: 2}, 20 * 1024},
+		{"filled forms", PdfGenerator{Forms: 3, Prefill: true, Outline: true, Annotations: true, Info: true}, 60 * 1024},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.g.Generate(newFileRand(4, 1), tt.size)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkPdfMetadata(t, &tt.g, data)
+		})
+	}
+}
+
+func TestPdfGeneratorReuse(t *testing.T) {
+	reused := &PdfGenerator{Images: "flate", Forms: 2, Prefill: true, Outline: true, Annotations: true, Info: true}
+	for i, size := range []int{300 * 1024, 40 * 1024} {
+		data, err := reused.Generate(newFileRand(6, i+1), size)
+		if err != nil {
+			t.Fatalf("document %d: %v", i+1, err)
+		}
+		checkPdfMetadata(t, reused, data)
+
+		fresh := *reused
+		want, err := fresh.Generate(newFileRand(6, i+1), size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, want) || !reflect.DeepEqual(reused.Metadata(), fresh.Metadata()) {
+			t.Errorf("document %d differs from the same document from a new generator", i+1)
+		}
+	}
+}
@@ -0,0 +1,686 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"hash/crc32"
+	"image/png"
+	"io"
+	"math/rand/v2"
+	"strings"
+)
+
+// PptxGenerator generates valid PPTX files (Office Open XML) that look like
+// real PowerPoint decks: a title slide, section title slides, bullet
+// slides and slides with pixel art animals, each with speaker notes. The
+// size is reached with the number of slides.
+type PptxGenerator struct {
+	OfficeProperties
+
+	props   officeProperties // the properties of the last generated deck
+	slides  int              // its slides
+	animals []string         // its embedded images
+}
+
+// Deck layout, in EMUs (914400 per inch) unless noted
+const (
+	pptxMaxImages     = 8         // distinct images in ppt/media; later slides reuse them
+	pptxImageBytes    = 32 * 1024 // deck size per embedded image
+	pptxImagePixels   = 128       // width and height of an image in pixels
+	pptxImageEMU      = 3657600   // width and height of an image on a slide (4 inches)
+	pptxSlideWidth    = 12192000  // 16:9
+	pptxSlideHeight   = 6858000
+	pptxSectionSize   = 10 // slides per section, each starting with a section title slide
+	pptxImageEvery    = 3  // every third slide shows an image, if there are any
+	pptxFirstSlideRel = 10 // relationship id of the first slide in presentation.xml.rels
+	pptxNS            = `xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main"`
+)
+
+// What slides add to a package: their entries, measured in a zip of their
+// own without its end record, and their share of the parts that list the
+// slides, estimated from a sample when compressed. A package needs zip64
+// end records once it has 65535 entries.
+const (
+	pptxTailSample = 256 // slides of the sample
+	pptxEmptyZip   = 22  // size of a zip file without entries: its end of central directory record
+	pptxZip64End   = 76  // zip64 end of central directory record and locator
+	pptxFixedParts = 21  // entries of a deck besides its media and slides
+)
+
+// Slide layouts, numbered as in ppt/slideLayouts
+const (
+	pptxLayoutTitle     = 1 // title and subtitle
+	pptxLayoutContent   = 2 // title and bullets
+	pptxLayoutTitleOnly = 3 // title above a picture
+)
+
+func (g *PptxGenerator) Extension() string {
+	return "pptx"
+}
+
+// Metadata reports the document properties, the number of slides, and the
+// animals of the embedded images
+func (g *PptxGenerator) Metadata() map[string]any {
+	metadata := map[string]any{"slides": g.slides, "images": len(g.animals), "animals": g.animals}
+	g.props.addMetadata(metadata)
+	return metadata
+}
+
+func (g *PptxGenerator) Generate(r *rand.Rand, sizeBytes int) ([]byte, error) {
+	return generateBuffered(g, r, sizeBytes)
+}
+
+// GenerateTo compresses the slides and adds them until the next one would
+// exceed the target size
+func (g *PptxGenerator) GenerateTo(w io.Writer, r *rand.Rand, sizeBytes int64) (int64, error) {
+	return g.generate(w, zip.Deflate, r, sizeBytes)
+}
+
+// GenerateExactTo stores the slides and the parts that list them
+// uncompressed, so the size of every slide is known before it is added,
+// and pads ppt/presentation.xml with the bytes left after the last slide
+// that fits
+func (g *PptxGenerator) GenerateExactTo(w io.Writer, r *rand.Rand, sizeBytes int64) (int64, error) {
+	return g.generate(w, zip.Store, r, sizeBytes)
+}
+
+// generate writes a deck with as many slides as fit in sizeBytes, and at
+// least the title slide. The package overhead is measured first by
+// writing it without slides.
+func (g *PptxGenerator) generate(w io.Writer, method uint16, r *rand.Rand, sizeBytes int64) (int64, error) {
+	images, err := g.prepare(r, sizeBytes)
+	if err != nil {
+		return 0, err
+	}
+	overhead, err := g.writePackage(io.Discard, method, r, images, func(*pptxDeck) {})
+	if err != nil {
+		return 0, err
+	}
+	return g.writePackage(w, method, r, images, func(deck *pptxDeck) {
+		budget := sizeBytes - overhead
+		for deck.err == nil {
+			slide := deck.next()
+			if deck.slides > 0 && deck.size+slide.size+deck.zip64End(deck.slides+1) > budget {
+				break
+			}
+			deck.write(slide)
+		}
+		if method == zip.Store {
+			deck.padding = budget - deck.size - deck.zip64End(deck.slides)
+		}
+	})
+}
+
+// prepare picks the document properties and draws the images, which are
+// shared by both passes of generate. Larger decks get more distinct
+// images.
+func (g *PptxGenerator) prepare(r *rand.Rand, sizeBytes int64) ([]docxImage, error) {
+	g.props = g.resolve(r, docxHeadingText(r))
+	images := make([]docxImage, min(pptxMaxImages, int(sizeBytes/pptxImageBytes)))
+	g.animals = g.animals[:0]
+	for i := range images {
+		animal := GetRandomAnimal(r)
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, drawAnimal(animal, randomPastelColor(r), pptxImagePixels, pptxImagePixels)); err != nil {
+			return nil, err
+		}
+		images[i] = docxImage{animal: animal.Name, data: buf.Bytes()}
+		g.animals = append(g.animals, animal.Name)
+	}
+	return images, nil
+}
+
+// writePackage writes the PPTX package. fill adds the slides, which are
+// compressed with the given method like the parts that list them, written
+// last once the number of slides is known.
+func (g *PptxGenerator) writePackage(w io.Writer, method uint16, r *rand.Rand, images []docxImage, fill func(deck *pptxDeck)) (int64, error) {
+	sw := newSizedWriter(w, -1)
+	zipWriter := zip.NewWriter(sw)
+
+	// _rels/.rels
+	rels := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="ppt/presentation.xml"/>` + officePropsRelationships + `
+</Relationships>`
+	err := writeZipFile(zipWriter, "_rels/.rels", rels)
+	if err == nil {
+		err = writeDocProps(zipWriter, &g.props, "Microsoft Office PowerPoint", `
+  <PresentationFormat>Widescreen</PresentationFormat>`)
+	}
+	if err != nil {
+		return sw.Len(), err
+	}
+
+	// The masters, layouts and themes the slides are based on
+	parts := [][2]string{
+		{"ppt/theme/theme1.xml", pptxTheme},
+		{"ppt/theme/theme2.xml", pptxTheme},
+		{"ppt/slideMasters/slideMaster1.xml", pptxSlideMaster},
+		{"ppt/slideMasters/_rels/slideMaster1.xml.rels", pptxRels(
+			pptxRel(1, "slideLayout", "../slideLayouts/slideLayout1.xml"),
+			pptxRel(2, "slideLayout", "../slideLayouts/slideLayout2.xml"),
+			pptxRel(3, "slideLayout", "../slideLayouts/slideLayout3.xml"),
+			pptxRel(4, "theme", "../theme/theme1.xml"))},
+	}
+	for i, layout := range []string{pptxTitleLayout, pptxContentLayout, pptxTitleOnlyLayout} {
+		parts = append(parts,
+			[2]string{fmt.Sprintf("ppt/slideLayouts/slideLayout%d.xml", i+1), layout},
+			[2]string{fmt.Sprintf("ppt/slideLayouts/_rels/slideLayout%d.xml.rels", i+1),
+				pptxRels(pptxRel(1, "slideMaster", "../slideMasters/slideMaster1.xml"))})
+	}
+	parts = append(parts,
+		[2]string{"ppt/notesMasters/notesMaster1.xml", pptxNotesMaster},
+		[2]string{"ppt/notesMasters/_rels/notesMaster1.xml.rels", pptxRels(pptxRel(1, "theme", "../theme/theme2.xml"))},
+		[2]string{"ppt/presProps.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<p:presentationPr ` + pptxNS + `/>`},
+		[2]string{"ppt/viewProps.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<p:viewPr ` + pptxNS + `><p:normalViewPr><p:restoredLeft sz="15620"/><p:restoredTop sz="94660"/></p:normalViewPr><p:gridSpacing cx="76200" cy="76200"/></p:viewPr>`},
+		[2]string{"ppt/tableStyles.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<a:tblStyleLst xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" def="{5C22544A-7EE6-4342-B048-85BDC9FD1C3A}"/>`})
+	for _, part := range parts {
+		if err := writeZipFile(zipWriter, part[0], part[1]); err != nil {
+			return sw.Len(), err
+		}
+	}
+
+	// ppt/media, stored since PNG is already compressed
+	for i, img := range images {
+		mediaWriter, err := zipWriter.CreateHeader(&zip.FileHeader{Name: fmt.Sprintf("ppt/media/image%d.png", i+1), Method: zip.Store})
+		if err != nil {
+			return sw.Len(), err
+		}
+		if _, err := mediaWriter.Write(img.data); err != nil {
+			return sw.Len(), err
+		}
+	}
+
+	// ppt/slides and ppt/notesSlides, each slide streamed straight into
+	// its zip entries
+	deck := newPptxDeck(r, &g.props, images, zipWriter, method)
+	fill(deck)
+	if deck.err != nil {
+		return sw.Len(), deck.err
+	}
+	g.slides = deck.slides
+
+	// [Content_Types].xml, ppt/presentation.xml and its relationships,
+	// which list the slides
+	for _, part := range []struct {
+		name  string
+		write func(w io.Writer, slides int, padding int64)
+	}{
+		{"[Content_Types].xml", writePptxContentTypes},
+		{"ppt/presentation.xml", writePptxPresentation},
+		{"ppt/_rels/presentation.xml.rels", writePptxPresentationRels},
+	} {
+		partWriter, err := zipWriter.CreateHeader(&zip.FileHeader{Name: part.name, Method: method})
+		if err != nil {
+			return sw.Len(), err
+		}
+		pw := newSizedWriter(partWriter, -1)
+		part.write(pw, deck.slides, deck.padding)
+		if pw.err != nil {
+			return sw.Len(), pw.err
+		}
+	}
+
+	err = zipWriter.Close()
+	return sw.Len(), err
+}
+
+// pptxDeck generates the slides of a deck and writes them to the package.
+// size is the number of bytes the written slides add to the package,
+// including their entries in the parts that list them.
+type pptxDeck struct {
+	r      *rand.Rand
+	props  *officeProperties
+	images []docxImage
+	zip    *zip.Writer
+	packer pptxPacker
+	err    error
+
+	slides     int // slides written
+	sections   int // section title slides generated
+	figures    int // image slides generated
+	size       int64
+	padding    int64   // bytes of whitespace to pad ppt/presentation.xml with
+	tailSlope  float64 // bytes a slide adds to the compressed parts that list the slides
+	nextSample int     // slide the slope is estimated again at, once its number has another digit
+}
+
+// pptxSlide is a slide with its notes, as zip entries, and the bytes it
+// adds to the package
+type pptxSlide struct {
+	parts []pptxPart
+	size  int64
+}
+
+// pptxPart is a zip entry whose data is already compressed with the
+// method of its header, so its size is known before it is written
+type pptxPart struct {
+	header *zip.FileHeader
+	data   []byte
+}
+
+// pptxPacker compresses parts with one method, reusing its compressor
+type pptxPacker struct {
+	method     uint16
+	compressor *flate.Writer
+	buf        bytes.Buffer
+}
+
+// part compresses data into the zip entry name
+func (p *pptxPacker) part(name, data string) pptxPart {
+	header := &zip.FileHeader{Name: name, Method: p.method, CRC32: crc32.ChecksumIEEE([]byte(data)), UncompressedSize64: uint64(len(data))}
+	compressed := []byte(data)
+	if p.method != zip.Store {
+		p.buf.Reset()
+		if p.compressor == nil {
+			p.compressor, _ = flate.NewWriter(&p.buf, flate.DefaultCompression)
+		} else {
+			p.compressor.Reset(&p.buf)
+		}
+		io.WriteString(p.compressor, data)
+		p.compressor.Close()
+		compressed = bytes.Clone(p.buf.Bytes())
+	}
+	header.CompressedSize64 = uint64(len(compressed))
+	return pptxPart{header, compressed}
+}
+
+func newPptxDeck(r *rand.Rand, props *officeProperties, images []docxImage, zw *zip.Writer, method uint16) *pptxDeck {
+	return &pptxDeck{r: r, props: props, images: images, zip: zw, packer: pptxPacker{method: method}, nextSample: 1}
+}
+
+// next generates the slide after the ones written: the title slide first,
+// then a section title slide at the start of every section, and bullet
+// slides with an image slide every pptxImageEvery slides
+func (d *pptxDeck) next() pptxSlide {
+	r := d.r
+	index := d.slides + 1
+	layout := pptxLayoutContent
+	var shapes string
+	image := -1
+	switch {
+	case index == 1:
+		layout = pptxLayoutTitle
+		shapes = pptxTextShape(2, "Title 1", `<p:ph type="ctrTitle"/>`, pptxParagraph(0, xmlEscape(d.props.title))) +
+			pptxTextShape(3, "Subtitle 2", `<p:ph type="subTitle" idx="1"/>`,
+				pptxParagraph(0, xmlEscape(d.props.author))+pptxParagraph(0, d.props.created.Format("January 2, 2006")))
+	case index%pptxSectionSize == 1:
+		d.sections++
+		layout = pptxLayoutTitle
+		shapes = pptxTextShape(2, "Title 1", `<p:ph type="ctrTitle"/>`, pptxParagraph(0, fmt.Sprintf("%d. %s", d.sections, docxHeadingText(r)))) +
+			pptxTextShape(3, "Subtitle 2", `<p:ph type="subTitle" idx="1"/>`, pptxParagraph(0, strings.TrimSuffix(randomSentence(r), ".")))
+	case index%pptxImageEvery == 0 && len(d.images) > 0:
+		// Every image is shown once before any is shown again
+		d.figures++
+		layout = pptxLayoutTitleOnly
+		image = d.figures - 1
+		if image >= len(d.images) {
+			image = r.IntN(len(d.images))
+		}
+		shapes = pptxTextShape(2, "Title 1", `<p:ph type="title"/>`, pptxParagraph(0, d.images[image].animal)) +
+			pptxPicture(3, image, d.images[image].animal)
+	default:
+		var bullets strings.Builder
+		for i := 3 + r.IntN(4); i > 0; i-- {
+			level := 0
+			if bullets.Len() > 0 && r.IntN(4) == 0 {
+				level = 1
+			}
+			bullets.WriteString(pptxParagraph(level, strings.TrimSuffix(randomSentence(r), ".")))
+		}
+		shapes = pptxTextShape(2, "Title 1", `<p:ph type="title"/>`, pptxParagraph(0, docxHeadingText(r))) +
+			pptxTextShape(3, "Content Placeholder 2", `<p:ph idx="1"/>`, bullets.String())
+	}
+
+	var notes strings.Builder
+	for i := 1 + r.IntN(2); i > 0; i-- {
+		notes.WriteString(pptxParagraph(0, randomParagraph(r)))
+	}
+
+	slideRels := []string{
+		pptxRel(1, "slideLayout", fmt.Sprintf("../slideLayouts/slideLayout%d.xml", layout)),
+		pptxRel(2, "notesSlide", fmt.Sprintf("../notesSlides/notesSlide%d.xml", index)),
+	}
+	if image >= 0 {
+		slideRels = append(slideRels, pptxRel(3, "image", fmt.Sprintf("../media/image%d.png", image+1)))
+	}
+	slide := pptxSlide{parts: []pptxPart{
+		d.packer.part(fmt.Sprintf("ppt/slides/slide%d.xml", index), pptxSlideXML("sld", shapes)),
+		d.packer.part(fmt.Sprintf("ppt/slides/_rels/slide%d.xml.rels", index), pptxRels(slideRels...)),
+		d.packer.part(fmt.Sprintf("ppt/notesSlides/notesSlide%d.xml", index), pptxSlideXML("notes", pptxNotesShapes(notes.String()))),
+		d.packer.part(fmt.Sprintf("ppt/notesSlides/_rels/notesSlide%d.xml.rels", index), pptxRels(
+			pptxRel(1, "notesMaster", "../notesMasters/notesMaster1.xml"),
+			pptxRel(2, "slide", fmt.Sprintf("../slides/slide%d.xml", index)))),
+	}}
+
+	// The slide's entries, and what it adds to the parts that list it:
+	// exactly when they are stored, an estimate when they are compressed
+	slide.size = pptxPartsSize(slide.parts)
+	if d.packer.method == zip.Store {
+		slide.size += int64(len(pptxSlideTypes(index)) + len(pptxSlideID(index)) + len(pptxSlideRel(index)))
+	} else {
+		if index == d.nextSample {
+			d.tailSlope = d.sampleTail(index)
+			d.nextSample *= 10
+		}
+		slide.size += int64(d.tailSlope)
+	}
+	return slide
+}
+
+// sampleTail estimates the bytes each slide from the given one on adds to
+// the compressed parts that list the slides, by compressing the entries of
+// a sample of pptxTailSample slides
+func (d *pptxDeck) sampleTail(from int) float64 {
+	var types, ids, rels strings.Builder
+	for i := from; i < from+pptxTailSample; i++ {
+		types.WriteString(pptxSlideTypes(i))
+		ids.WriteString(pptxSlideID(i))
+		rels.WriteString(pptxSlideRel(i))
+	}
+	sample := func(types, ids, rels string) int64 {
+		return pptxPartsSize([]pptxPart{
+			d.packer.part("[Content_Types].xml", types),
+			d.packer.part("ppt/presentation.xml", ids),
+			d.packer.part("ppt/_rels/presentation.xml.rels", rels),
+		})
+	}
+	return float64(sample(types.String(), ids.String(), rels.String())-sample("", "", "")) / pptxTailSample
+}
+
+// zip64End returns the bytes of zip64 end records the package needs with
+// the given number of slides, each of which is four entries
+func (d *pptxDeck) zip64End(slides int) int64 {
+	if pptxFixedParts+len(d.images)+4*slides >= 0xFFFF {
+		return pptxZip64End
+	}
+	return 0
+}
+
+// write adds a slide generated by next to the package
+func (d *pptxDeck) write(slide pptxSlide) {
+	for _, part := range slide.parts {
+		w, err := d.zip.CreateRaw(part.header)
+		if err != nil {
+			d.err = err
+			return
+		}
+		if _, err := w.Write(part.data); err != nil {
+			d.err = err
+			return
+		}
+	}
+	d.slides++
+	d.size += slide.size
+}
+
+// pptxPartsSize returns the bytes that parts add to a package
+func pptxPartsSize(parts []pptxPart) int64 {
+	sw := newSizedWriter(io.Discard, -1)
+	zw := zip.NewWriter(sw)
+	for _, part := range parts {
+		header := *part.header
+		w, err := zw.CreateRaw(&header)
+		if err != nil {
+			return 0
+		}
+		w.Write(part.data)
+	}
+	zw.Close()
+	return sw.Len() - pptxEmptyZip
+}
+
+// writePptxContentTypes writes [Content_Types].xml
+func writePptxContentTypes(w io.Writer, slides int, _ int64) {
+	io.WriteString(w, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+  <Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+  <Default Extension="xml" ContentType="application/xml"/>
+  <Default Extension="png" ContentType="image/png"/>
+  <Override PartName="/ppt/presentation.xml" ContentType="application/vnd.openxmlformats-officedocument.presentationml.presentation.main+xml"/>
+  <Override PartName="/ppt/slideMasters/slideMaster1.xml" ContentType="application/vnd.openxmlformats-officedocument.presentationml.slideMaster+xml"/>
+  <Override PartName="/ppt/slideLayouts/slideLayout1.xml" ContentType="application/vnd.openxmlformats-officedocument.presentationml.slideLayout+xml"/>
+  <Override PartName="/ppt/slideLayouts/slideLayout2.xml" ContentType="application/vnd.openxmlformats-officedocument.presentationml.slideLayout+xml"/>
+  <Override PartName="/ppt/slideLayouts/slideLayout3.xml" ContentType="application/vnd.openxmlformats-officedocument.presentationml.slideLayout+xml"/>
+  <Override PartName="/ppt/notesMasters/notesMaster1.xml" ContentType="application/vnd.openxmlformats-officedocument.presentationml.notesMaster+xml"/>
+  <Override PartName="/ppt/theme/theme1.xml" ContentType="application/vnd.openxmlformats-officedocument.theme+xml"/>
+  <Override PartName="/ppt/theme/theme2.xml" ContentType="application/vnd.openxmlformats-officedocument.theme+xml"/>
+  <Override PartName="/ppt/presProps.xml" ContentType="application/vnd.openxmlformats-officedocument.presentationml.presProps+xml"/>
+  <Override PartName="/ppt/viewProps.xml" ContentType="application/vnd.openxmlformats-officedocument.presentationml.viewProps+xml"/>
+  <Override PartName="/ppt/tableStyles.xml" ContentType="application/vnd.openxmlformats-officedocument.presentationml.tableStyles+xml"/>`)
+	for i := 1; i <= slides; i++ {
+		io.WriteString(w, pptxSlideTypes(i))
+	}
+	io.WriteString(w, officePropsContentTypes+"\n</Types>")
+}
+
+// writePptxPresentation writes ppt/presentation.xml, padded with
+// whitespace before its end tag
+func writePptxPresentation(w io.Writer, slides int, padding int64) {
+	io.WriteString(w, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<p:presentation `+pptxNS+` saveSubsetFonts="1">
+  <p:sldMasterIdLst><p:sldMasterId id="2147483648" r:id="rId1"/></p:sldMasterIdLst>
+  <p:notesMasterIdLst><p:notesMasterId r:id="rId2"/></p:notesMasterIdLst>
+  <p:sldIdLst>`)
+	for i := 1; i <= slides; i++ {
+		io.WriteString(w, pptxSlideID(i))
+	}
+	fmt.Fprintf(w, `
+  </p:sldIdLst>
+  <p:sldSz cx="%d" cy="%d"/>
+  <p:notesSz cx="6858000" cy="9144000"/>
+`, pptxSlideWidth, pptxSlideHeight)
+	writeRepeated(w, ' ', padding)
+	io.WriteString(w, "</p:presentation>")
+}
+
+// writePptxPresentationRels writes ppt/_rels/presentation.xml.rels: the
+// master, notes master, theme and properties are rId1 to rId6, the slides
+// rId<pptxFirstSlideRel> and on
+func writePptxPresentationRels(w io.Writer, slides int, _ int64) {
+	io.WriteString(w, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`+
+		pptxRel(1, "slideMaster", "slideMasters/slideMaster1.xml")+
+		pptxRel(2, "notesMaster", "notesMasters/notesMaster1.xml")+
+		pptxRel(3, "theme", "theme/theme1.xml")+
+		pptxRel(4, "presProps", "presProps.xml")+
+		pptxRel(5, "viewProps", "viewProps.xml")+
+		pptxRel(6, "tableStyles", "tableStyles.xml"))
+	for i := 1; i <= slides; i++ {
+		io.WriteString(w, pptxSlideRel(i))
+	}
+	io.WriteString(w, "\n</Relationships>")
+}
+
+// pptxSlideTypes are the content types of slide i and its notes
+func pptxSlideTypes(i int) string {
+	return fmt.Sprintf(`
+  <Override PartName="/ppt/slides/slide%d.xml" ContentType="application/vnd.openxmlformats-officedocument.presentationml.slide+xml"/>
+  <Override PartName="/ppt/notesSlides/notesSlide%d.xml" ContentType="application/vnd.openxmlformats-officedocument.presentationml.notesSlide+xml"/>`, i, i)
+}
+
+// pptxSlideID is the entry of slide i in the slide list of
+// ppt/presentation.xml; slide ids start at 256
+func pptxSlideID(i int) string {
+	return fmt.Sprintf(`
+    <p:sldId id="%d" r:id="rId%d"/>`, 255+i, pptxFirstSlideRel+i-1)
+}
+
+// pptxSlideRel is the relationship of ppt/presentation.xml to slide i
+func pptxSlideRel(i int) string {
+	return pptxRel(pptxFirstSlideRel+i-1, "slide", fmt.Sprintf("slides/slide%d.xml", i))
+}
+
+// pptxRel is a relationship of the given officeDocument type
+func pptxRel(id int, relType, target string) string {
+	return fmt.Sprintf(`
+  <Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/%s" Target="%s"/>`, id, relType, target)
+}
+
+// pptxRels is a relationships part
+func pptxRels(rels ...string) string {
+	return `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` + strings.Join(rels, "") + `
+</Relationships>`
+}
+
+// pptxSlideXML is a slide or notes slide with the given shapes
+func pptxSlideXML(root, shapes string) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<p:%s %s>
+  <p:cSld>
+    <p:spTree>%s%s
+    </p:spTree>
+  </p:cSld>
+  <p:clrMapOvr><a:masterClrMapping/></p:clrMapOvr>
+</p:%s>`, root, pptxNS, pptxGroup, shapes, root)
+}
+
+// pptxNotesShapes are the shapes of a notes slide: the slide image and the
+// notes text
+func pptxNotesShapes(paragraphs string) string {
+	return `
+      <p:sp><p:nvSpPr><p:cNvPr id="2" name="Slide Image Placeholder 1"/><p:cNvSpPr><a:spLocks noGrp="1" noRot="1" noChangeAspect="1"/></p:cNvSpPr><p:nvPr><p:ph type="sldImg"/></p:nvPr></p:nvSpPr><p:spPr/></p:sp>` +
+		pptxTextShape(3, "Notes Placeholder 2", `<p:ph type="body" idx="1"/>`, paragraphs)
+}
+
+// pptxTextShape is a placeholder shape with text. The placeholder's
+// position and text style come from the layout and master.
+func pptxTextShape(id int, name, placeholder, paragraphs string) string {
+	return fmt.Sprintf(`
+      <p:sp><p:nvSpPr><p:cNvPr id="%d" name="%s"/><p:cNvSpPr><a:spLocks noGrp="1"/></p:cNvSpPr><p:nvPr>%s</p:nvPr></p:nvSpPr><p:spPr/><p:txBody><a:bodyPr/><a:lstStyle/>%s</p:txBody></p:sp>`,
+		id, name, placeholder, paragraphs)
+}
+
+// pptxParagraph is a paragraph of text at the given bullet level
+func pptxParagraph(level int, text string) string {
+	props := ""
+	if level > 0 {
+		props = fmt.Sprintf(`<a:pPr lvl="%d"/>`, level)
+	}
+	return `<a:p>` + props + `<a:r><a:rPr lang="en-US" dirty="0"/><a:t>` + text + `</a:t></a:r></a:p>`
+}
+
+// pptxPicture is image index of ppt/media, centered below the title. It is
+// relationship rId3 of the slide.
+func pptxPicture(id, index int, animal string) string {
+	return fmt.Sprintf(`
+      <p:pic><p:nvPicPr><p:cNvPr id="%d" name="Picture %d" descr="%s"/><p:cNvPicPr><a:picLocks noChangeAspect="1"/></p:cNvPicPr><p:nvPr/></p:nvPicPr>`+
+		`<p:blipFill><a:blip r:embed="rId3"/><a:stretch><a:fillRect/></a:stretch></p:blipFill>`+
+		`<p:spPr><a:xfrm><a:off x="%d" y="%d"/><a:ext cx="%d" cy="%d"/></a:xfrm><a:prstGeom prst="rect"><a:avLst/></a:prstGeom></p:spPr></p:pic>`,
+		id, index+1, animal, (pptxSlideWidth-pptxImageEMU)/2, 1600200, pptxImageEMU, pptxImageEMU)
+}
+
+// pptxPlaceholder is a placeholder of a master or layout at the given
+// position. body is its text body, if any.
+func pptxPlaceholder(id int, name, placeholder string, x, y, cx, cy int, body string) string {
+	return fmt.Sprintf(`
+      <p:sp><p:nvSpPr><p:cNvPr id="%d" name="%s"/><p:cNvSpPr><a:spLocks noGrp="1"/></p:cNvSpPr><p:nvPr>%s</p:nvPr></p:nvSpPr><p:spPr><a:xfrm><a:off x="%d" y="%d"/><a:ext cx="%d" cy="%d"/></a:xfrm><a:prstGeom prst="rect"><a:avLst/></a:prstGeom></p:spPr>%s</p:sp>`,
+		id, name, placeholder, x, y, cx, cy, body)
+}
+
+// pptxGroup is the group shape properties every shape tree starts with
+const pptxGroup = `
+      <p:nvGrpSpPr><p:cNvPr id="1" name=""/><p:cNvGrpSpPr/><p:nvPr/></p:nvGrpSpPr><p:grpSpPr><a:xfrm><a:off x="0" y="0"/><a:ext cx="0" cy="0"/><a:chOff x="0" y="0"/><a:chExt cx="0" cy="0"/></a:xfrm></p:grpSpPr>`
+
+// pptxEmptyBody is the text body of a placeholder without text
+const pptxEmptyBody = `<p:txBody><a:bodyPr/><a:lstStyle/><a:p><a:endParaRPr lang="en-US"/></a:p></p:txBody>`
+
+// pptxClrMap maps the theme colors for the masters
+const pptxClrMap = `
+  <p:clrMap bg1="lt1" tx1="dk1" bg2="lt2" tx2="dk2" accent1="accent1" accent2="accent2" accent3="accent3" accent4="accent4" accent5="accent5" accent6="accent6" hlink="hlink" folHlink="folHlink"/>`
+
+// pptxSlideMaster is ppt/slideMasters/slideMaster1.xml: the title and body
+// placeholders, the three layouts and the text styles, with bullets on
+// the body text
+var pptxSlideMaster = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<p:sldMaster ` + pptxNS + `>
+  <p:cSld>
+    <p:bg><p:bgRef idx="1001"><a:schemeClr val="bg1"/></p:bgRef></p:bg>
+    <p:spTree>` + pptxGroup +
+	pptxPlaceholder(2, "Title Placeholder 1", `<p:ph type="title"/>`, 838200, 365125, 10515600, 1325563, pptxEmptyBody) +
+	pptxPlaceholder(3, "Text Placeholder 2", `<p:ph type="body" idx="1"/>`, 838200, 1825625, 10515600, 4351338, pptxEmptyBody) + `
+    </p:spTree>
+  </p:cSld>` + pptxClrMap + `
+  <p:sldLayoutIdLst><p:sldLayoutId id="2147483649" r:id="rId1"/><p:sldLayoutId id="2147483650" r:id="rId2"/><p:sldLayoutId id="2147483651" r:id="rId3"/></p:sldLayoutIdLst>
+  <p:txStyles>
+    <p:titleStyle><a:lvl1pPr algn="l"><a:defRPr sz="4400"><a:solidFill><a:schemeClr val="tx1"/></a:solidFill><a:latin typeface="+mj-lt"/></a:defRPr></a:lvl1pPr></p:titleStyle>
+    <p:bodyStyle>
+      <a:lvl1pPr marL="228600" indent="-228600"><a:spcBef><a:spcPts val="1000"/></a:spcBef><a:buFont typeface="Arial"/><a:buChar char="•"/><a:defRPr sz="2800"><a:solidFill><a:schemeClr val="tx1"/></a:solidFill><a:latin typeface="+mn-lt"/></a:defRPr></a:lvl1pPr>
+      <a:lvl2pPr marL="685800" indent="-228600"><a:spcBef><a:spcPts val="500"/></a:spcBef><a:buFont typeface="Arial"/><a:buChar char="–"/><a:defRPr sz="2400"><a:solidFill><a:schemeClr val="tx1"/></a:solidFill><a:latin typeface="+mn-lt"/></a:defRPr></a:lvl2pPr>
+    </p:bodyStyle>
+    <p:otherStyle><a:lvl1pPr><a:defRPr sz="1800"><a:solidFill><a:schemeClr val="tx1"/></a:solidFill><a:latin typeface="+mn-lt"/></a:defRPr></a:lvl1pPr></p:otherStyle>
+  </p:txStyles>
+</p:sldMaster>`
+
+// pptxLayout is a slide layout of the given type and name, based on the
+// master
+func pptxLayout(layoutType, name, shapes string) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<p:sldLayout %s type="%s" preserve="1">
+  <p:cSld name="%s">
+    <p:spTree>%s%s
+    </p:spTree>
+  </p:cSld>
+  <p:clrMapOvr><a:masterClrMapping/></p:clrMapOvr>
+</p:sldLayout>`, pptxNS, layoutType, name, pptxGroup, shapes)
+}
+
+// The slide layouts: a centered title and subtitle, a title above bullets
+// and a title alone. Placeholders without a position take the master's.
+var (
+	pptxTitleLayout = pptxLayout("title", "Title Slide",
+		pptxPlaceholder(2, "Title 1", `<p:ph type="ctrTitle"/>`, 1524000, 1122363, 9144000, 2387600,
+			`<p:txBody><a:bodyPr anchor="b"/><a:lstStyle><a:lvl1pPr algn="ctr"><a:defRPr sz="6000"/></a:lvl1pPr></a:lstStyle><a:p><a:endParaRPr lang="en-US"/></a:p></p:txBody>`)+
+			pptxPlaceholder(3, "Subtitle 2", `<p:ph type="subTitle" idx="1"/>`, 1524000, 3602038, 9144000, 1655762,
+				`<p:txBody><a:bodyPr/><a:lstStyle><a:lvl1pPr marL="0" indent="0" algn="ctr"><a:buNone/><a:defRPr sz="2400"/></a:lvl1pPr></a:lstStyle><a:p><a:endParaRPr lang="en-US"/></a:p></p:txBody>`))
+	pptxContentLayout = pptxLayout("obj", "Title and Content",
+		pptxTextShape(2, "Title 1", `<p:ph type="title"/>`, `<a:p><a:endParaRPr lang="en-US"/></a:p>`)+
+			pptxTextShape(3, "Content Placeholder 2", `<p:ph idx="1"/>`, `<a:p><a:endParaRPr lang="en-US"/></a:p>`))
+	pptxTitleOnlyLayout = pptxLayout("titleOnly", "Title Only",
+		pptxTextShape(2, "Title 1", `<p:ph type="title"/>`, `<a:p><a:endParaRPr lang="en-US"/></a:p>`))
+)
+
+// pptxNotesMaster is ppt/notesMasters/notesMaster1.xml: the slide image
+// above the notes text on a portrait page
+var pptxNotesMaster = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<p:notesMaster ` + pptxNS + `>
+  <p:cSld>
+    <p:bg><p:bgRef idx="1001"><a:schemeClr val="bg1"/></p:bgRef></p:bg>
+    <p:spTree>` + pptxGroup +
+	pptxPlaceholder(2, "Slide Image Placeholder 1", `<p:ph type="sldImg" idx="2"/>`, 685800, 1143000, 5486400, 3086100, "") +
+	pptxPlaceholder(3, "Notes Placeholder 2", `<p:ph type="body" sz="quarter" idx="3"/>`, 685800, 4400550, 5486400, 3600450, pptxEmptyBody) + `
+    </p:spTree>
+  </p:cSld>` + pptxClrMap + `
+  <p:notesStyle><a:lvl1pPr marL="0"><a:defRPr sz="1200"><a:solidFill><a:schemeClr val="tx1"/></a:solidFill><a:latin typeface="+mn-lt"/></a:defRPr></a:lvl1pPr></p:notesStyle>
+</p:notesMaster>`
+
+// pptxTheme is ppt/theme/theme1.xml and theme2.xml: the Office colors and
+// fonts, and the plain fill, line and effect styles every theme needs
+const pptxTheme = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<a:theme xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" name="Office Theme">
+  <a:themeElements>
+    <a:clrScheme name="Office">
+      <a:dk1><a:sysClr val="windowText" lastClr="000000"/></a:dk1><a:lt1><a:sysClr val="window" lastClr="FFFFFF"/></a:lt1>
+      <a:dk2><a:srgbClr val="44546A"/></a:dk2><a:lt2><a:srgbClr val="E7E6E6"/></a:lt2>
+      <a:accent1><a:srgbClr val="4472C4"/></a:accent1><a:accent2><a:srgbClr val="ED7D31"/></a:accent2><a:accent3><a:srgbClr val="A5A5A5"/></a:accent3>
+      <a:accent4><a:srgbClr val="FFC000"/></a:accent4><a:accent5><a:srgbClr val="5B9BD5"/></a:accent5><a:accent6><a:srgbClr val="70AD47"/></a:accent6>
+      <a:hlink><a:srgbClr val="0563C1"/></a:hlink><a:folHlink><a:srgbClr val="954F72"/></a:folHlink>
+    </a:clrScheme>
+    <a:fontScheme name="Office">
+      <a:majorFont><a:latin typeface="Calibri Light"/><a:ea typeface=""/><a:cs typeface=""/></a:majorFont>
+      <a:minorFont><a:latin typeface="Calibri"/><a:ea typeface=""/><a:cs typeface=""/></a:minorFont>
+    </a:fontScheme>
+    <a:fmtScheme name="Office">
+      <a:fillStyleLst><a:solidFill><a:schemeClr val="phClr"/></a:solidFill><a:solidFill><a:schemeClr val="phClr"><a:tint val="50000"/></a:schemeClr></a:solidFill><a:solidFill><a:schemeClr val="phClr"><a:shade val="80000"/></a:schemeClr></a:solidFill></a:fillStyleLst>
+      <a:lnStyleLst><a:ln w="6350"><a:solidFill><a:schemeClr val="phClr"/></a:solidFill></a:ln><a:ln w="12700"><a:solidFill><a:schemeClr val="phClr"/></a:solidFill></a:ln><a:ln w="19050"><a:solidFill><a:schemeClr val="phClr"/></a:solidFill></a:ln></a:lnStyleLst>
+      <a:effectStyleLst><a:effectStyle><a:effectLst/></a:effectStyle><a:effectStyle><a:effectLst/></a:effectStyle><a:effectStyle><a:effectLst/></a:effectStyle></a:effectStyleLst>
+      <a:bgFillStyleLst><a:solidFill><a:schemeClr val="phClr"/></a:solidFill><a:solidFill><a:schemeClr val="phClr"><a:tint val="95000"/></a:schemeClr></a:solidFill><a:solidFill><a:schemeClr val="phClr"><a:shade val="90000"/></a:schemeClr></a:solidFill></a:bgFillStyleLst>
+    </a:fmtScheme>
+  </a:themeElements>
+</a:theme>`
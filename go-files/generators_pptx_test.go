@@ -0,0 +1,81 @@
+package main
+
+import (
+	"image/png"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var pptxSlideIDPattern = regexp.MustCompile(`<p:sldId id="(\d+)" r:id="([^"]+)"/>`)
+
+func TestPptxPackage(t *testing.T) {
+	g := &PptxGenerator{}
+	testOoxml(t, g, 400*1024, func(t *testing.T, pkg ooxmlPackage, contentTypes map[string]string) {
+		metadata := g.Metadata()
+		presentation := pkg.rels(t, "ppt/presentation.xml")
+
+		// The slide list names every slide once, in order
+		ids := pptxSlideIDPattern.FindAllStringSubmatch(pkg["ppt/presentation.xml"], -1)
+		seen := make(map[string]bool)
+		slides := 0
+		for name := range pkg {
+			if strings.HasPrefix(name, "ppt/slides/slide") {
+				slides++
+			}
+		}
+		if len(ids) != metadata["slides"] || slides != len(ids) {
+			t.Fatalf("%d slides listed and %d stored, metadata says %v", len(ids), slides, metadata["slides"])
+		}
+		used := make(map[string]bool) // media shown by a slide
+		for i, m := range ids {
+			slide := presentation[m[2]]
+			if seen[m[1]] || seen[slide] {
+				t.Errorf("slide %d has the id %s or part %s of another slide", i+1, m[1], slide)
+			}
+			seen[m[1]], seen[slide] = true, true
+
+			// Every slide has a layout and notes, which refer back to it
+			var layouts, notes int
+			for _, target := range pkg.rels(t, slide) {
+				switch {
+				case strings.HasPrefix(target, "ppt/slideLayouts/"):
+					layouts++
+				case strings.HasPrefix(target, "ppt/notesSlides/"):
+					notes++
+					found := false
+					for _, source := range pkg.rels(t, target) {
+						found = found || source == slide
+					}
+					if !found {
+						t.Errorf("%s does not refer back to %s", target, slide)
+					}
+				case strings.HasPrefix(target, "ppt/media/"):
+					used[target] = true
+				}
+			}
+			if layouts != 1 || notes != 1 {
+				t.Errorf("%s has %d layouts and %d notes slides", slide, layouts, notes)
+			}
+		}
+
+		// The images in ppt/media are the ones the metadata lists, and
+		// slides show each of them
+		images := 0
+		for name, content := range pkg {
+			if !strings.HasPrefix(name, "ppt/media/") {
+				continue
+			}
+			images++
+			if _, err := png.DecodeConfig(strings.NewReader(content)); err != nil {
+				t.Errorf("%s: %v", name, err)
+			}
+			if !used[name] {
+				t.Errorf("%s is never shown", name)
+			}
+		}
+		if images != metadata["images"] || images == 0 {
+			t.Errorf("%d images in ppt/media, metadata says %v", images, metadata["images"])
+		}
+	})
+}
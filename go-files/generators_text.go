@@ -1,10 +1,14 @@
 package main
 
 import (
-	"bytes"
 	"fmt"
+	"io"
 	"math/rand/v2"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // TxtGenerator generates plain text files
@@ -14,37 +18,167 @@ func (g *TxtGenerator) Extension() string {
 	return "txt"
 }
 
-func (g *TxtGenerator) Generate(sizeBytes int) ([]byte, error) {
-	var buf bytes.Buffer
-	for buf.Len() < sizeBytes {
-		buf.WriteString(randomParagraph())
-		buf.WriteString("\n\n")
+func (g *TxtGenerator) Generate(r *rand.Rand, sizeBytes int) ([]byte, error) {
+	return generateBuffered(g, r, sizeBytes)
+}
+
+func (g *TxtGenerator) GenerateTo(w io.Writer, r *rand.Rand, sizeBytes int64) (int64, error) {
+	sw := newSizedWriter(w, sizeBytes)
+	for !sw.Done() {
+		sw.WriteString(randomParagraph(r))
+		sw.WriteString("\n\n")
 	}
-	return buf.Bytes()[:sizeBytes], nil
+	return sw.Len(), sw.err
+}
+
+// GenerateExactTo is the same as GenerateTo, since plain text can be cut anywhere
+func (g *TxtGenerator) GenerateExactTo(w io.Writer, r *rand.Rand, sizeBytes int64) (int64, error) {
+	return g.GenerateTo(w, r, sizeBytes)
 }
 
 // CsvGenerator generates CSV files
-type CsvGenerator struct{}
+type CsvGenerator struct {
+	Columns []string `json:"columns"` // see csvColumns, defaults to defaultCsvColumns
+
+	rows int // complete data rows of the last generated file
+}
+
+// defaultCsvColumns is the column layout used when none is configured
+var defaultCsvColumns = []string{"id", "name", "email", "department", "salary"}
+
+// csvColumns generates the value of each supported column for record id
+var csvColumns = map[string]func(r *rand.Rand, id int) string{
+	"id":         func(r *rand.Rand, id int) string { return strconv.Itoa(id) },
+	"name":       func(r *rand.Rand, id int) string { return randomWord(r) + " " + randomWord(r) },
+	"first_name": func(r *rand.Rand, id int) string { return randomWord(r) },
+	"last_name":  func(r *rand.Rand, id int) string { return randomWord(r) },
+	"email":      func(r *rand.Rand, id int) string { return randomWord(r) + "@" + randomWord(r) + ".com" },
+	"department": func(r *rand.Rand, id int) string { return randomWord(r) },
+	"city":       func(r *rand.Rand, id int) string { return randomWord(r) },
+	"salary":     func(r *rand.Rand, id int) string { return strconv.Itoa(30000 + r.IntN(70000)) },
+	"age":        func(r *rand.Rand, id int) string { return strconv.Itoa(18 + r.IntN(50)) },
+	"phone":      func(r *rand.Rand, id int) string { return fmt.Sprintf("+1-555-%03d-%04d", r.IntN(1000), r.IntN(10000)) },
+	"date":       func(r *rand.Rand, id int) string { return randomDate(r).Format("2006-01-02") },
+	"active":     func(r *rand.Rand, id int) string { return strconv.FormatBool(r.IntN(2) == 1) },
+	"score":      func(r *rand.Rand, id int) string { return strconv.Itoa(r.IntN(100)) },
+}
+
+// csvTextColumns are free-text columns that can be padded in exact mode
+var csvTextColumns = []string{"name", "department", "city", "first_name", "last_name"}
 
 func (g *CsvGenerator) Extension() string {
 	return "csv"
 }
 
-func (g *CsvGenerator) Generate(sizeBytes int) ([]byte, error) {
-	var buf bytes.Buffer
+// Metadata reports the number of complete data rows, not counting the header
+func (g *CsvGenerator) Metadata() map[string]any {
+	return map[string]any{"rows": g.rows}
+}
+
+// Validate checks the configured columns
+func (g *CsvGenerator) Validate() error {
+	for _, col := range g.Columns {
+		if csvColumns[col] == nil {
+			names := make([]string, 0, len(csvColumns))
+			for name := range csvColumns {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return fmt.Errorf("unknown csv column %q (supported: %s)", col, strings.Join(names, ", "))
+		}
+	}
+	return nil
+}
+
+func (g *CsvGenerator) columns() []string {
+	if len(g.Columns) == 0 {
+		return defaultCsvColumns
+	}
+	return g.Columns
+}
+
+func (g *CsvGenerator) Generate(r *rand.Rand, sizeBytes int) ([]byte, error) {
+	return generateBuffered(g, r, sizeBytes)
+}
+
+// GenerateTo writes whole rows only, as many as fit in sizeBytes
+func (g *CsvGenerator) GenerateTo(w io.Writer, r *rand.Rand, sizeBytes int64) (int64, error) {
+	sw := newSizedWriter(w, -1)
 	// Write header
-	buf.WriteString("id,name,email,department,salary\n")
+	sw.WriteString(strings.Join(g.columns(), ",") + "\n")
+
+	id := 1
+	for sw.err == nil {
+		row := csvLine(g.values(r, id))
+		if sw.Len()+int64(len(row)) > sizeBytes {
+			break
+		}
+		sw.WriteString(row)
+		id++
+	}
+	g.rows = id - 1
+	return sw.Len(), sw.err
+}
+
+// GenerateExactTo writes whole rows only, padding a text column of the
+// last row so the file ends exactly at sizeBytes
+func (g *CsvGenerator) GenerateExactTo(w io.Writer, r *rand.Rand, sizeBytes int64) (int64, error) {
+	sw := newSizedWriter(w, -1)
+	sw.WriteString(strings.Join(g.columns(), ",") + "\n")
 
 	id := 1
-	for buf.Len() < sizeBytes {
-		name := randomWord() + " " + randomWord()
-		email := randomWord() + "@" + randomWord() + ".com"
-		dept := randomWord()
-		salary := 30000 + rand.IntN(70000)
-		buf.WriteString(fmt.Sprintf("%d,%s,%s,%s,%d\n", id, name, email, dept, salary))
+	for sw.err == nil {
+		row := csvLine(g.values(r, id))
+		if sizeBytes-sw.Len()-int64(len(row)) < exactSlack {
+			break
+		}
+		sw.WriteString(row)
 		id++
 	}
-	return buf.Bytes()[:sizeBytes], nil
+
+	if remaining := int(sizeBytes - sw.Len()); remaining > 0 {
+		values := g.values(r, id)
+		if pad := g.textColumn(); pad >= 0 {
+			values[pad] = ""
+			if base := len(csvLine(values)); base <= remaining {
+				values[pad] = randomText(r, remaining-base)
+			}
+		}
+		sw.WriteString(csvLine(values))
+		id++
+	}
+	g.rows = id - 1
+	return sw.Len(), sw.err
+}
+
+// values generates the fields of record id
+func (g *CsvGenerator) values(r *rand.Rand, id int) []string {
+	columns := g.columns()
+	values := make([]string, len(columns))
+	for i, col := range columns {
+		values[i] = csvColumns[col](r, id)
+	}
+	return values
+}
+
+// textColumn returns the index of the first free-text column, or -1
+func (g *CsvGenerator) textColumn() int {
+	for i, col := range g.columns() {
+		if slices.Contains(csvTextColumns, col) {
+			return i
+		}
+	}
+	return -1
+}
+
+// csvLine joins fields into one CSV record
+func csvLine(values []string) string {
+	return strings.Join(values, ",") + "\n"
+}
+
+// randomDate returns a random day between 2022 and 2024
+func randomDate(r *rand.Rand) time.Time {
+	return time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, r.IntN(3*365))
 }
 
 // JsonGenerator generates JSON files
@@ -54,29 +188,64 @@ func (g *JsonGenerator) Extension() string {
 	return "json"
 }
 
-func (g *JsonGenerator) Generate(sizeBytes int) ([]byte, error) {
-	var buf bytes.Buffer
-	buf.WriteString("[\n")
+func (g *JsonGenerator) Generate(r *rand.Rand, sizeBytes int) ([]byte, error) {
+	return generateBuffered(g, r, sizeBytes)
+}
+
+func (g *JsonGenerator) GenerateTo(w io.Writer, r *rand.Rand, sizeBytes int64) (int64, error) {
+	sw := newSizedWriter(w, -1)
+	sw.WriteString("[\n")
 
 	first := true
-	for buf.Len() < sizeBytes-10 {
+	for sw.Len() < sizeBytes-10 && sw.err == nil {
 		if !first {
-			buf.WriteString(",\n")
+			sw.WriteString(",\n")
 		}
 		first = false
+		sw.WriteString(jsonRecord(r))
+	}
 
-		buf.WriteString("  {\n")
-		buf.WriteString(fmt.Sprintf("    \"id\": %d,\n", rand.IntN(100000)))
-		buf.WriteString(fmt.Sprintf("    \"name\": \"%s\",\n", randomWord()+" "+randomWord()))
-		buf.WriteString(fmt.Sprintf("    \"email\": \"%s@%s.com\",\n", randomWord(), randomWord()))
-		buf.WriteString(fmt.Sprintf("    \"active\": %t,\n", rand.IntN(2) == 1))
-		buf.WriteString(fmt.Sprintf("    \"score\": %d,\n", rand.IntN(100)))
-		buf.WriteString(fmt.Sprintf("    \"description\": \"%s\"\n", randomSentence()))
-		buf.WriteString("  }")
+	sw.WriteString("\n]")
+	return sw.Len(), sw.err
+}
+
+// GenerateExactTo writes whole records only and fills the gap before the
+// closing bracket with whitespace
+func (g *JsonGenerator) GenerateExactTo(w io.Writer, r *rand.Rand, sizeBytes int64) (int64, error) {
+	const closer = "\n]"
+	sw := newSizedWriter(w, -1)
+	sw.WriteString("[\n")
+
+	first := true
+	for sw.err == nil {
+		record := jsonRecord(r)
+		if !first {
+			record = ",\n" + record
+		}
+		if sw.Len()+int64(len(record)+len(closer)) > sizeBytes {
+			break
+		}
+		sw.WriteString(record)
+		first = false
 	}
 
-	buf.WriteString("\n]")
-	return buf.Bytes(), nil
+	writeRepeated(sw, ' ', sizeBytes-sw.Len()-int64(len(closer)))
+	sw.WriteString(closer)
+	return sw.Len(), sw.err
+}
+
+// jsonRecord generates one JSON object
+func jsonRecord(r *rand.Rand) string {
+	var sb strings.Builder
+	sb.WriteString("  {\n")
+	sb.WriteString(fmt.Sprintf("    \"id\": %d,\n", r.IntN(100000)))
+	sb.WriteString(fmt.Sprintf("    \"name\": \"%s\",\n", randomWord(r)+" "+randomWord(r)))
+	sb.WriteString(fmt.Sprintf("    \"email\": \"%s@%s.com\",\n", randomWord(r), randomWord(r)))
+	sb.WriteString(fmt.Sprintf("    \"active\": %t,\n", r.IntN(2) == 1))
+	sb.WriteString(fmt.Sprintf("    \"score\": %d,\n", r.IntN(100)))
+	sb.WriteString(fmt.Sprintf("    \"description\": \"%s\"\n", randomSentence(r)))
+	sb.WriteString("  }")
+	return sb.String()
 }
 
 // XmlGenerator generates XML files
@@ -86,22 +255,54 @@ func (g *XmlGenerator) Extension() string {
 	return "xml"
 }
 
-func (g *XmlGenerator) Generate(sizeBytes int) ([]byte, error) {
-	var buf bytes.Buffer
-	buf.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
-	buf.WriteString("<records>\n")
+func (g *XmlGenerator) Generate(r *rand.Rand, sizeBytes int) ([]byte, error) {
+	return generateBuffered(g, r, sizeBytes)
+}
+
+func (g *XmlGenerator) GenerateTo(w io.Writer, r *rand.Rand, sizeBytes int64) (int64, error) {
+	sw := newSizedWriter(w, -1)
+	sw.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	sw.WriteString("<records>\n")
 
-	for buf.Len() < sizeBytes-20 {
-		buf.WriteString("  <record>\n")
-		buf.WriteString(fmt.Sprintf("    <id>%d</id>\n", rand.IntN(100000)))
-		buf.WriteString(fmt.Sprintf("    <name>%s</name>\n", randomWord()+" "+randomWord()))
-		buf.WriteString(fmt.Sprintf("    <email>%s@%s.com</email>\n", randomWord(), randomWord()))
-		buf.WriteString(fmt.Sprintf("    <description>%s</description>\n", randomSentence()))
-		buf.WriteString("  </record>\n")
+	for sw.Len() < sizeBytes-20 && sw.err == nil {
+		sw.WriteString(xmlRecord(r))
 	}
 
-	buf.WriteString("</records>")
-	return buf.Bytes(), nil
+	sw.WriteString("</records>")
+	return sw.Len(), sw.err
+}
+
+// GenerateExactTo writes whole records only and fills the gap before the
+// closing tag with whitespace
+func (g *XmlGenerator) GenerateExactTo(w io.Writer, r *rand.Rand, sizeBytes int64) (int64, error) {
+	const closer = "</records>"
+	sw := newSizedWriter(w, -1)
+	sw.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	sw.WriteString("<records>\n")
+
+	for sw.err == nil {
+		record := xmlRecord(r)
+		if sw.Len()+int64(len(record)+len(closer)) > sizeBytes {
+			break
+		}
+		sw.WriteString(record)
+	}
+
+	writeRepeated(sw, ' ', sizeBytes-sw.Len()-int64(len(closer)))
+	sw.WriteString(closer)
+	return sw.Len(), sw.err
+}
+
+// xmlRecord generates one <record> element
+func xmlRecord(r *rand.Rand) string {
+	var sb strings.Builder
+	sb.WriteString("  <record>\n")
+	sb.WriteString(fmt.Sprintf("    <id>%d</id>\n", r.IntN(100000)))
+	sb.WriteString(fmt.Sprintf("    <name>%s</name>\n", randomWord(r)+" "+randomWord(r)))
+	sb.WriteString(fmt.Sprintf("    <email>%s@%s.com</email>\n", randomWord(r), randomWord(r)))
+	sb.WriteString(fmt.Sprintf("    <description>%s</description>\n", randomSentence(r)))
+	sb.WriteString("  </record>\n")
+	return sb.String()
 }
 
 // HtmlGenerator generates HTML files
@@ -111,25 +312,63 @@ func (g *HtmlGenerator) Extension() string {
 	return "html"
 }
 
-func (g *HtmlGenerator) Generate(sizeBytes int) ([]byte, error) {
-	var buf bytes.Buffer
-	buf.WriteString("<!DOCTYPE html>\n<html>\n<head>\n")
-	buf.WriteString("  <meta charset=\"UTF-8\">\n")
-	buf.WriteString(fmt.Sprintf("  <title>%s</title>\n", randomWord()))
-	buf.WriteString("</head>\n<body>\n")
+func (g *HtmlGenerator) Generate(r *rand.Rand, sizeBytes int) ([]byte, error) {
+	return generateBuffered(g, r, sizeBytes)
+}
+
+func (g *HtmlGenerator) GenerateTo(w io.Writer, r *rand.Rand, sizeBytes int64) (int64, error) {
+	sw := newSizedWriter(w, -1)
+	sw.WriteString(htmlHead(r))
+
+	for sw.Len() < sizeBytes-20 && sw.err == nil {
+		sw.WriteString(htmlSection(r))
+	}
 
-	for buf.Len() < sizeBytes-20 {
-		buf.WriteString(fmt.Sprintf("  <h2>%s</h2>\n", randomSentence()))
-		buf.WriteString(fmt.Sprintf("  <p>%s</p>\n", randomParagraph()))
-		buf.WriteString("  <ul>\n")
-		for i := 0; i < 3+rand.IntN(5); i++ {
-			buf.WriteString(fmt.Sprintf("    <li>%s</li>\n", randomSentence()))
+	sw.WriteString("</body>\n</html>")
+	return sw.Len(), sw.err
+}
+
+// GenerateExactTo writes whole sections only and fills the gap before the
+// closing tags with whitespace
+func (g *HtmlGenerator) GenerateExactTo(w io.Writer, r *rand.Rand, sizeBytes int64) (int64, error) {
+	const closer = "</body>\n</html>"
+	sw := newSizedWriter(w, -1)
+	sw.WriteString(htmlHead(r))
+
+	for sw.err == nil {
+		section := htmlSection(r)
+		if sw.Len()+int64(len(section)+len(closer)) > sizeBytes {
+			break
 		}
-		buf.WriteString("  </ul>\n")
+		sw.WriteString(section)
 	}
 
-	buf.WriteString("</body>\n</html>")
-	return buf.Bytes(), nil
+	writeRepeated(sw, ' ', sizeBytes-sw.Len()-int64(len(closer)))
+	sw.WriteString(closer)
+	return sw.Len(), sw.err
+}
+
+// htmlHead generates the document head and opening body tag
+func htmlHead(r *rand.Rand) string {
+	var sb strings.Builder
+	sb.WriteString("<!DOCTYPE html>\n<html>\n<head>\n")
+	sb.WriteString("  <meta charset=\"UTF-8\">\n")
+	sb.WriteString(fmt.Sprintf("  <title>%s</title>\n", randomWord(r)))
+	sb.WriteString("</head>\n<body>\n")
+	return sb.String()
+}
+
+// htmlSection generates a heading, paragraph and list
+func htmlSection(r *rand.Rand) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("  <h2>%s</h2>\n", randomSentence(r)))
+	sb.WriteString(fmt.Sprintf("  <p>%s</p>\n", randomParagraph(r)))
+	sb.WriteString("  <ul>\n")
+	for i := 0; i < 3+r.IntN(5); i++ {
+		sb.WriteString(fmt.Sprintf("    <li>%s</li>\n", randomSentence(r)))
+	}
+	sb.WriteString("  </ul>\n")
+	return sb.String()
 }
 
 // MarkdownGenerator generates Markdown files
@@ -139,48 +378,127 @@ func (g *MarkdownGenerator) Extension() string {
 	return "md"
 }
 
-func (g *MarkdownGenerator) Generate(sizeBytes int) ([]byte, error) {
-	var buf bytes.Buffer
-	buf.WriteString(fmt.Sprintf("# %s\n\n", strings.Title(randomWord()+" "+randomWord())))
+func (g *MarkdownGenerator) Generate(r *rand.Rand, sizeBytes int) ([]byte, error) {
+	return generateBuffered(g, r, sizeBytes)
+}
+
+// GenerateTo writes whole sections only, as many as fit in sizeBytes, so
+// no code fence is left open
+func (g *MarkdownGenerator) GenerateTo(w io.Writer, r *rand.Rand, sizeBytes int64) (int64, error) {
+	sw := newSizedWriter(w, -1)
+	sw.WriteString(markdownTitle(r))
 
-	for buf.Len() < sizeBytes {
-		buf.WriteString(fmt.Sprintf("## %s\n\n", randomSentence()))
-		buf.WriteString(randomParagraph() + "\n\n")
+	for sw.err == nil {
+		section := markdownSection(r)
+		if sw.Len()+int64(len(section)) > sizeBytes {
+			break
+		}
+		sw.WriteString(section)
+	}
+
+	return sw.Len(), sw.err
+}
 
-		// Add a list
-		buf.WriteString("### Key Points\n\n")
-		for i := 0; i < 3+rand.IntN(4); i++ {
-			buf.WriteString(fmt.Sprintf("- %s\n", randomSentence()))
+// GenerateExactTo writes whole sections only, so no code fence is left
+// open, and ends with a plain paragraph sized to fill the remaining bytes
+func (g *MarkdownGenerator) GenerateExactTo(w io.Writer, r *rand.Rand, sizeBytes int64) (int64, error) {
+	sw := newSizedWriter(w, -1)
+	sw.WriteString(markdownTitle(r))
+
+	for sw.err == nil {
+		section := markdownSection(r)
+		if sw.Len()+int64(len(section)) > sizeBytes {
+			break
 		}
-		buf.WriteString("\n")
+		sw.WriteString(section)
+	}
 
-		// Add a code block
-		buf.WriteString("```\n")
-		buf.WriteString(randomSentence() + "\n")
-		buf.WriteString("```\n\n")
+	if remaining := int(sizeBytes - sw.Len()); remaining > 0 {
+		sw.WriteString(randomText(r, remaining-1) + "\n")
 	}
+	return sw.Len(), sw.err
+}
 
-	return buf.Bytes()[:sizeBytes], nil
+// markdownTitle generates the top level heading
+func markdownTitle(r *rand.Rand) string {
+	return fmt.Sprintf("# %s\n\n", strings.Title(randomWord(r)+" "+randomWord(r)))
+}
+
+// markdownSection generates a section with a paragraph, list and code block
+func markdownSection(r *rand.Rand) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("## %s\n\n", randomSentence(r)))
+	sb.WriteString(randomParagraph(r) + "\n\n")
+
+	// Add a list
+	sb.WriteString("### Key Points\n\n")
+	for i := 0; i < 3+r.IntN(4); i++ {
+		sb.WriteString(fmt.Sprintf("- %s\n", randomSentence(r)))
+	}
+	sb.WriteString("\n")
+
+	// Add a code block
+	sb.WriteString("```\n")
+	sb.WriteString(randomSentence(r) + "\n")
+	sb.WriteString("```\n\n")
+	return sb.String()
 }
 
 // LogGenerator generates log files
 type LogGenerator struct{}
 
+var logLevels = []string{"DEBUG", "INFO", "WARN", "ERROR"}
+
 func (g *LogGenerator) Extension() string {
 	return "log"
 }
 
-func (g *LogGenerator) Generate(sizeBytes int) ([]byte, error) {
-	levels := []string{"DEBUG", "INFO", "WARN", "ERROR"}
-	var buf bytes.Buffer
+func (g *LogGenerator) Generate(r *rand.Rand, sizeBytes int) ([]byte, error) {
+	return generateBuffered(g, r, sizeBytes)
+}
+
+// GenerateTo writes whole lines only, as many as fit in sizeBytes
+func (g *LogGenerator) GenerateTo(w io.Writer, r *rand.Rand, sizeBytes int64) (int64, error) {
+	sw := newSizedWriter(w, -1)
 
 	timestamp := 1704067200 // 2024-01-01 00:00:00
-	for buf.Len() < sizeBytes {
-		level := levels[rand.IntN(len(levels))]
-		ts := timestamp + rand.IntN(86400)
-		buf.WriteString(fmt.Sprintf("[%d] [%s] %s: %s\n", ts, level, randomWord(), randomSentence()))
-		timestamp += rand.IntN(60)
+	for sw.err == nil {
+		line := logPrefix(r, timestamp) + randomSentence(r) + "\n"
+		timestamp += r.IntN(60)
+		if sw.Len()+int64(len(line)) > sizeBytes {
+			break
+		}
+		sw.WriteString(line)
 	}
 
-	return buf.Bytes()[:sizeBytes], nil
+	return sw.Len(), sw.err
+}
+
+// GenerateExactTo writes whole lines only, padding the message of the last
+// line so the file ends exactly at sizeBytes
+func (g *LogGenerator) GenerateExactTo(w io.Writer, r *rand.Rand, sizeBytes int64) (int64, error) {
+	sw := newSizedWriter(w, -1)
+
+	timestamp := 1704067200 // 2024-01-01 00:00:00
+	for sw.err == nil {
+		line := logPrefix(r, timestamp) + randomSentence(r) + "\n"
+		timestamp += r.IntN(60)
+		if sizeBytes-sw.Len()-int64(len(line)) < exactSlack {
+			break
+		}
+		sw.WriteString(line)
+	}
+
+	if remaining := int(sizeBytes - sw.Len()); remaining > 0 {
+		prefix := logPrefix(r, timestamp)
+		sw.WriteString(prefix + randomText(r, remaining-len(prefix)-1) + "\n")
+	}
+	return sw.Len(), sw.err
+}
+
+// logPrefix generates the timestamp, level and source of a log line
+func logPrefix(r *rand.Rand, timestamp int) string {
+	level := logLevels[r.IntN(len(logLevels))]
+	ts := timestamp + r.IntN(86400)
+	return fmt.Sprintf("[%d] [%s] %s: ", ts, level, randomWord(r))
 }
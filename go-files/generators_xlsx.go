@@ -0,0 +1,910 @@
+package main
+
+import (
+	"archive/zip"
+	"compress/flate"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// XlsxGenerator generates valid XLSX files (Office Open XML) that look like
+// the workbooks finance users send: a summary, an employee list and a
+// sales ledger, with shared strings, date, currency and percent formats,
+// SUM, VLOOKUP and cross-sheet formulas with cached values, merged title
+// cells and frozen header rows. The ledger is streamed, and continues on
+// further sheets when one is full. Optionally, the employees and sales are
+// Excel tables, and the summary has charts.
+type XlsxGenerator struct {
+	OfficeProperties
+	Tables bool     `json:"tables"` // a table over the employees and over each sales sheet
+	Charts []string `json:"charts"` // charts on the summary sheet, see xlsxChartKinds
+
+	props     officeProperties // the properties of the last generated workbook
+	sheets    []string         // its sheet names
+	employees int              // its employees
+	rows      int              // its sales rows
+	netSales  string           // the cached total of its sales
+	tables    int              // its tables
+}
+
+// Workbook layout
+const (
+	xlsxMaxEmployees  = 50
+	xlsxEmployeeBytes = 4096                       // workbook size per employee
+	xlsxFirstRow      = 3                          // first data row of a sheet, below the title and header rows
+	xlsxMaxRows       = 1 << 20                    // rows of a worksheet
+	xlsxSheetRows     = xlsxMaxRows - xlsxFirstRow // sales rows of a sheet, leaving a row for the totals
+	xlsxSalesSheet    = 3                          // number of the first sales sheet's part
+	xlsxMainNS        = `xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"`
+)
+
+// The compressed size of a package is measured by flushing the compressor
+// at most every xlsxFlushBytes of sales rows. Until the first measurement,
+// rows are assumed to compress by xlsxCompression.
+const (
+	xlsxFlushBytes  = 16 << 20
+	xlsxCompression = 4.0
+)
+
+// Cell styles, the indexes of cellXfs in xl/styles.xml
+const (
+	xlsxStyleDefault = iota
+	xlsxStyleTitle
+	xlsxStyleHeader
+	xlsxStyleDate
+	xlsxStyleCurrency
+	xlsxStylePercent
+	xlsxStyleTotal // bold currency with a line above
+)
+
+// xlsxEpoch is day 0 of Excel's date serial numbers
+var xlsxEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+func (g *XlsxGenerator) Extension() string {
+	return "xlsx"
+}
+
+// Metadata reports the workbook properties, the sheets, the number of
+// employees and sales rows, the net sales total, and the tables and
+// charts
+func (g *XlsxGenerator) Metadata() map[string]any {
+	metadata := map[string]any{"sheets": g.sheets, "employees": g.employees, "rows": g.rows, "net_sales": g.netSales}
+	g.props.addMetadata(metadata)
+	if g.Tables {
+		metadata["tables"] = g.tables
+	}
+	if len(g.Charts) > 0 {
+		metadata["charts"] = g.Charts
+	}
+	return metadata
+}
+
+// Validate checks the configured charts and document properties
+func (g *XlsxGenerator) Validate() error {
+	for _, kind := range g.Charts {
+		if !slices.Contains(xlsxChartKinds, kind) {
+			return fmt.Errorf("unknown xlsx chart %q (supported: %s)", kind, strings.Join(xlsxChartKinds, ", "))
+		}
+	}
+	return g.OfficeProperties.Validate()
+}
+
+func (g *XlsxGenerator) Generate(r *rand.Rand, sizeBytes int) ([]byte, error) {
+	return generateBuffered(g, r, sizeBytes)
+}
+
+// GenerateTo compresses the sales rows until the package reaches the
+// target size. How many more rows fit is estimated from how well the rows
+// so far compressed, and measured again after writing them.
+func (g *XlsxGenerator) GenerateTo(w io.Writer, r *rand.Rand, sizeBytes int64) (int64, error) {
+	wb := g.prepare(r, sizeBytes)
+	overhead, err := g.overhead(wb, zip.Deflate, 1)
+	if err != nil {
+		return 0, err
+	}
+	return g.writePackage(w, zip.Deflate, wb, func(sales *xlsxSales) {
+		target := sizeBytes - overhead
+		start, transitions := sales.compressed(), int64(0)
+		for sales.rows.err == nil {
+			written := sales.compressed() - start - transitions
+			ratio := xlsxCompression
+			if sales.count > 0 && written > 0 {
+				ratio = float64(sales.rows.Len()) / float64(written)
+			}
+			more := int64(float64(target-written) * ratio)
+			if more <= 0 || (sales.count > 0 && more < sales.rows.Len()/int64(sales.count)) {
+				break
+			}
+			end := sales.rows.Len() + min(more, xlsxFlushBytes)
+			for sales.rows.Len() < end && sales.rows.err == nil {
+				sale := sales.next()
+				if !sale.newSheet {
+					sales.write(sale)
+					continue
+				}
+				// Every sheet adds its own parts to the package, so the
+				// switch to a new sheet is measured apart from the rows
+				if overhead, err = g.overhead(wb, zip.Deflate, len(sales.sheets)+1); err != nil {
+					sales.rows.err = err
+					return
+				}
+				target = sizeBytes - overhead
+				before := sales.compressed()
+				sales.write(sale)
+				transitions += sales.compressed() - before
+				break
+			}
+		}
+	})
+}
+
+// GenerateExactTo stores the worksheets uncompressed, so every byte of
+// sales rows adds exactly one byte to the package. The package overhead is
+// measured first by writing it without sales rows, and again for every
+// further sales sheet. The totals rows and the summary grow with the
+// digits of the row counts and net totals, which the sales rows leave room
+// for.
+func (g *XlsxGenerator) GenerateExactTo(w io.Writer, r *rand.Rand, sizeBytes int64) (int64, error) {
+	wb := g.prepare(r, sizeBytes)
+	overhead, err := g.overhead(wb, zip.Store, 1)
+	if err != nil {
+		return 0, err
+	}
+	return g.writePackage(w, zip.Store, wb, func(sales *xlsxSales) {
+		budget := sizeBytes - overhead
+		growth := xlsxTotalsGrowth(wb.totalsSize)
+		for sales.rows.err == nil {
+			sale := sales.next()
+			fits := budget
+			if sale.newSheet {
+				more, err := g.overhead(wb, zip.Store, len(sales.sheets)+1)
+				if err != nil {
+					sales.rows.err = err
+					return
+				}
+				fits = sizeBytes - more
+			}
+			if sales.rows.Len()+int64(len(sale.xml))+growth(sales.totalsWith(sale)) > fits {
+				break
+			}
+			budget = fits
+			sales.write(sale)
+		}
+		writeRepeated(sales.rows, ' ', budget-sales.rows.Len()-growth(sales.sheets))
+	})
+}
+
+// overhead returns the size of the package with the given number of empty
+// sales sheets
+func (g *XlsxGenerator) overhead(wb *xlsxWorkbook, method uint16, sheets int) (int64, error) {
+	return g.writePackage(io.Discard, method, wb, func(sales *xlsxSales) {
+		for len(sales.sheets) < sheets {
+			sales.newSheet()
+		}
+	})
+}
+
+// prepare picks the workbook properties, departments, regions and
+// employees, which are shared by all passes of GenerateTo and
+// GenerateExactTo. Larger workbooks have more employees.
+func (g *XlsxGenerator) prepare(r *rand.Rand, sizeBytes int64) *xlsxWorkbook {
+	g.props = g.resolve(r, "")
+	wb := &xlsxWorkbook{r: r, props: &g.props, index: map[string]int{}, tables: g.Tables, charts: g.Charts}
+	departments := xlsxPool(r, 3+r.IntN(4))
+	wb.departmentNames = departments
+	wb.regions = xlsxPool(r, 4+r.IntN(5))
+	for _, region := range wb.regions {
+		wb.str(region) // shared before any sales row refers to them
+	}
+
+	wb.employees = make([]xlsxEmployee, max(3, min(xlsxMaxEmployees, int(sizeBytes/xlsxEmployeeBytes))))
+	for i := range wb.employees {
+		wb.employees[i] = xlsxEmployee{
+			name:       csvColumns["name"](r, i+1),
+			email:      csvColumns["email"](r, i+1),
+			department: departments[r.IntN(len(departments))],
+			start:      randomDate(r),
+			salary:     30000 + r.IntN(70000),
+			bonus:      r.IntN(16),
+		}
+		wb.employees[i].cached = xmlEscape(wb.employees[i].name)
+		wb.payroll += wb.employees[i].salary
+	}
+	wb.lookup = fmt.Sprintf(",Employees!$A$%d:$B$%d,2,FALSE)</f><v>", xlsxFirstRow, xlsxFirstRow+len(wb.employees)-1)
+	g.employees = len(wb.employees)
+	return wb
+}
+
+// writePackage writes the XLSX package. fill writes the sales rows, which
+// are compressed with the given method like the summary sheet, whose
+// cached values depend on the sales. The parts that list the sheets are
+// written last, once the number of sales sheets is known.
+func (g *XlsxGenerator) writePackage(w io.Writer, method uint16, wb *xlsxWorkbook, fill func(sales *xlsxSales)) (int64, error) {
+	sw := newSizedWriter(w, -1)
+	zipWriter := zip.NewWriter(sw)
+
+	// Deflated parts go through a compressor that can be flushed, to
+	// measure the package while the sales rows are written
+	var compressor *flate.Writer
+	zipWriter.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
+		var err error
+		compressor, err = flate.NewWriter(out, flate.DefaultCompression)
+		return compressor, err
+	})
+
+	// _rels/.rels
+	rels := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` + officePropsRelationships + `
+</Relationships>`
+	err := writeZipFile(zipWriter, "_rels/.rels", rels)
+	if err == nil {
+		err = writeZipFile(zipWriter, "xl/styles.xml", xlsxStyles)
+	}
+	if err == nil {
+		err = writeZipFile(zipWriter, "xl/worksheets/sheet2.xml", wb.employeeSheet())
+	}
+	if err != nil {
+		return sw.Len(), err
+	}
+
+	// xl/worksheets/sheet3.xml and on - sales rows, streamed straight into
+	// the zip entries
+	sales := &xlsxSales{wb: wb, zip: zipWriter, method: method}
+	sales.rows = newSizedWriter(sales, -1)
+	sales.compressed = func() int64 {
+		compressor.Flush()
+		zipWriter.Flush()
+		return sw.Len()
+	}
+	sales.newSheet()
+	fill(sales)
+	sales.closeSheet()
+	if sales.rows.err != nil {
+		return sw.Len(), sales.rows.err
+	}
+	grand := xlsxGrandTotal(sales.sheets)
+	g.sheets = xlsxSheetNames(len(sales.sheets))
+	g.rows, g.netSales = grand.rows, xlsxCents(grand.net)
+
+	// xl/worksheets/sheet1.xml - the summary, once the sales totals are known
+	summaryWriter, err := zipWriter.CreateHeader(&zip.FileHeader{Name: "xl/worksheets/sheet1.xml", Method: method})
+	if err != nil {
+		return sw.Len(), err
+	}
+	if _, err := io.WriteString(summaryWriter, wb.summarySheet(sales.sheets)); err != nil {
+		return sw.Len(), err
+	}
+
+	// xl/tables/table1.xml and on - the employee table, then a table per
+	// sales sheet, whose ranges grow with the sales like their totals rows
+	var parts []string // the content types of the parts below
+	g.tables = 0
+	if wb.tables {
+		err := writeZipFile(zipWriter, "xl/worksheets/_rels/sheet2.xml.rels", xlsxRels(xlsxRelTable, "../tables/table1.xml"))
+		if err == nil {
+			err = writeZipFile(zipWriter, "xl/tables/table1.xml", wb.employeeTable())
+		}
+		for i, t := range sales.sheets {
+			if err == nil {
+				err = writeZipFile(zipWriter, fmt.Sprintf("xl/worksheets/_rels/sheet%d.xml.rels", xlsxSalesSheet+i),
+					xlsxRels(xlsxRelTable, fmt.Sprintf("../tables/table%d.xml", 2+i)))
+			}
+			var tableWriter io.Writer
+			if err == nil {
+				tableWriter, err = zipWriter.CreateHeader(&zip.FileHeader{Name: fmt.Sprintf("xl/tables/table%d.xml", 2+i), Method: method})
+			}
+			if err == nil {
+				_, err = io.WriteString(tableWriter, salesTable(i, t))
+			}
+		}
+		if err != nil {
+			return sw.Len(), err
+		}
+		g.tables = 1 + len(sales.sheets)
+		for i := 1; i <= g.tables; i++ {
+			parts = append(parts, fmt.Sprintf(`
+  <Override PartName="/xl/tables/table%d.xml" ContentType="%s"/>`, i, xlsxTableContent))
+		}
+	}
+
+	// xl/drawings/drawing1.xml and xl/charts/chart1.xml and on - the
+	// summary's charts
+	if len(wb.charts) > 0 {
+		charts := make([]string, len(wb.charts))
+		for i, kind := range wb.charts {
+			charts[i] = fmt.Sprintf("../charts/chart%d.xml", i+1)
+			if err := writeZipFile(zipWriter, fmt.Sprintf("xl/charts/chart%d.xml", i+1), wb.chart(kind)); err != nil {
+				return sw.Len(), err
+			}
+			parts = append(parts, fmt.Sprintf(`
+  <Override PartName="/xl/charts/chart%d.xml" ContentType="%s"/>`, i+1, xlsxChartContent))
+		}
+		err := writeZipFile(zipWriter, "xl/worksheets/_rels/sheet1.xml.rels", xlsxRels(xlsxRelDrawing, "../drawings/drawing1.xml"))
+		if err == nil {
+			err = writeZipFile(zipWriter, "xl/drawings/drawing1.xml", xlsxDrawing(wb.charts))
+		}
+		if err == nil {
+			err = writeZipFile(zipWriter, "xl/drawings/_rels/drawing1.xml.rels", xlsxRels(xlsxRelChart, charts...))
+		}
+		if err != nil {
+			return sw.Len(), err
+		}
+		parts = append(parts, `
+  <Override PartName="/xl/drawings/drawing1.xml" ContentType="`+xlsxDrawingContent+`"/>`)
+	}
+
+	// xl/sharedStrings.xml, after the sheets have added their labels
+	if err := writeZipFile(zipWriter, "xl/sharedStrings.xml", wb.sharedStrings()); err != nil {
+		return sw.Len(), err
+	}
+
+	// [Content_Types].xml
+	var contentTypes strings.Builder
+	contentTypes.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+  <Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+  <Default Extension="xml" ContentType="application/xml"/>
+  <Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+  <Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+  <Override PartName="/xl/sharedStrings.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sharedStrings+xml"/>`)
+	for i := range g.sheets {
+		fmt.Fprintf(&contentTypes, `
+  <Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+	}
+	contentTypes.WriteString(strings.Join(parts, "") + officePropsContentTypes + "\n</Types>")
+	if err := writeZipFile(zipWriter, "[Content_Types].xml", contentTypes.String()); err != nil {
+		return sw.Len(), err
+	}
+
+	// xl/workbook.xml and xl/_rels/workbook.xml.rels: the sheets are rId1
+	// and on, followed by the styles and shared strings
+	var workbook, wbRels strings.Builder
+	workbook.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook ` + xlsxMainNS + ` xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+  <sheets>`)
+	wbRels.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i, name := range g.sheets {
+		fmt.Fprintf(&workbook, `
+    <sheet name="%s" sheetId="%d" r:id="rId%d"/>`, name, i+1, i+1)
+		fmt.Fprintf(&wbRels, `
+  <Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+	workbook.WriteString(`
+  </sheets>
+  <calcPr calcId="191029"/>
+</workbook>`)
+	fmt.Fprintf(&wbRels, `
+  <Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+  <Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/sharedStrings" Target="sharedStrings.xml"/>
+</Relationships>`, len(g.sheets)+1, len(g.sheets)+2)
+	err = writeZipFile(zipWriter, "xl/workbook.xml", workbook.String())
+	if err == nil {
+		err = writeZipFile(zipWriter, "xl/_rels/workbook.xml.rels", wbRels.String())
+	}
+	if err == nil {
+		err = writeDocProps(zipWriter, &g.props, "Microsoft Excel", fmt.Sprintf(`
+  <HeadingPairs><vt:vector size="2" baseType="variant"><vt:variant><vt:lpstr>Worksheets</vt:lpstr></vt:variant><vt:variant><vt:i4>%d</vt:i4></vt:variant></vt:vector></HeadingPairs>
+  <TitlesOfParts><vt:vector size="%d" baseType="lpstr"><vt:lpstr>%s</vt:lpstr></vt:vector></TitlesOfParts>`,
+			len(g.sheets), len(g.sheets), strings.Join(g.sheets, "</vt:lpstr><vt:lpstr>")))
+	}
+	if err != nil {
+		return sw.Len(), err
+	}
+
+	err = zipWriter.Close()
+	return sw.Len(), err
+}
+
+// xlsxWorkbook is what the sheets of a workbook share: the shared strings
+// table and the employees that sales refer to
+type xlsxWorkbook struct {
+	r       *rand.Rand
+	props   *officeProperties
+	strings []string // shared strings, in order of first use
+	index   map[string]int
+
+	employees       []xlsxEmployee
+	payroll         int
+	departmentNames []string
+	regions         []string
+	lookup          string // the VLOOKUP of sales rows after the row number, up to the cached value
+
+	tables bool
+	charts []string
+}
+
+type xlsxEmployee struct {
+	name, email, department string
+	cached                  string // the escaped name, as cached by VLOOKUPs
+	start                   time.Time
+	salary                  int
+	bonus                   int // percent
+}
+
+// str returns the index of s in the shared strings table, adding it if
+// needed
+func (wb *xlsxWorkbook) str(s string) int {
+	i, ok := wb.index[s]
+	if !ok {
+		i = len(wb.strings)
+		wb.index[s] = i
+		wb.strings = append(wb.strings, s)
+	}
+	return i
+}
+
+// shared is a cell with a shared string
+func (wb *xlsxWorkbook) shared(col, row int, s string, style int) string {
+	return fmt.Sprintf(`<c r="%s"%s t="s"><v>%d</v></c>`, xlsxRef(col, row), xlsxStyle(style), wb.str(s))
+}
+
+// titleRow is row 1 with the sheet's title, merged across its columns
+func (wb *xlsxWorkbook) titleRow(title string) string {
+	return fmt.Sprintf(`
+    <row r="1" ht="21" customHeight="1">%s</row>`, wb.shared(0, 1, title, xlsxStyleTitle))
+}
+
+// headerRow is row 2 with the column labels
+func (wb *xlsxWorkbook) headerRow(labels ...string) string {
+	cells := make([]string, len(labels))
+	for i, label := range labels {
+		cells[i] = wb.shared(i, 2, label, xlsxStyleHeader)
+	}
+	return xlsxRow(2, cells...)
+}
+
+// employeeSheet is the Employees sheet, with a total payroll row
+func (wb *xlsxWorkbook) employeeSheet() string {
+	var b strings.Builder
+	b.WriteString(xlsxSheetStart(true, []int{8, 24, 32, 16, 12, 12, 10}))
+	b.WriteString(wb.titleRow("Employees"))
+	b.WriteString(wb.headerRow(xlsxEmployeeColumns...))
+	for i, e := range wb.employees {
+		row := xlsxFirstRow + i
+		b.WriteString(xlsxRow(row,
+			xlsxNumber(0, row, strconv.Itoa(i+1), xlsxStyleDefault),
+			wb.shared(1, row, e.name, xlsxStyleDefault),
+			wb.shared(2, row, e.email, xlsxStyleDefault),
+			wb.shared(3, row, e.department, xlsxStyleDefault),
+			xlsxNumber(4, row, strconv.Itoa(xlsxSerial(e.start)), xlsxStyleDate),
+			xlsxNumber(5, row, strconv.Itoa(e.salary), xlsxStyleCurrency),
+			xlsxNumber(6, row, xlsxPercent(e.bonus), xlsxStylePercent)))
+	}
+	total := xlsxFirstRow + len(wb.employees)
+	b.WriteString(xlsxRow(total,
+		wb.shared(0, total, "Total", xlsxStyleHeader),
+		xlsxFormula(5, total, fmt.Sprintf("SUM(F%d:F%d)", xlsxFirstRow, total-1), strconv.Itoa(wb.payroll), xlsxStyleTotal)))
+	b.WriteString(xlsxSheetEnd("A1:G1", wb.tableParts()))
+	return b.String()
+}
+
+// tableParts links an employee or sales sheet to its table, if any
+func (wb *xlsxWorkbook) tableParts() string {
+	if !wb.tables {
+		return ""
+	}
+	return xlsxTablePart
+}
+
+// salesStart is a sales sheet up to its first sales row
+func (wb *xlsxWorkbook) salesStart() string {
+	return xlsxSheetStart(true, []int{12, 12, 24, 16, 12, 10, 12}) +
+		wb.titleRow("Sales") +
+		wb.headerRow(xlsxSalesColumns...)
+}
+
+// salesTotals is the end of a sales sheet: the net total row. The sum
+// starts at the header row, so its range is never empty, and SUM skips the
+// label.
+func (wb *xlsxWorkbook) salesTotals(t xlsxTotals) string {
+	total := xlsxFirstRow + t.rows
+	return xlsxRow(total,
+		wb.shared(0, total, "Total", xlsxStyleHeader),
+		xlsxFormula(6, total, fmt.Sprintf("SUM(G%d:G%d)", xlsxFirstRow-1, total-1), xlsxCents(t.net), xlsxStyleTotal)) +
+		xlsxSheetEnd("A1:G1", wb.tableParts())
+}
+
+// summarySheet is the Summary sheet, whose formulas refer to the other
+// sheets. With charts, it has a department block for them to plot and the
+// drawing that holds them.
+func (wb *xlsxWorkbook) summarySheet(sheets []xlsxTotals) string {
+	employees := len(wb.employees)
+	lastEmployee := xlsxFirstRow + employees - 1
+	names := xlsxSheetNames(len(sheets))[xlsxSalesSheet-1:]
+	counts := make([]string, len(sheets))
+	nets := make([]string, len(sheets))
+	for i, t := range sheets {
+		counts[i] = fmt.Sprintf("COUNT(%sA%d:A%d)", xlsxSheetRef(names[i]), xlsxFirstRow-1, xlsxFirstRow+t.rows-1)
+		nets[i] = fmt.Sprintf("%sG%d", xlsxSheetRef(names[i]), xlsxFirstRow+t.rows)
+	}
+	grand := xlsxGrandTotal(sheets)
+
+	var b strings.Builder
+	widths, drawing := []int{24, 18}, ""
+	if len(wb.charts) > 0 {
+		widths, drawing = []int{24, 18, 14}, xlsxDrawingPart
+	}
+	b.WriteString(xlsxSheetStart(false, widths))
+	b.WriteString(wb.titleRow(wb.props.title))
+	b.WriteString(wb.headerRow("Metric", "Value"))
+	row := xlsxFirstRow
+	metric := func(label, value string) {
+		b.WriteString(xlsxRow(row, wb.shared(0, row, label, xlsxStyleDefault), value))
+		row++
+	}
+	metric("Employees", xlsxFormula(1, row, fmt.Sprintf("COUNTA(Employees!A%d:A%d)", xlsxFirstRow, lastEmployee),
+		strconv.Itoa(employees), xlsxStyleDefault))
+	metric("Total payroll", xlsxFormula(1, row, fmt.Sprintf("Employees!F%d", lastEmployee+1),
+		strconv.Itoa(wb.payroll), xlsxStyleCurrency))
+	metric("Average salary", xlsxFormula(1, row, fmt.Sprintf("AVERAGE(Employees!F%d:F%d)", xlsxFirstRow, lastEmployee),
+		strconv.FormatFloat(float64(wb.payroll)/float64(employees), 'f', -1, 64), xlsxStyleCurrency))
+	metric("Sales", xlsxFormula(1, row, strings.Join(counts, "+"), strconv.Itoa(grand.rows), xlsxStyleDefault))
+	metric("Net sales", xlsxFormula(1, row, strings.Join(nets, "+"), xlsxCents(grand.net), xlsxStyleCurrency))
+	metric("Report date", xlsxNumber(1, row, strconv.Itoa(xlsxSerial(wb.props.modified)), xlsxStyleDate))
+	metric("Prepared by", wb.shared(1, row, wb.props.author, xlsxStyleDefault))
+	if len(wb.charts) > 0 {
+		b.WriteString(wb.departmentRows())
+	}
+	b.WriteString(xlsxSheetEnd("A1:B1", drawing))
+	return b.String()
+}
+
+// totalsSize returns the size of the parts that depend on the sales
+// totals
+func (wb *xlsxWorkbook) totalsSize(sheets []xlsxTotals) int64 {
+	size := len(wb.summarySheet(sheets))
+	for i, t := range sheets {
+		size += len(wb.salesTotals(t))
+		if wb.tables {
+			size += len(salesTable(i, t))
+		}
+	}
+	return int64(size)
+}
+
+// xlsxTotalsGrowth returns a function that returns how much the parts
+// measured by totalsSize, such as the totals rows and the summary, grow
+// from those of empty sales sheets. Full sheets no longer change, and
+// otherwise only the lengths of the numbers matter, so the totals are only
+// rendered again when one of them changes.
+func xlsxTotalsGrowth(totalsSize func(sheets []xlsxTotals) int64) func(sheets []xlsxTotals) int64 {
+	var key [6]int
+	size := int64(-1)
+	return func(sheets []xlsxTotals) int64 {
+		last, grand := sheets[len(sheets)-1], xlsxGrandTotal(sheets)
+		k := [6]int{len(sheets), xlsxDigits(int64(xlsxFirstRow + last.rows - 1)), xlsxDigits(int64(xlsxFirstRow + last.rows)),
+			xlsxDigits(last.net), xlsxDigits(int64(grand.rows)), xlsxDigits(grand.net)}
+		if k != key || size < 0 {
+			key, size = k, totalsSize(sheets)-totalsSize(make([]xlsxTotals, len(sheets)))
+		}
+		return size
+	}
+}
+
+// sharedStrings is xl/sharedStrings.xml
+func (wb *xlsxWorkbook) sharedStrings() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<sst %s uniqueCount="%d">`, xlsxMainNS, len(wb.strings))
+	for _, s := range wb.strings {
+		fmt.Fprintf(&b, "\n  <si><t>%s</t></si>", xmlEscape(s))
+	}
+	b.WriteString("\n</sst>")
+	return b.String()
+}
+
+// xlsxSales streams the sales ledger into sheet parts, starting a new
+// sheet when one is full, and keeps the totals of each sheet
+type xlsxSales struct {
+	wb     *xlsxWorkbook
+	zip    *zip.Writer
+	method uint16
+	entry  io.Writer    // the current sheet's zip entry
+	sheets []xlsxTotals // the last one is the current sheet
+	count  int          // sales rows over all sheets
+
+	rows       *sizedWriter // writes to the current sheet and counts the rows' bytes over all sheets
+	compressed func() int64 // flushes the compressor and returns the size of the package so far
+	buf        []byte
+	scratch    []xlsxTotals
+}
+
+// xlsxTotals are the number of sales rows of a sheet and their net total
+// in cents
+type xlsxTotals struct {
+	rows int
+	net  int64
+}
+
+// xlsxSale is a sales row and its net amount in cents. newSheet is set
+// when the current sheet is full and the row is the first of a new one.
+type xlsxSale struct {
+	xml      []byte
+	net      int64
+	newSheet bool
+}
+
+// Write writes to the current sheet
+func (s *xlsxSales) Write(p []byte) (int, error) {
+	return s.entry.Write(p)
+}
+
+// newSheet closes the current sheet, if any, and starts the next one
+func (s *xlsxSales) newSheet() {
+	if len(s.sheets) > 0 {
+		s.closeSheet()
+	}
+	entry, err := s.zip.CreateHeader(&zip.FileHeader{
+		Name:   fmt.Sprintf("xl/worksheets/sheet%d.xml", xlsxSalesSheet+len(s.sheets)),
+		Method: s.method,
+	})
+	if err != nil {
+		s.rows.err = err
+		return
+	}
+	s.entry = entry
+	if _, err := io.WriteString(entry, s.wb.salesStart()); err != nil {
+		s.rows.err = err
+	}
+	s.sheets = append(s.sheets, xlsxTotals{})
+}
+
+// closeSheet writes the totals row of the current sheet
+func (s *xlsxSales) closeSheet() {
+	if s.rows.err == nil {
+		_, s.rows.err = io.WriteString(s.entry, s.wb.salesTotals(s.sheets[len(s.sheets)-1]))
+	}
+}
+
+// next generates the next sales row: a whole dollar amount sold by an
+// employee, looked up by ID, with a discount of up to 20%. The row is
+// built in a buffer that is reused by the next call.
+func (s *xlsxSales) next() xlsxSale {
+	wb, r := s.wb, s.wb.r
+	sale := xlsxSale{newSheet: s.sheets[len(s.sheets)-1].rows == xlsxSheetRows}
+	row := int64(xlsxFirstRow + s.sheets[len(s.sheets)-1].rows)
+	if sale.newSheet {
+		row = xlsxFirstRow
+	}
+	employee := r.IntN(len(wb.employees))
+	amount := 50 + r.IntN(4951)
+	discount := 5 * r.IntN(5)
+	sale.net = int64(amount * (100 - discount))
+
+	// cell appends the start tag of a cell in the row
+	cell := func(b []byte, col byte, style int, kind string) []byte {
+		b = append(b, `<c r="`...)
+		b = append(b, col)
+		b = strconv.AppendInt(b, row, 10)
+		b = append(b, '"')
+		if style != xlsxStyleDefault {
+			b = append(b, ` s="`...)
+			b = strconv.AppendInt(b, int64(style), 10)
+			b = append(b, '"')
+		}
+		if kind != "" {
+			b = append(b, ` t="`...)
+			b = append(b, kind...)
+			b = append(b, '"')
+		}
+		return append(b, '>')
+	}
+
+	b := append(s.buf[:0], "\n    <row r=\""...)
+	b = strconv.AppendInt(b, row, 10)
+	b = append(b, `">`...)
+	b = append(cell(b, 'A', xlsxStyleDate, ""), "<v>"...)
+	b = strconv.AppendInt(b, int64(xlsxSerial(randomDate(r))), 10)
+	b = append(cell(append(b, "</v></c>"...), 'B', xlsxStyleDefault, ""), "<v>"...)
+	b = strconv.AppendInt(b, int64(employee+1), 10)
+	b = append(cell(append(b, "</v></c>"...), 'C', xlsxStyleDefault, "str"), "<f>VLOOKUP(B"...)
+	b = strconv.AppendInt(b, row, 10)
+	b = append(b, wb.lookup...)
+	b = append(b, wb.employees[employee].cached...)
+	b = append(cell(append(b, "</v></c>"...), 'D', xlsxStyleDefault, "s"), "<v>"...)
+	b = strconv.AppendInt(b, int64(wb.str(wb.regions[r.IntN(len(wb.regions))])), 10)
+	b = append(cell(append(b, "</v></c>"...), 'E', xlsxStyleCurrency, ""), "<v>"...)
+	b = strconv.AppendInt(b, int64(amount), 10)
+	b = append(cell(append(b, "</v></c>"...), 'F', xlsxStylePercent, ""), "<v>"...)
+	b = strconv.AppendFloat(b, float64(discount)/100, 'f', -1, 64)
+	b = append(cell(append(b, "</v></c>"...), 'G', xlsxStyleCurrency, ""), "<f>E"...)
+	b = strconv.AppendInt(b, row, 10)
+	b = append(b, "*(1-F"...)
+	b = strconv.AppendInt(b, row, 10)
+	b = append(b, ")</f><v>"...)
+	b = xlsxAppendCents(b, sale.net)
+	b = append(b, "</v></c></row>"...)
+	s.buf = b
+	sale.xml = b
+	return sale
+}
+
+// write writes a row generated by next, in a new sheet if it is the first
+// of one
+func (s *xlsxSales) write(sale xlsxSale) {
+	if sale.newSheet {
+		s.newSheet()
+	}
+	s.rows.Write(sale.xml)
+	t := &s.sheets[len(s.sheets)-1]
+	t.rows++
+	t.net += sale.net
+	s.count++
+}
+
+// totalsWith returns the totals of the sheets as they would be after
+// writing sale
+func (s *xlsxSales) totalsWith(sale xlsxSale) []xlsxTotals {
+	s.scratch = append(s.scratch[:0], s.sheets...)
+	if sale.newSheet {
+		s.scratch = append(s.scratch, xlsxTotals{})
+	}
+	t := &s.scratch[len(s.scratch)-1]
+	t.rows++
+	t.net += sale.net
+	return s.scratch
+}
+
+func xlsxGrandTotal(sheets []xlsxTotals) xlsxTotals {
+	var grand xlsxTotals
+	for _, t := range sheets {
+		grand.rows += t.rows
+		grand.net += t.net
+	}
+	return grand
+}
+
+// xlsxSheetNames returns the names of the sheets of a workbook with the
+// given number of sales sheets; sheet i is stored as
+// xl/worksheets/sheet<i+1>.xml
+func xlsxSheetNames(sales int) []string {
+	names := []string{"Summary", "Employees", "Sales"}
+	for i := 2; i <= sales; i++ {
+		names = append(names, fmt.Sprintf("Sales %d", i))
+	}
+	return names
+}
+
+// xlsxSheetRef is the prefix that refers to a cell of another sheet
+func xlsxSheetRef(name string) string {
+	if strings.Contains(name, " ") {
+		return "'" + name + "'!"
+	}
+	return name + "!"
+}
+
+// xlsxSheetStart is a worksheet up to its first row, with the given column
+// widths and, if frozen, the title and header rows frozen
+func xlsxSheetStart(frozen bool, widths []int) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet ` + xlsxMainNS + ` xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+  <sheetViews><sheetView workbookViewId="0">`)
+	if frozen {
+		fmt.Fprintf(&b, `<pane ySplit="%d" topLeftCell="A%d" activePane="bottomLeft" state="frozen"/><selection pane="bottomLeft" activeCell="A%d" sqref="A%d"/>`,
+			xlsxFirstRow-1, xlsxFirstRow, xlsxFirstRow, xlsxFirstRow)
+	}
+	b.WriteString("</sheetView></sheetViews>\n  <sheetFormatPr defaultRowHeight=\"15\"/>\n  <cols>")
+	for i, width := range widths {
+		fmt.Fprintf(&b, `<col min="%d" max="%d" width="%d" customWidth="1"/>`, i+1, i+1, width)
+	}
+	b.WriteString("</cols>\n  <sheetData>")
+	return b.String()
+}
+
+// xlsxSheetEnd closes a worksheet whose title is merged across the cells
+// of merge. parts links the sheet to its drawing or table.
+func xlsxSheetEnd(merge, parts string) string {
+	return fmt.Sprintf(`
+  </sheetData>
+  <mergeCells count="1"><mergeCell ref="%s"/></mergeCells>
+  <pageMargins left="0.7" right="0.7" top="0.75" bottom="0.75" header="0.3" footer="0.3"/>%s
+</worksheet>`, merge, parts)
+}
+
+func xlsxRow(row int, cells ...string) string {
+	return fmt.Sprintf("\n    <row r=\"%d\">%s</row>", row, strings.Join(cells, ""))
+}
+
+// xlsxRef is the reference of a cell, for the first 26 columns
+func xlsxRef(col, row int) string {
+	return string(rune('A'+col)) + strconv.Itoa(row)
+}
+
+func xlsxStyle(style int) string {
+	if style == xlsxStyleDefault {
+		return ""
+	}
+	return fmt.Sprintf(` s="%d"`, style)
+}
+
+func xlsxNumber(col, row int, value string, style int) string {
+	return fmt.Sprintf(`<c r="%s"%s><v>%s</v></c>`, xlsxRef(col, row), xlsxStyle(style), value)
+}
+
+// xlsxFormula is a formula cell with its cached numeric value
+func xlsxFormula(col, row int, formula, value string, style int) string {
+	return fmt.Sprintf(`<c r="%s"%s><f>%s</f><v>%s</v></c>`, xlsxRef(col, row), xlsxStyle(style), formula, value)
+}
+
+// xlsxSerial is a date as an Excel serial number
+func xlsxSerial(t time.Time) int {
+	return int(t.Sub(xlsxEpoch).Hours() / 24)
+}
+
+// xlsxCents formats an amount in cents
+func xlsxCents(cents int64) string {
+	return string(xlsxAppendCents(nil, cents))
+}
+
+func xlsxAppendCents(b []byte, cents int64) []byte {
+	b = strconv.AppendInt(b, cents/100, 10)
+	return append(b, '.', byte('0'+cents%100/10), byte('0'+cents%10))
+}
+
+// xlsxPercent formats a percentage as a fraction
+func xlsxPercent(percent int) string {
+	return strconv.FormatFloat(float64(percent)/100, 'f', -1, 64)
+}
+
+// xlsxDigits returns the number of decimal digits of n
+func xlsxDigits(n int64) int {
+	digits := 1
+	for ; n >= 10; n /= 10 {
+		digits++
+	}
+	return digits
+}
+
+// xlsxPool returns n distinct capitalized words, such as department or
+// region names
+func xlsxPool(r *rand.Rand, n int) []string {
+	pool := make([]string, 0, n)
+	seen := map[string]bool{}
+	for len(pool) < n {
+		if word := strings.Title(randomWord(r)); !seen[word] {
+			seen[word] = true
+			pool = append(pool, word)
+		}
+	}
+	return pool
+}
+
+// xlsxStyles is xl/styles.xml. Number formats 164 and 165 are custom;
+// 9 is the built-in whole percent format.
+const xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet ` + xlsxMainNS + `>
+  <numFmts count="2"><numFmt numFmtId="164" formatCode="yyyy-mm-dd"/><numFmt numFmtId="165" formatCode="&quot;$&quot;#,##0.00"/></numFmts>
+  <fonts count="3">
+    <font><sz val="11"/><name val="Calibri"/><family val="2"/></font>
+    <font><b/><sz val="11"/><name val="Calibri"/><family val="2"/></font>
+    <font><b/><sz val="16"/><color rgb="FF1F3864"/><name val="Calibri"/><family val="2"/></font>
+  </fonts>
+  <fills count="3">
+    <fill><patternFill patternType="none"/></fill>
+    <fill><patternFill patternType="gray125"/></fill>
+    <fill><patternFill patternType="solid"><fgColor rgb="FFD9E1F2"/><bgColor indexed="64"/></patternFill></fill>
+  </fills>
+  <borders count="2">
+    <border><left/><right/><top/><bottom/><diagonal/></border>
+    <border><left/><right/><top style="thin"><color auto="1"/></top><bottom/><diagonal/></border>
+  </borders>
+  <cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+  <cellXfs count="7">
+    <xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>
+    <xf numFmtId="0" fontId="2" fillId="0" borderId="0" xfId="0" applyFont="1"/>
+    <xf numFmtId="0" fontId="1" fillId="2" borderId="0" xfId="0" applyFont="1" applyFill="1"/>
+    <xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+    <xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+    <xf numFmtId="9" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+    <xf numFmtId="165" fontId="1" fillId="0" borderId="1" xfId="0" applyNumberFormat="1" applyFont="1" applyBorder="1"/>
+  </cellXfs>
+  <cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>
+</styleSheet>`
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var (
+	xlsxSheetPattern    = regexp.MustCompile(`<sheet name="([^"]+)" sheetId="\d+" r:id="([^"]+)"/>`)
+	xlsxCellPattern     = regexp.MustCompile(`<c r="([A-Z]+\d+)"`)
+	xlsxRowPattern      = regexp.MustCompile(`<row r="(\d+)"`)
+	xlsxSharedPattern   = regexp.MustCompile(`t="s"><v>(\d+)</v>`)
+	xlsxFormulaPattern  = regexp.MustCompile(`<(?:c:)?f>([^<]*)</(?:c:)?f>`)
+	xlsxTableRefPattern = regexp.MustCompile(`<table [^>]* ref="([^"]+)"`)
+	xlsxRangePattern    = regexp.MustCompile(`(?:('[^']+'|[A-Za-z]+)!)?\$?([A-Z]+)\$?(\d+)(?::\$?([A-Z]+)\$?(\d+))?`)
+)
+
+// workbookSheets returns the sheet parts of a workbook in order, and their
+// names
+func workbookSheets(t *testing.T, pkg ooxmlPackage) (parts, names []string) {
+	t.Helper()
+	rels := pkg.rels(t, "xl/workbook.xml")
+	for _, m := range xlsxSheetPattern.FindAllStringSubmatch(pkg["xl/workbook.xml"], -1) {
+		names = append(names, m[1])
+		parts = append(parts, rels[m[2]])
+	}
+	return parts, names
+}
+
+// checkXlsx checks that a workbook has the sheets, tables and charts of
+// g's metadata, that its shared string indexes are in range, and that
+// every cell a formula, table or chart refers to exists
+func checkXlsx(t *testing.T, g *XlsxGenerator, pkg ooxmlPackage, contentTypes map[string]string) {
+	t.Helper()
+	parts, names := workbookSheets(t, pkg)
+	if !reflect.DeepEqual(names, g.Metadata()["sheets"]) {
+		t.Errorf("sheets %v, metadata says %v", names, g.Metadata()["sheets"])
+	}
+	worksheets := 0
+	for name := range pkg {
+		if strings.HasPrefix(name, "xl/worksheets/sheet") {
+			worksheets++
+		}
+	}
+	if worksheets != len(names) {
+		t.Errorf("%d worksheets for %d sheets", worksheets, len(names))
+	}
+
+	shared := strings.Count(pkg["xl/sharedStrings.xml"], "<si>")
+	if !strings.Contains(pkg["xl/sharedStrings.xml"], `uniqueCount="`+strconv.Itoa(shared)+`"`) {
+		t.Errorf("sharedStrings.xml has %d strings and a different uniqueCount", shared)
+	}
+	cells := make(map[string]map[string]bool) // by sheet name
+	for i, part := range parts {
+		cells[names[i]] = make(map[string]bool)
+		for _, m := range xlsxCellPattern.FindAllStringSubmatch(pkg[part], -1) {
+			cells[names[i]][m[1]] = true
+		}
+		for _, m := range xlsxSharedPattern.FindAllStringSubmatch(pkg[part], -1) {
+			if n, _ := strconv.Atoi(m[1]); n >= shared {
+				t.Errorf("%s refers to shared string %d of %d", names[i], n, shared)
+			}
+		}
+	}
+
+	// Formulas refer to cells on their own sheet unless they name another
+	formulas := 0
+	for i, part := range parts {
+		for _, f := range xlsxFormulaPattern.FindAllStringSubmatch(pkg[part], -1) {
+			formulas++
+			checkXlsxRefs(t, cells, names[i], f[1])
+		}
+	}
+	if formulas == 0 {
+		t.Error("the workbook has no formulas")
+	}
+
+	// Tables cover cells of the sheet that links them, and charts plot
+	// cells of any sheet
+	tables, charts := 0, 0
+	for i, part := range parts {
+		for _, target := range pkg.rels(t, part) {
+			if strings.HasPrefix(target, "xl/tables/") {
+				tables++
+				checkXlsxRefs(t, cells, names[i], xlsxTableRefPattern.FindStringSubmatch(pkg[target])[1])
+			}
+		}
+	}
+	for name, content := range pkg {
+		switch {
+		case strings.HasPrefix(name, "xl/tables/") && contentTypes[name] != xlsxTableContent,
+			strings.HasPrefix(name, "xl/drawings/drawing") && contentTypes[name] != xlsxDrawingContent,
+			strings.HasPrefix(name, "xl/charts/") && contentTypes[name] != xlsxChartContent:
+			t.Errorf("%s has content type %q", name, contentTypes[name])
+		case strings.HasPrefix(name, "xl/charts/"):
+			charts++
+			for _, f := range xlsxFormulaPattern.FindAllStringSubmatch(content, -1) {
+				checkXlsxRefs(t, cells, name, f[1])
+			}
+		}
+	}
+	if want := g.Metadata()["tables"]; g.Tables && tables != want || !g.Tables && tables != 0 {
+		t.Errorf("%d tables, metadata says %v", tables, want)
+	}
+	if charts != len(g.Charts) {
+		t.Errorf("%d charts, want %d", charts, len(g.Charts))
+	}
+}
+
+// checkXlsxRefs checks that the cells and range ends that formula refers
+// to exist
+func checkXlsxRefs(t *testing.T, cells map[string]map[string]bool, sheet, formula string) {
+	t.Helper()
+	for _, m := range xlsxRangePattern.FindAllStringSubmatch(formula, -1) {
+		name := sheet
+		if m[1] != "" {
+			name = strings.Trim(m[1], "'")
+		}
+		if cells[name] == nil {
+			t.Errorf("%s refers to the missing sheet %s in %s", sheet, name, formula)
+			continue
+		}
+		refs := []string{m[2] + m[3]}
+		if m[4] != "" {
+			refs = append(refs, m[4]+m[5])
+		}
+		for _, ref := range refs {
+			if !cells[name][ref] {
+				t.Errorf("%s refers to the empty cell %s!%s in %s", sheet, name, ref, formula)
+			}
+		}
+	}
+}
+
+func TestXlsxPackage(t *testing.T) {
+	g := &XlsxGenerator{}
+	testOoxml(t, g, 300*1024, func(t *testing.T, pkg ooxmlPackage, contentTypes map[string]string) {
+		checkXlsx(t, g, pkg, contentTypes)
+
+		// The sales rows are those of the metadata
+		parts, _ := workbookSheets(t, pkg)
+		rows := 0
+		for _, part := range parts[xlsxSalesSheet-1:] {
+			rows += len(xlsxRowPattern.FindAllString(pkg[part], -1)) - xlsxFirstRow
+		}
+		if rows != g.Metadata()["rows"] || rows == 0 {
+			t.Errorf("%d sales rows, metadata says %v", rows, g.Metadata()["rows"])
+		}
+	})
+}
+
+func TestXlsxTablesAndCharts(t *testing.T) {
+	g := &XlsxGenerator{Tables: true, Charts: xlsxChartKinds}
+	testOoxml(t, g, 200*1024, func(t *testing.T, pkg ooxmlPackage, contentTypes map[string]string) {
+		checkXlsx(t, g, pkg, contentTypes)
+		if !strings.Contains(pkg["xl/worksheets/sheet1.xml"], xlsxDrawingPart) {
+			t.Error("the summary does not link its drawing")
+		}
+	})
+}
+
+// TestXlsxSheetRollover starts with a sales sheet two rows short of full,
+// rather than writing a million rows, and checks that the totals row is
+// the last row of a worksheet and that the ledger continues on a new sheet
+func TestXlsxSheetRollover(t *testing.T) {
+	g := &XlsxGenerator{Tables: true}
+	wb := g.prepare(newFileRand(5, 1), 64*1024)
+	var buf bytes.Buffer
+	_, err := g.writePackage(&buf, zip.Store, wb, func(sales *xlsxSales) {
+		sales.sheets[0].rows = xlsxSheetRows - 2
+		for range 5 {
+			sales.write(sales.next())
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg := readOoxml(t, buf.Bytes())
+	checkXlsx(t, g, pkg, checkOoxml(t, pkg))
+
+	metadata := g.Metadata()
+	if want := []string{"Summary", "Employees", "Sales", "Sales 2"}; !reflect.DeepEqual(metadata["sheets"], want) {
+		t.Errorf("sheets %v, want %v", metadata["sheets"], want)
+	}
+	if metadata["rows"] != xlsxSheetRows+3 {
+		t.Errorf("metadata says %v rows, want %d", metadata["rows"], xlsxSheetRows+3)
+	}
+	parts, _ := workbookSheets(t, pkg)
+	for i, want := range [][]int{{1, 2, xlsxMaxRows - 2, xlsxMaxRows - 1, xlsxMaxRows}, {1, 2, 3, 4, 5, 6}} {
+		var rows []int
+		for _, m := range xlsxRowPattern.FindAllStringSubmatch(pkg[parts[xlsxSalesSheet-1+i]], -1) {
+			n, _ := strconv.Atoi(m[1])
+			rows = append(rows, n)
+		}
+		if !reflect.DeepEqual(rows, want) {
+			t.Errorf("sales sheet %d has rows %v, want %v", i+1, rows, want)
+		}
+	}
+}
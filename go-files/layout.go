@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// treeStream is the PCG stream used to build the directory tree. File
+// streams use the file index, so this value never collides with one.
+const treeStream = ^uint64(0)
+
+// folderNames are realistic directory names used by the "words" naming mode
+var folderNames = []string{
+	"archive", "assets", "backup", "build", "clients", "config", "data",
+	"docs", "drafts", "exports", "finance", "images", "invoices", "legal",
+	"logs", "marketing", "media", "meetings", "misc", "old", "photos",
+	"projects", "reports", "research", "sales", "scans", "shared", "src",
+	"templates", "temp", "users", "2021", "2022", "2023", "2024", "2025",
+	"q1", "q2", "q3", "q4",
+}
+
+// intRange is an inclusive range of integers, parsed from "N" or "MIN-MAX"
+type intRange struct {
+	Min, Max int
+}
+
+// parseIntRange parses "N" or "MIN-MAX" into a range of non-negative integers
+func parseIntRange(s string) (intRange, error) {
+	minText, maxText, found := strings.Cut(strings.TrimSpace(s), "-")
+	if !found {
+		maxText = minText
+	}
+	lo, err1 := strconv.Atoi(strings.TrimSpace(minText))
+	hi, err2 := strconv.Atoi(strings.TrimSpace(maxText))
+	if err1 != nil || err2 != nil || lo < 0 || hi < lo {
+		return intRange{}, fmt.Errorf("invalid range %q (expected N or MIN-MAX)", s)
+	}
+	return intRange{Min: lo, Max: hi}, nil
+}
+
+// Pick returns a uniformly random value in the range
+func (ir intRange) Pick(r *rand.Rand) int {
+	return ir.Min + r.IntN(ir.Max-ir.Min+1)
+}
+
+// TreeOptions configures the directory tree
+type TreeOptions struct {
+	Depth       intRange // depth of each branch below the output directory
+	Fanout      intRange // subdirectories per directory
+	FilesPerDir intRange // files placed in each directory per pass
+	DirNames    string   // "words" or "random"
+}
+
+// treeLayout maps file indices to directories of a generated tree
+type treeLayout struct {
+	dirs []string // relative paths, "" is the output directory itself
+	ends []int    // ends[i] is the cumulative file capacity up to dirs[i]
+}
+
+// newTreeLayout builds a directory tree from the seed. The tree only
+// depends on the seed, the options and maxDirs, the number of files of
+// the run, which caps the number of directories so that deep and wide
+// trees never leave most of them empty.
+func newTreeLayout(seed uint64, opts TreeOptions, maxDirs int) (*treeLayout, error) {
+	if opts.DirNames != "words" && opts.DirNames != "random" {
+		return nil, fmt.Errorf("unknown directory naming %q (expected words or random)", opts.DirNames)
+	}
+
+	r := rand.New(rand.NewPCG(seed, treeStream))
+	layout := &treeLayout{}
+
+	// Breadth-first, so shallow directories come first in the layout.
+	// A branch always grows until the minimum depth, then stops at a
+	// random depth up to the maximum, unless the tree is full.
+	type node struct {
+		path  string
+		depth int
+	}
+	queue := []node{{path: "", depth: 0}}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		layout.dirs = append(layout.dirs, n.path)
+
+		if n.depth >= opts.Depth.Max || (n.depth >= opts.Depth.Min && r.IntN(2) == 0) {
+			continue
+		}
+		used := make(map[string]bool)
+		for i := opts.Fanout.Pick(r); i > 0 && len(layout.dirs)+len(queue) < maxDirs; i-- {
+			name := uniqueDirName(dirName(r, opts.DirNames), used)
+			queue = append(queue, node{path: filepath.Join(n.path, name), depth: n.depth + 1})
+		}
+	}
+
+	total := 0
+	for range layout.dirs {
+		total += opts.FilesPerDir.Pick(r)
+		layout.ends = append(layout.ends, total)
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("files per directory must allow at least one file")
+	}
+	return layout, nil
+}
+
+// dirName picks a directory name in the given naming mode
+func dirName(r *rand.Rand, mode string) string {
+	if mode == "words" {
+		return folderNames[r.IntN(len(folderNames))]
+	}
+	return strings.ToLower(randomWord(r))
+}
+
+// uniqueDirName appends a counter to name if a sibling already uses it
+func uniqueDirName(name string, used map[string]bool) string {
+	unique := name
+	for n := 2; used[unique]; n++ {
+		unique = fmt.Sprintf("%s_%d", name, n)
+	}
+	used[unique] = true
+	return unique
+}
+
+// Dir returns the directory for the file with the given index. Files fill
+// the directories in order; once every directory has received its share,
+// the assignment starts over from the top of the tree.
+func (l *treeLayout) Dir(index int) string {
+	pos := (index - 1) % l.ends[len(l.ends)-1]
+	i := sort.Search(len(l.ends), func(i int) bool { return l.ends[i] > pos })
+	return l.dirs[i]
+}
+
+// Create makes every directory of the tree under root, including the
+// ones that end up without files
+func (l *treeLayout) Create(root string) error {
+	for _, dir := range l.dirs {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Summary describes the shape of the tree
+func (l *treeLayout) Summary() string {
+	maxDepth := 0
+	for _, dir := range l.dirs {
+		if dir != "" {
+			maxDepth = max(maxDepth, strings.Count(dir, string(filepath.Separator))+1)
+		}
+	}
+	return fmt.Sprintf("%d directories, up to %d levels deep", len(l.dirs), maxDepth)
+}
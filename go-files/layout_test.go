@@ -0,0 +1,49 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTreeLayout(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     TreeOptions
+		maxDirs  int
+		wantDirs int // exact number of directories, 0 to only check the cap
+	}{
+		{"capped by files", TreeOptions{Depth: intRange{8, 8}, Fanout: intRange{10, 10}, FilesPerDir: intRange{1, 3}, DirNames: "words"}, 5, 5},
+		{"single file", TreeOptions{Depth: intRange{3, 3}, Fanout: intRange{4, 4}, FilesPerDir: intRange{1, 1}, DirNames: "random"}, 1, 1},
+		{"flat", TreeOptions{Depth: intRange{0, 0}, Fanout: intRange{2, 4}, FilesPerDir: intRange{1, 10}, DirNames: "words"}, 100, 1},
+		{"full", TreeOptions{Depth: intRange{2, 2}, Fanout: intRange{3, 3}, FilesPerDir: intRange{1, 10}, DirNames: "words"}, 1000, 13},
+		{"default", TreeOptions{Depth: intRange{1, 3}, Fanout: intRange{2, 4}, FilesPerDir: intRange{1, 10}, DirNames: "words"}, 50, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layout, err := newTreeLayout(7, tt.opts, tt.maxDirs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(layout.dirs) > max(1, tt.maxDirs) || (tt.wantDirs > 0 && len(layout.dirs) != tt.wantDirs) {
+				t.Errorf("%d directories for %d files, want %d", len(layout.dirs), tt.maxDirs, tt.wantDirs)
+			}
+			if layout.dirs[0] != "" {
+				t.Errorf("first directory is %q, want the output directory", layout.dirs[0])
+			}
+
+			again, _ := newTreeLayout(7, tt.opts, tt.maxDirs)
+			if !slices.Equal(layout.dirs, again.dirs) {
+				t.Error("the same seed built a different tree")
+			}
+			for index := 1; index <= tt.maxDirs; index++ {
+				if !slices.Contains(layout.dirs, layout.Dir(index)) {
+					t.Errorf("file %d placed in unknown directory %q", index, layout.Dir(index))
+				}
+			}
+		})
+	}
+
+	if _, err := newTreeLayout(7, TreeOptions{FilesPerDir: intRange{0, 0}, DirNames: "words"}, 10); err == nil {
+		t.Error("a tree without room for files was accepted")
+	}
+}
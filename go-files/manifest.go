@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// manifestEntry describes one generated file in the manifest
+type manifestEntry struct {
+	Index     int            `json:"index"`
+	Path      string         `json:"path"` // relative to the output directory, with forward slashes
+	Ext       string         `json:"ext"`
+	Size      int64          `json:"size"`
+	SHA256    string         `json:"sha256"`
+	Generator string         `json:"generator"`
+	Seed      uint64         `json:"seed"` // with the index, enough to regenerate the file
+	Metadata  map[string]any `json:"metadata,omitempty"`
+}
+
+// manifestColumns is the header of CSV manifests. Metadata is stored as a
+// JSON object in the last column.
+var manifestColumns = []string{"index", "path", "ext", "size", "sha256", "generator", "seed", "metadata"}
+
+// manifest collects the entries of a run, which are written sorted by index
+// so the manifest does not depend on the number of workers
+type manifest struct {
+	mu      sync.Mutex
+	entries []manifestEntry
+}
+
+// checkManifestPath verifies that the manifest format is known
+func checkManifestPath(path string) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jsonl", ".ndjson", ".csv":
+		return nil
+	}
+	return fmt.Errorf("unknown manifest format %q (expected .jsonl or .csv)", path)
+}
+
+// Add records one generated file
+func (m *manifest) Add(cfg *Config, res fileResult) {
+	path, err := filepath.Rel(cfg.OutDir, res.Filename)
+	if err != nil {
+		path = res.Filename
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = append(m.entries, manifestEntry{
+		Index:     res.Index,
+		Path:      filepath.ToSlash(path),
+		Ext:       res.Extension,
+		Size:      res.Size,
+		SHA256:    res.SHA256,
+		Generator: res.Generator,
+		Seed:      cfg.Seed,
+		Metadata:  res.Metadata,
+	})
+}
+
+// Write writes the manifest as JSON lines or CSV, depending on the extension
+func (m *manifest) Write(path string) error {
+	sort.Slice(m.entries, func(i, j int) bool { return m.entries[i].Index < m.entries[j].Index })
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		err = m.writeCSV(f)
+	} else {
+		enc := json.NewEncoder(f)
+		for _, entry := range m.entries {
+			if err = enc.Encode(entry); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func (m *manifest) writeCSV(f *os.File) error {
+	w := csv.NewWriter(f)
+	w.Write(manifestColumns)
+	for _, entry := range m.entries {
+		metadata := ""
+		if len(entry.Metadata) > 0 {
+			data, err := json.Marshal(entry.Metadata)
+			if err != nil {
+				return err
+			}
+			metadata = string(data)
+		}
+		w.Write([]string{
+			strconv.Itoa(entry.Index),
+			entry.Path,
+			entry.Ext,
+			strconv.FormatInt(entry.Size, 10),
+			entry.SHA256,
+			entry.Generator,
+			strconv.FormatUint(entry.Seed, 10),
+			metadata,
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
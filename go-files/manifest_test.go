@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestManifestRoundTrip(t *testing.T) {
+	cfg := testConfig(t, "txt,csv,pdf,png,docx,xlsx", 12, 1024, 16*1024)
+	dir := t.TempDir()
+	cfg.Manifests = []string{filepath.Join(dir, "manifest.jsonl"), filepath.Join(dir, "manifest.csv")}
+	if err := execute(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	jsonl, err := loadManifest(cfg.Manifests[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	csv, err := loadManifest(cfg.Manifests[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jsonl) != cfg.NumFiles {
+		t.Fatalf("manifest has %d entries, want %d", len(jsonl), cfg.NumFiles)
+	}
+	if !reflect.DeepEqual(jsonl, csv) {
+		t.Error("JSON lines and CSV manifests differ")
+	}
+
+	hashes := dirHashes(t, cfg.OutDir)
+	for i, e := range jsonl {
+		if e.Index != i+1 || e.Seed != cfg.Seed || hashes[e.Path] != e.SHA256 {
+			t.Errorf("entry %d: %+v does not match %s on disk", i, e, hashes[e.Path])
+		}
+	}
+	if n := verifyManifest(cfg.OutDir, jsonl); n != 0 {
+		t.Fatalf("%d files do not match a fresh manifest", n)
+	}
+
+	changed := filepath.Join(cfg.OutDir, filepath.FromSlash(jsonl[3].Path))
+	data, err := os.ReadFile(changed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)/2] ^= 1
+	if err := os.WriteFile(changed, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(cfg.OutDir, filepath.FromSlash(jsonl[5].Path))); err != nil {
+		t.Fatal(err)
+	}
+	if n := verifyManifest(cfg.OutDir, csv); n != 2 {
+		t.Errorf("%d mismatches after changing one file and removing another, want 2", n)
+	}
+}
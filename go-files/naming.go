@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// defaultNameTemplate reproduces the classic file_N.ext names
+const defaultNameTemplate = "file_{index}.{ext}"
+
+// nameTemplateFields lists the fields a name template may reference
+var nameTemplateFields = []string{"index", "ext", "size", "kb", "animal", "uuid", "random"}
+
+// nameFormat matches a printf format without its %: flags, width,
+// precision and the verb
+var nameFormat = regexp.MustCompile(`^[-+# 0]*[0-9]*(\.[0-9]*)?([a-zA-Z])$`)
+
+// nameFormatVerbs are the verbs a format may use with the integer fields
+// and with the string fields
+const (
+	nameIntVerbs    = "dxXobv"
+	nameStringVerbs = "sxXv"
+)
+
+// nameTemplate renders output file names from a template such as
+// "{index:06d}_{animal}_{size}.{ext}". A field may carry a printf style
+// format after a colon.
+type nameTemplate struct {
+	parts []templatePart
+}
+
+type templatePart struct {
+	literal string
+	field   string
+	format  string
+}
+
+// nameFields holds the values available to a name template
+type nameFields struct {
+	Index  int
+	Ext    string
+	Size   int64
+	Animal string
+	Rand   *rand.Rand // source for {uuid} and {random}
+}
+
+// parseNameTemplate parses and validates a name template
+func parseNameTemplate(template string) (*nameTemplate, error) {
+	t := &nameTemplate{}
+	rest := template
+	for rest != "" {
+		open := strings.IndexByte(rest, '{')
+		if open < 0 {
+			t.parts = append(t.parts, templatePart{literal: rest})
+			break
+		}
+		if open > 0 {
+			t.parts = append(t.parts, templatePart{literal: rest[:open]})
+		}
+		end := strings.IndexByte(rest[open:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed '{' in name template %q", template)
+		}
+		field, format, _ := strings.Cut(rest[open+1:open+end], ":")
+		if !isNameTemplateField(field) {
+			return nil, fmt.Errorf("unknown field {%s} in name template (supported: %s)",
+				field, strings.Join(nameTemplateFields, ", "))
+		}
+		if format != "" {
+			if err := checkNameFormat(field, format); err != nil {
+				return nil, err
+			}
+		}
+		t.parts = append(t.parts, templatePart{field: field, format: format})
+		rest = rest[open+end+1:]
+	}
+	if len(t.parts) == 0 {
+		return nil, fmt.Errorf("empty name template")
+	}
+	return t, nil
+}
+
+// checkNameFormat checks that format is a printf format for the value
+// of field, so that it never renders as %!verb(...)
+func checkNameFormat(field, format string) error {
+	m := nameFormat.FindStringSubmatch(format)
+	verbs := nameStringVerbs
+	if field == "index" || field == "size" || field == "kb" {
+		verbs = nameIntVerbs
+	}
+	if m == nil || !strings.Contains(verbs, m[2]) {
+		return fmt.Errorf("invalid format %q for {%s} in name template (verbs: %s)",
+			format, field, strings.Join(strings.Split(verbs, ""), ", "))
+	}
+	return nil
+}
+
+func isNameTemplateField(field string) bool {
+	for _, f := range nameTemplateFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// static reports whether the names depend only on the index and the
+// extension, so they are known before the content is generated
+func (t *nameTemplate) static() bool {
+	for _, part := range t.parts {
+		if part.field != "" && part.field != "index" && part.field != "ext" {
+			return false
+		}
+	}
+	return true
+}
+
+// Render builds the file name for one file. The result is a relative path
+// that must stay inside the output directory.
+func (t *nameTemplate) Render(f nameFields) (string, error) {
+	var sb strings.Builder
+	for _, part := range t.parts {
+		if part.field == "" {
+			sb.WriteString(part.literal)
+			continue
+		}
+
+		var value any
+		switch part.field {
+		case "index":
+			value = f.Index
+		case "ext":
+			value = f.Ext
+		case "size":
+			value = f.Size
+		case "kb":
+			value = f.Size / 1024
+		case "animal":
+			value = strings.ToLower(f.Animal)
+			if f.Animal == "" {
+				value = "none"
+			}
+		case "uuid":
+			value = randomUUID(f.Rand)
+		case "random":
+			value = randomString(f.Rand, 12)
+		}
+
+		if part.format != "" {
+			sb.WriteString(fmt.Sprintf("%"+part.format, value))
+		} else {
+			sb.WriteString(fmt.Sprint(value))
+		}
+	}
+
+	name := filepath.FromSlash(sb.String())
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("file name %q escapes the output directory", name)
+	}
+	return name, nil
+}
+
+// randomUUID generates a version 4 UUID from r
+func randomUUID(r *rand.Rand) string {
+	var b [16]byte
+	for i := range b {
+		b[i] = byte(r.IntN(256))
+	}
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
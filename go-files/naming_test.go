@@ -0,0 +1,176 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseNameTemplate(t *testing.T) {
+	tests := []struct {
+		template string
+		valid    bool
+	}{
+		{"file_{index}.{ext}", true},
+		{"{index:06d}_{animal}_{size}.{ext}", true},
+		{"{index:x}/{kb:-8d}.{ext}", true},
+		{"{animal:.3s}-{uuid:v}.{ext}", true},
+		{"{index:q}.{ext}", false},
+		{"{index:5dx}.{ext}", false},
+		{"{index:*d}.{ext}", false},
+		{"{uuid:d}.{ext}", false},
+		{"{size:s}.{ext}", false},
+		{"{name}.{ext}", false},
+		{"{index.{ext}", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		_, err := parseNameTemplate(tt.template)
+		if (err == nil) != tt.valid {
+			t.Errorf("parseNameTemplate(%q) error = %v, want valid %v", tt.template, err, tt.valid)
+		}
+	}
+}
+
+func TestRenderName(t *testing.T) {
+	tests := []struct {
+		template string
+		want     string
+		static   bool
+	}{
+		{"file_{index}.{ext}", "file_7.pdf", true},
+		{"{index:04d}_{animal}.{ext}", "0007_cat.pdf", false},
+		{"{kb}KB/{size:x}.{ext}", filepath.Join("2KB", "800.pdf"), false},
+		{"{ext}/{index:o}", filepath.Join("pdf", "7"), true},
+	}
+	for _, tt := range tests {
+		names, err := parseNameTemplate(tt.template)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := names.Render(nameFields{Index: 7, Ext: "pdf", Size: 2048, Animal: "Cat"})
+		if err != nil || got != tt.want {
+			t.Errorf("%q rendered %q, %v, want %q", tt.template, got, err, tt.want)
+		}
+		if names.static() != tt.static {
+			t.Errorf("%q static = %v, want %v", tt.template, names.static(), tt.static)
+		}
+	}
+
+	names, _ := parseNameTemplate("../{index}")
+	if _, err := names.Render(nameFields{Index: 1}); err == nil {
+		t.Error("a name outside the output directory was accepted")
+	}
+}
+
+func TestExistingFilesKept(t *testing.T) {
+	cfg := testConfig(t, "txt", 1, 64*1024, 64*1024)
+	existing := filepath.Join(cfg.OutDir, "file_1.txt")
+	if err := os.WriteFile(existing, []byte("keep"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := execute(cfg); err == nil {
+		t.Error("run over an existing file succeeded without force")
+	}
+	if data, _ := os.ReadFile(existing); string(data) != "keep" {
+		t.Errorf("existing file was overwritten with %d bytes", len(data))
+	}
+	if tmp, _ := filepath.Glob(filepath.Join(cfg.OutDir, ".generator-*")); len(tmp) > 0 {
+		t.Errorf("temporary files left behind: %v", tmp)
+	}
+
+	cfg.Force = true
+	if err := execute(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := os.Stat(existing); info.Size() != 64*1024 {
+		t.Errorf("forced run wrote %d bytes, want %d", info.Size(), 64*1024)
+	}
+}
+
+func TestTemporaryFilesRemoved(t *testing.T) {
+	// The names depend on the content, so the files are generated before
+	// the name is rendered or found to exist
+	tests := []struct {
+		name     string
+		template string
+	}{
+		{"existing file", "{kb}KB_{index}.{ext}"},
+		{"name outside the output directory", "{animal}/../../{index}.{ext}"},
+	}
+	for _, tt := range tests {
+		cfg := testConfig(t, "txt", 2, 4*1024, 4*1024)
+		cfg.Names, _ = parseNameTemplate(tt.template)
+		if err := os.WriteFile(filepath.Join(cfg.OutDir, "4KB_1.txt"), []byte("keep"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := execute(cfg); err == nil {
+			t.Errorf("%s: run succeeded", tt.name)
+		}
+		if tmp, _ := filepath.Glob(filepath.Join(cfg.OutDir, ".generator-*")); len(tmp) > 0 {
+			t.Errorf("%s: temporary files left behind: %v", tt.name, tmp)
+		}
+	}
+
+	// The temporary files of another run into the same directory are left
+	// alone, and files that are kept are readable like any other
+	cfg := testConfig(t, "txt", 1, 4*1024, 4*1024)
+	other := filepath.Join(cfg.OutDir, ".generator-1.tmp")
+	if err := os.WriteFile(other, []byte("another run"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := execute(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(other); string(data) != "another run" {
+		t.Errorf("the temporary file of another run now holds %d bytes", len(data))
+	}
+	if info, err := os.Stat(filepath.Join(cfg.OutDir, "file_1.txt")); err != nil || info.Mode().Perm() != 0644 || info.Size() != 4*1024 {
+		t.Errorf("generated file is %v, %v, want 4KB with mode 0644", info, err)
+	}
+}
+
+func TestNameCollisions(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		kept     int // files written before the collision is found
+	}{
+		{"names known before generating", "{ext}/report.{ext}", "files 1 and 2 are both named", 0},
+		{"names known after generating", "{kb}KB.{ext}", "2 file(s) could not be written", 1},
+	}
+	for _, tt := range tests {
+		for _, force := range []bool{false, true} {
+			cfg := testConfig(t, "txt", 3, 4*1024, 4*1024)
+			cfg.Names, _ = parseNameTemplate(tt.template)
+			cfg.Force = force
+
+			if err := execute(cfg); err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+				t.Errorf("%s, force %v: error = %v, want %q", tt.name, force, err, tt.want)
+			}
+			var files []string
+			filepath.WalkDir(cfg.OutDir, func(path string, d fs.DirEntry, err error) error {
+				if err == nil && !d.IsDir() {
+					files = append(files, path)
+				}
+				return err
+			})
+			if len(files) != tt.kept {
+				t.Errorf("%s, force %v: files %v left, want %d", tt.name, force, files, tt.kept)
+			}
+		}
+	}
+
+	// A collision found while generating names both files, in order
+	names := &nameRegistry{}
+	if err := names.claim("a.txt", 4); err != nil {
+		t.Fatal(err)
+	}
+	if err := names.claim("a.txt", 2); err == nil || !strings.HasPrefix(err.Error(), "files 2 and 4 are both named a.txt") {
+		t.Errorf("second claim error = %v", err)
+	}
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"archive/zip"
+	"compress/flate"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math/rand/v2"
+	"path"
+	"strings"
+	"time"
+)
+
+// odfMimetypes are the media types of the OpenDocument formats, which
+// their packages store in the mimetype entry
+var odfMimetypes = map[string]string{
+	"odt": "application/vnd.oasis.opendocument.text",
+	"ods": "application/vnd.oasis.opendocument.spreadsheet",
+	"odp": "application/vnd.oasis.opendocument.presentation",
+}
+
+// OpenDocument parts share one set of namespace declarations
+const (
+	odfVersion   = "1.3"
+	odfGenerator = "LibreOffice/7.6.4.1$Linux_X86_64"
+	odfNS        = `xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0" xmlns:draw="urn:oasis:names:tc:opendocument:xmlns:drawing:1.0" xmlns:fo="urn:oasis:names:tc:opendocument:xmlns:xsl-fo-compatible:1.0" xmlns:xlink="http://www.w3.org/1999/xlink" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:meta="urn:oasis:names:tc:opendocument:xmlns:meta:1.0" xmlns:number="urn:oasis:names:tc:opendocument:xmlns:datastyle:1.0" xmlns:svg="urn:oasis:names:tc:opendocument:xmlns:svg-compatible:1.0" xmlns:presentation="urn:oasis:names:tc:opendocument:xmlns:presentation:1.0" xmlns:config="urn:oasis:names:tc:opendocument:xmlns:config:1.0" xmlns:of="urn:oasis:names:tc:opendocument:xmlns:of:1.2"`
+)
+
+// The compressed size of content.xml is measured by flushing the
+// compressor at most every odfFlushBytes of body
+const odfFlushBytes = 16 << 20
+
+// odfPackage writes an OpenDocument package: the mimetype entry first,
+// then the parts, and META-INF/manifest.xml listing them on close
+type odfPackage struct {
+	sw         *sizedWriter
+	zip        *zip.Writer
+	compressor *flate.Writer // of the last deflated part
+	mimetype   string
+	files      []string
+}
+
+// newOdfPackage starts the package of the format ext with its mimetype
+// entry, stored uncompressed and without a data descriptor or extra
+// fields, so it can be recognized at a fixed offset. The package is
+// returned even on error, for the size written.
+func newOdfPackage(w io.Writer, ext string) (*odfPackage, error) {
+	p := &odfPackage{sw: newSizedWriter(w, -1), mimetype: odfMimetypes[ext]}
+	p.zip = zip.NewWriter(p.sw)
+	p.zip.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
+		var err error
+		p.compressor, err = flate.NewWriter(out, flate.DefaultCompression)
+		return p.compressor, err
+	})
+	mimetype, err := p.zip.CreateRaw(&zip.FileHeader{
+		Name:               "mimetype",
+		Method:             zip.Store,
+		CRC32:              crc32.ChecksumIEEE([]byte(p.mimetype)),
+		CompressedSize64:   uint64(len(p.mimetype)),
+		UncompressedSize64: uint64(len(p.mimetype)),
+	})
+	if err != nil {
+		return p, err
+	}
+	_, err = io.WriteString(mimetype, p.mimetype)
+	return p, err
+}
+
+// create starts a part compressed with the given method
+func (p *odfPackage) create(name string, method uint16) (io.Writer, error) {
+	p.files = append(p.files, name)
+	return p.zip.CreateHeader(&zip.FileHeader{Name: name, Method: method})
+}
+
+// add writes a compressed part
+func (p *odfPackage) add(name, content string) error {
+	w, err := p.create(name, zip.Deflate)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, content)
+	return err
+}
+
+// addImages writes the images to Pictures, stored since PNG is already
+// compressed
+func (p *odfPackage) addImages(images []docxImage) error {
+	for i, img := range images {
+		w, err := p.create(fmt.Sprintf("Pictures/image%d.png", i+1), zip.Store)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(img.data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compressed flushes the current part and returns the size of the
+// package so far
+func (p *odfPackage) compressed() int64 {
+	if p.compressor != nil {
+		p.compressor.Flush()
+	}
+	p.zip.Flush()
+	return p.sw.Len()
+}
+
+// close writes META-INF/manifest.xml and the central directory, and
+// returns the size of the package
+func (p *odfPackage) close() (int64, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<?xml version="1.0" encoding="UTF-8"?>
+<manifest:manifest xmlns:manifest="urn:oasis:names:tc:opendocument:xmlns:manifest:1.0" manifest:version="%[1]s">
+ <manifest:file-entry manifest:full-path="/" manifest:version="%[1]s" manifest:media-type="%[2]s"/>`, odfVersion, p.mimetype)
+	for _, name := range p.files {
+		mediaType := "text/xml"
+		if path.Ext(name) == ".png" {
+			mediaType = "image/png"
+		}
+		fmt.Fprintf(&b, `
+ <manifest:file-entry manifest:full-path="%s" manifest:media-type="%s"/>`, name, mediaType)
+	}
+	b.WriteString("\n</manifest:manifest>")
+	if err := writeZipFile(p.zip, "META-INF/manifest.xml", b.String()); err != nil {
+		return p.sw.Len(), err
+	}
+	err := p.zip.Close()
+	return p.sw.Len(), err
+}
+
+// addMeta writes meta.xml with the document properties. The company,
+// which OpenDocument has no element for, is a user-defined property.
+func (p *odfPackage) addMeta(props *officeProperties) error {
+	var keywords strings.Builder
+	for _, keyword := range strings.Split(props.keywords, ",") {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			fmt.Fprintf(&keywords, "\n  <meta:keyword>%s</meta:keyword>", xmlEscape(keyword))
+		}
+	}
+	return p.add("meta.xml", fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<office:document-meta %s office:version="%s">
+ <office:meta>
+  <meta:generator>%s</meta:generator>
+  <dc:title>%s</dc:title>
+  <dc:subject>%s</dc:subject>%s
+  <meta:initial-creator>%s</meta:initial-creator>
+  <dc:creator>%s</dc:creator>
+  <meta:creation-date>%s</meta:creation-date>
+  <dc:date>%s</dc:date>
+  <meta:editing-cycles>%d</meta:editing-cycles>
+  <meta:user-defined meta:name="Company">%s</meta:user-defined>
+ </office:meta>
+</office:document-meta>`,
+		odfNS, odfVersion, odfGenerator, xmlEscape(props.title), xmlEscape(props.subject), keywords.String(),
+		xmlEscape(props.author), xmlEscape(props.lastModifiedBy),
+		props.created.UTC().Format(time.RFC3339), props.modified.UTC().Format(time.RFC3339),
+		props.revision, xmlEscape(props.company)))
+}
+
+// odfBody generates the blocks of a document body that fill it up to its
+// size: the paragraphs, lists, tables and figures of a text document or
+// the slides of a presentation
+type odfBody interface {
+	next() string
+	write(body *sizedWriter, block string)
+}
+
+// odfFill writes blocks until the compressed body reaches target bytes.
+// How many more fit is estimated from how well the blocks so far
+// compressed; until the first measurement, blocks are assumed not to
+// compress. Half of them are written before measuring again, since the
+// ratio drifts as the compressor's window fills. compressed flushes the
+// body and returns the size of the package so far.
+func odfFill(body *sizedWriter, blocks odfBody, compressed func() int64, target int64) {
+	start, count := compressed(), int64(0)
+	for body.err == nil {
+		written := compressed() - start
+		ratio := 1.0
+		if count > 0 && written > 0 {
+			ratio = float64(body.Len()) / float64(written)
+		}
+		more := int64(float64(target-written) * ratio)
+		if more <= 0 || (count > 0 && more < body.Len()/count) {
+			break
+		}
+		end := body.Len() + min(more/2, odfFlushBytes)
+		for body.Len() < end && body.err == nil {
+			blocks.write(body, blocks.next())
+			count++
+		}
+	}
+}
+
+// odfFillExact writes blocks while they fit in budget bytes of stored
+// body, and pads the body with whitespace to exactly budget bytes
+func odfFillExact(body *sizedWriter, blocks odfBody, budget int64) {
+	for body.err == nil {
+		block := blocks.next()
+		if body.Len()+int64(len(block)) > budget {
+			break
+		}
+		blocks.write(body, block)
+	}
+	writeRepeated(body, ' ', budget-body.Len())
+}
+
+// odfList is a list of three to six items, some of them indented to the
+// second level below the item before. attrs are the attributes of the
+// list, and item returns the paragraph of an item.
+func odfList(r *rand.Rand, attrs string, item func() string) string {
+	var b strings.Builder
+	b.WriteString("<text:list" + attrs + ">")
+	items, nested := 0, false
+	for i := 3 + r.IntN(4); i > 0; i-- {
+		if items > 0 && r.IntN(4) == 0 {
+			if !nested {
+				b.WriteString("<text:list>")
+				nested = true
+			}
+			b.WriteString("<text:list-item>" + item() + "</text:list-item>")
+			continue
+		}
+		if nested {
+			b.WriteString("</text:list>")
+			nested = false
+		}
+		if items > 0 {
+			b.WriteString("</text:list-item>")
+		}
+		b.WriteString("<text:list-item>" + item())
+		items++
+	}
+	if nested {
+		b.WriteString("</text:list>")
+	}
+	b.WriteString("</text:list-item></text:list>")
+	return b.String()
+}
+
+// odfFontFaces declares the fonts the styles use
+const odfFontFaces = `
+ <office:font-face-decls>
+  <style:font-face style:name="Liberation Sans" svg:font-family="&apos;Liberation Sans&apos;" style:font-family-generic="swiss" style:font-pitch="variable"/>
+  <style:font-face style:name="Liberation Serif" svg:font-family="&apos;Liberation Serif&apos;" style:font-family-generic="roman" style:font-pitch="variable"/>
+ </office:font-face-decls>`
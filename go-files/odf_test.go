@@ -0,0 +1,9 @@
+package main
+
+import "testing"
+
+func TestOdfWriteErrors(t *testing.T) {
+	for _, ext := range []string{"odt", "ods", "odp"} {
+		checkWriteErrors(t, ext, 40*1024)
+	}
+}
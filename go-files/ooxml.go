@@ -0,0 +1,174 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+)
+
+// OfficeProperties configures the document properties of DOCX and XLSX
+// packages, stored in docProps/core.xml and docProps/app.xml. Empty
+// fields get random values.
+type OfficeProperties struct {
+	Title          string `json:"title"`
+	Subject        string `json:"subject"`
+	Author         string `json:"author"`
+	LastModifiedBy string `json:"last_modified_by"`
+	Company        string `json:"company"`
+	Keywords       string `json:"keywords"`
+	Created        string `json:"created"`  // RFC 3339 time or YYYY-MM-DD
+	Modified       string `json:"modified"` // same, not before created
+	Revision       int    `json:"revision"`
+}
+
+// officeProperties are the properties of one generated package
+type officeProperties struct {
+	title, subject, author, lastModifiedBy, company, keywords string
+	created, modified                                         time.Time
+	revision                                                  int
+}
+
+// Validate checks the configured dates and revision
+func (p *OfficeProperties) Validate() error {
+	created, err := parseOfficeDate(p.Created)
+	if err != nil {
+		return fmt.Errorf("created: %w", err)
+	}
+	modified, err := parseOfficeDate(p.Modified)
+	if err != nil {
+		return fmt.Errorf("modified: %w", err)
+	}
+	if !created.IsZero() && !modified.IsZero() && modified.Before(created) {
+		return fmt.Errorf("modified %s is before created %s", p.Modified, p.Created)
+	}
+	if p.Revision < 0 {
+		return fmt.Errorf("revision must not be negative")
+	}
+	return nil
+}
+
+// resolve fills in the properties that are not configured. title is the
+// document's own title, used unless one is configured.
+func (p *OfficeProperties) resolve(r *rand.Rand, title string) officeProperties {
+	pick := func(value string, random func() string) string {
+		if value != "" {
+			return value
+		}
+		return random()
+	}
+	name := func() string { return strings.Title(randomWord(r) + " " + randomWord(r)) }
+
+	props := officeProperties{
+		title:          pick(p.Title, func() string { return pick(title, name) }),
+		subject:        pick(p.Subject, func() string { return strings.TrimSuffix(randomSentence(r), ".") }),
+		author:         pick(p.Author, name),
+		lastModifiedBy: pick(p.LastModifiedBy, name),
+		company:        pick(p.Company, func() string { return strings.Title(randomWord(r)) + " Inc." }),
+		keywords: pick(p.Keywords, func() string {
+			return strings.Join([]string{randomWord(r), randomWord(r), randomWord(r)}, ", ")
+		}),
+		revision: p.Revision,
+	}
+	if props.revision == 0 {
+		props.revision = 1 + r.IntN(20)
+	}
+
+	// Dates were validated, and a missing one is picked around the other
+	props.created, _ = parseOfficeDate(p.Created)
+	props.modified, _ = parseOfficeDate(p.Modified)
+	edits := time.Duration(r.IntN(90*86400)) * time.Second
+	switch {
+	case props.created.IsZero() && props.modified.IsZero():
+		props.created = randomDate(r).Add(time.Duration(r.IntN(86400)) * time.Second)
+		props.modified = props.created.Add(edits)
+	case props.created.IsZero():
+		props.created = props.modified.Add(-edits)
+	case props.modified.IsZero():
+		props.modified = props.created.Add(edits)
+	}
+	return props
+}
+
+// addMetadata adds the properties to a generator's metadata
+func (props *officeProperties) addMetadata(metadata map[string]any) {
+	metadata["title"] = props.title
+	metadata["subject"] = props.subject
+	metadata["author"] = props.author
+	metadata["last_modified_by"] = props.lastModifiedBy
+	metadata["company"] = props.company
+	metadata["keywords"] = props.keywords
+	metadata["created"] = props.created.Format(time.RFC3339)
+	metadata["modified"] = props.modified.Format(time.RFC3339)
+	metadata["revision"] = props.revision
+}
+
+// Content types and relationships of the property parts, for
+// [Content_Types].xml and _rels/.rels
+const (
+	officePropsContentTypes = `
+  <Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>
+  <Override PartName="/docProps/app.xml" ContentType="application/vnd.openxmlformats-officedocument.extended-properties+xml"/>`
+	officePropsRelationships = `
+  <Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>
+  <Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/extended-properties" Target="docProps/app.xml"/>`
+)
+
+// writeDocProps writes docProps/core.xml and docProps/app.xml. app holds
+// the application specific elements of app.xml.
+func writeDocProps(zw *zip.Writer, props *officeProperties, application, app string) error {
+	core := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/" xmlns:dcmitype="http://purl.org/dc/dcmitype/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+  <dc:title>%s</dc:title>
+  <dc:subject>%s</dc:subject>
+  <dc:creator>%s</dc:creator>
+  <cp:keywords>%s</cp:keywords>
+  <cp:lastModifiedBy>%s</cp:lastModifiedBy>
+  <cp:revision>%d</cp:revision>
+  <dcterms:created xsi:type="dcterms:W3CDTF">%s</dcterms:created>
+  <dcterms:modified xsi:type="dcterms:W3CDTF">%s</dcterms:modified>
+</cp:coreProperties>`,
+		xmlEscape(props.title), xmlEscape(props.subject), xmlEscape(props.author), xmlEscape(props.keywords),
+		xmlEscape(props.lastModifiedBy), props.revision,
+		props.created.UTC().Format(time.RFC3339), props.modified.UTC().Format(time.RFC3339))
+	if err := writeZipFile(zw, "docProps/core.xml", core); err != nil {
+		return err
+	}
+
+	return writeZipFile(zw, "docProps/app.xml", fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Properties xmlns="http://schemas.openxmlformats.org/officeDocument/2006/extended-properties" xmlns:vt="http://schemas.openxmlformats.org/officeDocument/2006/docPropsVTypes">
+  <Application>%s</Application>
+  <DocSecurity>0</DocSecurity>
+  <ScaleCrop>false</ScaleCrop>%s
+  <Company>%s</Company>
+  <LinksUpToDate>false</LinksUpToDate>
+  <SharedDoc>false</SharedDoc>
+  <HyperlinksChanged>false</HyperlinksChanged>
+  <AppVersion>16.0000</AppVersion>
+</Properties>`, application, app, xmlEscape(props.company)))
+}
+
+// parseOfficeDate parses an RFC 3339 time or a date; an empty string is
+// the zero time
+func parseOfficeDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return t, fmt.Errorf("invalid date %q (expected RFC 3339 or YYYY-MM-DD)", s)
+	}
+	return t, nil
+}
+
+// xmlEscape escapes text for XML character data and attribute values
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
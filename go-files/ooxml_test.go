@@ -0,0 +1,215 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"path"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// ooxmlPackage holds the parts of an OOXML package by name
+type ooxmlPackage map[string]string
+
+// ooxmlRefPattern matches the relationship ids that parts refer to
+var ooxmlRefPattern = regexp.MustCompile(`r:(?:id|embed)="([^"]+)"`)
+
+// testOoxml generates a file of size with g, compressed and then exact,
+// checks the structure of each package with checkOoxml, and calls check
+// with the package and its content types while g's metadata describes it
+func testOoxml(t *testing.T, g ExactGenerator, size int64, check func(t *testing.T, pkg ooxmlPackage, contentTypes map[string]string)) {
+	t.Helper()
+	modes := []struct {
+		name     string
+		generate func(w io.Writer, r *rand.Rand, size int64) (int64, error)
+	}{
+		{"stream", g.(StreamGenerator).GenerateTo},
+		{"exact", g.GenerateExactTo},
+	}
+	for _, mode := range modes {
+		t.Run(mode.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if _, err := mode.generate(&buf, newFileRand(9, 1), size); err != nil {
+				t.Fatal(err)
+			}
+			pkg := readOoxml(t, buf.Bytes())
+			check(t, pkg, checkOoxml(t, pkg))
+		})
+	}
+}
+
+// readOoxml reads the parts of a package
+func readOoxml(t *testing.T, data []byte) ooxmlPackage {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg := make(ooxmlPackage)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", f.Name, err)
+		}
+		if _, dup := pkg[f.Name]; dup {
+			t.Errorf("part %s is stored twice", f.Name)
+		}
+		pkg[f.Name] = string(content)
+	}
+	return pkg
+}
+
+// rels returns the relationships of part, from relationship id to the name
+// of the target part
+func (pkg ooxmlPackage) rels(t *testing.T, part string) map[string]string {
+	t.Helper()
+	dir, name := path.Split(part)
+	content, ok := pkg[dir+"_rels/"+name+".rels"]
+	if !ok {
+		return nil
+	}
+	var rels struct {
+		Relationship []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:",attr"`
+		}
+	}
+	if err := xml.Unmarshal([]byte(content), &rels); err != nil {
+		t.Fatalf("relationships of %s: %v", part, err)
+	}
+	targets := make(map[string]string)
+	for _, rel := range rels.Relationship {
+		if _, dup := targets[rel.ID]; dup {
+			t.Errorf("%s has relationship %s twice", part, rel.ID)
+		}
+		targets[rel.ID] = path.Join(dir, rel.Target)
+	}
+	return targets
+}
+
+// checkOoxml checks the structure of a package: every part has a content
+// type and every override names a part, every relationship targets a
+// part, and every relationship id a part refers to is one of its
+// relationships. It returns the content types of the parts.
+func checkOoxml(t *testing.T, pkg ooxmlPackage) map[string]string {
+	t.Helper()
+	var types struct {
+		Default []struct {
+			Extension   string `xml:",attr"`
+			ContentType string `xml:",attr"`
+		}
+		Override []struct {
+			PartName    string `xml:",attr"`
+			ContentType string `xml:",attr"`
+		}
+	}
+	if err := xml.Unmarshal([]byte(pkg["[Content_Types].xml"]), &types); err != nil {
+		t.Fatalf("[Content_Types].xml: %v", err)
+	}
+	defaults := make(map[string]string)
+	for _, d := range types.Default {
+		defaults[d.Extension] = d.ContentType
+	}
+	contentTypes := make(map[string]string)
+	for _, o := range types.Override {
+		name := strings.TrimPrefix(o.PartName, "/")
+		if _, ok := pkg[name]; !ok {
+			t.Errorf("[Content_Types].xml overrides the missing part %s", name)
+		}
+		contentTypes[name] = o.ContentType
+	}
+
+	for name, content := range pkg {
+		if name == "[Content_Types].xml" {
+			continue
+		}
+		if _, ok := contentTypes[name]; !ok {
+			contentTypes[name] = defaults[strings.TrimPrefix(path.Ext(name), ".")]
+		}
+		if contentTypes[name] == "" {
+			t.Errorf("part %s has no content type", name)
+		}
+		if strings.HasSuffix(name, ".rels") {
+			continue
+		}
+		rels := pkg.rels(t, name)
+		for id, target := range rels {
+			if _, ok := pkg[target]; !ok {
+				t.Errorf("relationship %s of %s targets the missing part %s", id, name, target)
+			}
+		}
+		for _, m := range ooxmlRefPattern.FindAllStringSubmatch(content, -1) {
+			if _, ok := rels[m[1]]; !ok {
+				t.Errorf("%s refers to the missing relationship %s", name, m[1])
+			}
+		}
+	}
+	// The package relationships, in _rels/.rels
+	for id, target := range pkg.rels(t, "") {
+		if _, ok := pkg[target]; !ok {
+			t.Errorf("package relationship %s targets the missing part %s", id, target)
+		}
+	}
+	return contentTypes
+}
+
+var errDiskFull = errors.New("disk full")
+
+// failingWriter accepts n bytes, then fails every write
+type failingWriter struct {
+	n int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		written := w.n
+		w.n = 0
+		return written, errDiskFull
+	}
+	w.n -= len(p)
+	return len(p), nil
+}
+
+// checkWriteErrors writes a file of ext to writers that fail after
+// various numbers of bytes, both streamed and exact, and checks that
+// every failure is returned with the bytes written
+func checkWriteErrors(t *testing.T, ext string, size int64) {
+	t.Helper()
+	g := NewGenerator(ext)
+	writes := map[string]func(w io.Writer) (int64, error){
+		"stream": func(w io.Writer) (int64, error) {
+			return g.(StreamGenerator).GenerateTo(w, newFileRand(2, 1), size)
+		},
+		"exact": func(w io.Writer) (int64, error) {
+			return g.(ExactGenerator).GenerateExactTo(w, newFileRand(2, 1), size)
+		},
+	}
+	for mode, write := range writes {
+		full, err := write(io.Discard)
+		if err != nil {
+			t.Fatalf("%s %s: %v", ext, mode, err)
+		}
+		for _, n := range []int64{0, 10, 100, 1000, full / 2, full - 1} {
+			written, err := write(&failingWriter{n: int(n)})
+			if !errors.Is(err, errDiskFull) || written != n {
+				t.Errorf("%s %s failing after %d of %d bytes returned %d, %v", ext, mode, n, full, written, err)
+			}
+		}
+	}
+}
+
+func TestOoxmlWriteErrors(t *testing.T) {
+	for _, ext := range []string{"docx", "xlsx", "pptx"} {
+		checkWriteErrors(t, ext, 200*1024)
+	}
+}
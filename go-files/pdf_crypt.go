@@ -0,0 +1,335 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rc4"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+)
+
+// pdfEncryptions are the methods of the standard security handler
+var pdfEncryptions = []string{"none", "rc4-40", "rc4-128", "aes-128", "aes-256"}
+
+// pdfPermissions are the permission flags of the standard security handler,
+// by bit position, counting from 1 as the PDF specification does
+var pdfPermissions = map[string]int{
+	"print":                 3,
+	"modify":                4,
+	"copy":                  5,
+	"annotate":              6,
+	"fill_forms":            9,
+	"extract_accessibility": 10,
+	"assemble":              11,
+	"print_high":            12,
+}
+
+// pdfPasswordPadding pads passwords to 32 bytes in revisions 2 to 4
+var pdfPasswordPadding = []byte{
+	0x28, 0xBF, 0x4E, 0x5E, 0x4E, 0x75, 0x8A, 0x41, 0x64, 0x00, 0x4E, 0x56, 0xFF, 0xFA, 0x01, 0x08,
+	0x2E, 0x2E, 0x00, 0xB6, 0xD0, 0x68, 0x3E, 0x80, 0x2F, 0x0C, 0xA9, 0xFE, 0x64, 0x53, 0x69, 0x7A,
+}
+
+// pdfPermissionValue returns the /P value that grants the named permissions.
+// Bits 1, 2, 7 and 8 are fixed, bits 13 to 32 are set.
+func pdfPermissionValue(permissions []string) int32 {
+	p := uint32(0xFFFFF0C0)
+	for _, name := range permissions {
+		p |= 1 << (pdfPermissions[name] - 1)
+	}
+	return int32(p)
+}
+
+// pdfPermissionNames returns the permissions granted by a /P value
+func pdfPermissionNames(p int32) []string {
+	names := []string{}
+	for name, bit := range pdfPermissions {
+		if uint32(p)&(1<<(bit-1)) != 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// pdfCrypt encrypts or decrypts the strings and streams of a document with
+// the standard security handler
+type pdfCrypt struct {
+	method   string
+	revision int
+	key      []byte     // the file key
+	id       []byte     // the first element of the trailer /ID
+	ivs      *rand.Rand // initialization vectors for AES
+
+	// Entries of the encryption dictionary
+	o, u, oe, ue, perms []byte
+	p                   int32
+}
+
+// newPdfCrypt derives the file key and the encryption dictionary entries.
+// id is the first element of the trailer /ID. Salts, the AES-256 file key
+// and IVs come from r, so the output is reproducible.
+func newPdfCrypt(method, userPassword, ownerPassword string, p int32, id []byte, r *rand.Rand) *pdfCrypt {
+	c := &pdfCrypt{method: method, p: p, id: id, ivs: rand.New(rand.NewPCG(r.Uint64(), r.Uint64()))}
+	if ownerPassword == "" {
+		ownerPassword = userPassword
+	}
+
+	if method == "aes-256" {
+		// Revision 6: a random file key, stored encrypted with keys
+		// derived from each password
+		c.revision = 6
+		c.key = randomBytes(r, 32)
+		uSalts, oSalts := randomBytes(r, 16), randomBytes(r, 16)
+		user, owner := []byte(userPassword), []byte(ownerPassword)
+		c.u = append(pdfHashR6(user, uSalts[:8], nil), uSalts...)
+		c.ue = aesNoPadding(pdfHashR6(user, uSalts[8:], nil), c.key)
+		c.o = append(pdfHashR6(owner, oSalts[:8], c.u), oSalts...)
+		c.oe = aesNoPadding(pdfHashR6(owner, oSalts[8:], c.u), c.key)
+
+		perms := make([]byte, 16)
+		binary.LittleEndian.PutUint64(perms, uint64(int64(p)))
+		copy(perms[8:], "Tadb")
+		copy(perms[12:], randomBytes(r, 4))
+		block, _ := aes.NewCipher(c.key)
+		c.perms = make([]byte, 16)
+		block.Encrypt(c.perms, perms)
+		return c
+	}
+
+	keyLen := 16
+	switch method {
+	case "rc4-40":
+		c.revision, keyLen = 2, 5
+	case "rc4-128":
+		c.revision = 3
+	default:
+		c.revision = 4
+	}
+	c.o = pdfOwnerValue(ownerPassword, userPassword, c.revision, keyLen)
+	c.key = pdfFileKey([]byte(userPassword), c.o, p, id, c.revision, keyLen)
+	c.u = pdfUserValue(c.key, id, c.revision)
+	return c
+}
+
+// dict returns the encryption dictionary
+func (c *pdfCrypt) dict() string {
+	entries := fmt.Sprintf("/O <%x> /U <%x> /P %d", c.o, c.u, c.p)
+	switch c.method {
+	case "rc4-40":
+		return "<< /Filter /Standard /V 1 /R 2 /Length 40 " + entries + " >>"
+	case "rc4-128":
+		return "<< /Filter /Standard /V 2 /R 3 /Length 128 " + entries + " >>"
+	case "aes-128":
+		return "<< /Filter /Standard /V 4 /R 4 /Length 128 /CF << /StdCF << /CFM /AESV2 /AuthEvent /DocOpen /Length 16 >> >> " +
+			"/StmF /StdCF /StrF /StdCF " + entries + " >>"
+	}
+	return "<< /Filter /Standard /V 5 /R 6 /Length 256 /CF << /StdCF << /CFM /AESV3 /AuthEvent /DocOpen /Length 32 >> >> " +
+		fmt.Sprintf("/StmF /StdCF /StrF /StdCF %s /OE <%x> /UE <%x> /Perms <%x> >>", entries, c.oe, c.ue, c.perms)
+}
+
+// objectKey derives the key of object num (algorithm 1). Revision 6 uses
+// the file key for every object.
+func (c *pdfCrypt) objectKey(num int) []byte {
+	if c.revision == 6 {
+		return c.key
+	}
+	h := md5.New()
+	h.Write(c.key)
+	h.Write([]byte{byte(num), byte(num >> 8), byte(num >> 16), 0, 0})
+	if c.revision == 4 {
+		h.Write([]byte("sAlT"))
+	}
+	return h.Sum(nil)[:min(len(c.key)+5, 16)]
+}
+
+// encrypt encrypts a string or stream of object num. AES output starts
+// with its random IV.
+func (c *pdfCrypt) encrypt(num int, data []byte) []byte {
+	key := c.objectKey(num)
+	if c.revision < 4 {
+		return rc4Crypt(key, data)
+	}
+	padding := aes.BlockSize - len(data)%aes.BlockSize
+	plain := append(append([]byte(nil), data...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	out := append(randomBytes(c.ivs, aes.BlockSize), make([]byte, len(plain))...)
+	block, _ := aes.NewCipher(key)
+	cipher.NewCBCEncrypter(block, out[:aes.BlockSize]).CryptBlocks(out[aes.BlockSize:], plain)
+	return out
+}
+
+// decrypt reverses encrypt
+func (c *pdfCrypt) decrypt(num int, data []byte) ([]byte, error) {
+	key := c.objectKey(num)
+	if c.revision < 4 {
+		return rc4Crypt(key, data), nil
+	}
+	if len(data) < 2*aes.BlockSize || len(data)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("AES data of %d bytes is not whole blocks", len(data))
+	}
+	out := make([]byte, len(data)-aes.BlockSize)
+	block, _ := aes.NewCipher(key)
+	cipher.NewCBCDecrypter(block, data[:aes.BlockSize]).CryptBlocks(out, data[aes.BlockSize:])
+	padding := int(out[len(out)-1])
+	if padding == 0 || padding > aes.BlockSize {
+		return nil, fmt.Errorf("invalid AES padding")
+	}
+	return out[:len(out)-padding], nil
+}
+
+// authenticatePdfCrypt checks password as the user password against the
+// entries of an encryption dictionary and returns the crypt on success
+func authenticatePdfCrypt(method string, password []byte, o, u, ue []byte, p int32, id []byte) (*pdfCrypt, bool) {
+	c := &pdfCrypt{method: method, o: o, u: u, ue: ue, p: p}
+	switch method {
+	case "aes-256":
+		c.revision = 6
+		if len(u) < 48 || len(ue) != 32 || !bytes.Equal(pdfHashR6(password, u[32:40], nil), u[:32]) {
+			return nil, false
+		}
+		key := pdfHashR6(password, u[40:48], nil)
+		block, _ := aes.NewCipher(key)
+		c.key = make([]byte, 32)
+		cipher.NewCBCDecrypter(block, make([]byte, aes.BlockSize)).CryptBlocks(c.key, ue)
+		return c, true
+	case "rc4-40":
+		c.revision = 2
+		c.key = pdfFileKey(password, o, p, id, 2, 5)
+	default:
+		c.revision = 3
+		if method == "aes-128" {
+			c.revision = 4
+		}
+		c.key = pdfFileKey(password, o, p, id, c.revision, 16)
+	}
+	// Revisions 3 and 4 only define the first 16 bytes of /U
+	expected := pdfUserValue(c.key, id, c.revision)
+	if c.revision > 2 {
+		return c, len(u) >= 16 && bytes.Equal(expected[:16], u[:16])
+	}
+	return c, bytes.Equal(expected, u)
+}
+
+// pdfPadPassword pads or truncates a password to 32 bytes
+func pdfPadPassword(password []byte) []byte {
+	return append(append([]byte(nil), password...), pdfPasswordPadding...)[:32]
+}
+
+// pdfOwnerValue computes /O for revisions 2 to 4 (algorithm 3)
+func pdfOwnerValue(owner, user string, revision, keyLen int) []byte {
+	h := md5.Sum(pdfPadPassword([]byte(owner)))
+	if revision >= 3 {
+		for range 50 {
+			h = md5.Sum(h[:])
+		}
+	}
+	key := h[:keyLen]
+	out := rc4Crypt(key, pdfPadPassword([]byte(user)))
+	if revision >= 3 {
+		for i := 1; i <= 19; i++ {
+			out = rc4Crypt(xorBytes(key, byte(i)), out)
+		}
+	}
+	return out
+}
+
+// pdfFileKey computes the file key for revisions 2 to 4 (algorithm 2)
+func pdfFileKey(user, o []byte, p int32, id []byte, revision, keyLen int) []byte {
+	h := md5.New()
+	h.Write(pdfPadPassword(user))
+	h.Write(o)
+	binary.Write(h, binary.LittleEndian, p)
+	h.Write(id)
+	sum := h.Sum(nil)
+	if revision >= 3 {
+		for range 50 {
+			next := md5.Sum(sum[:keyLen])
+			sum = next[:]
+		}
+	}
+	return sum[:keyLen]
+}
+
+// pdfUserValue computes /U for revisions 2 to 4 (algorithms 4 and 5)
+func pdfUserValue(key, id []byte, revision int) []byte {
+	if revision == 2 {
+		return rc4Crypt(key, pdfPasswordPadding)
+	}
+	h := md5.Sum(append(append([]byte(nil), pdfPasswordPadding...), id...))
+	out := rc4Crypt(key, h[:])
+	for i := 1; i <= 19; i++ {
+		out = rc4Crypt(xorBytes(key, byte(i)), out)
+	}
+	return append(out, make([]byte, 16)...) // the rest of /U is arbitrary
+}
+
+// pdfHashR6 is the password hash of revision 6 (algorithm 2.B): SHA-256,
+// then at least 64 rounds of AES-128 and a SHA-2 chosen by the output
+func pdfHashR6(password, salt, udata []byte) []byte {
+	if len(password) > 127 {
+		password = password[:127]
+	}
+	first := sha256.Sum256(bytes.Join([][]byte{password, salt, udata}, nil))
+	k := first[:]
+	e := []byte{0}
+	for round := 0; round < 64 || int(e[len(e)-1]) > round-32; round++ {
+		k1 := bytes.Repeat(bytes.Join([][]byte{password, k, udata}, nil), 64)
+		block, _ := aes.NewCipher(k[:16])
+		e = make([]byte, len(k1))
+		cipher.NewCBCEncrypter(block, k[16:32]).CryptBlocks(e, k1)
+		sum := 0
+		for _, b := range e[:16] {
+			sum += int(b)
+		}
+		switch sum % 3 {
+		case 0:
+			h := sha256.Sum256(e)
+			k = h[:]
+		case 1:
+			h := sha512.Sum384(e)
+			k = h[:]
+		default:
+			h := sha512.Sum512(e)
+			k = h[:]
+		}
+	}
+	return k[:32]
+}
+
+// aesNoPadding encrypts whole blocks with AES-256 in CBC mode and a zero IV
+func aesNoPadding(key, data []byte) []byte {
+	block, _ := aes.NewCipher(key)
+	out := make([]byte, len(data))
+	cipher.NewCBCEncrypter(block, make([]byte, aes.BlockSize)).CryptBlocks(out, data)
+	return out
+}
+
+func rc4Crypt(key, data []byte) []byte {
+	c, _ := rc4.NewCipher(key)
+	out := make([]byte, len(data))
+	c.XORKeyStream(out, data)
+	return out
+}
+
+// xorBytes returns key with every byte XORed with b
+func xorBytes(key []byte, b byte) []byte {
+	out := make([]byte, len(key))
+	for i := range key {
+		out[i] = key[i] ^ b
+	}
+	return out
+}
+
+func randomBytes(r *rand.Rand, n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(r.IntN(256))
+	}
+	return b
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPdfCryptRoundTrip(t *testing.T) {
+	id := []byte("0123456789abcdef")
+	p := pdfPermissionValue([]string{"print", "copy"})
+	plain := [][]byte{nil, []byte("("), []byte("Helv 10 Tf 0 g"), bytes.Repeat([]byte("stream data "), 100)}
+
+	for _, method := range pdfEncryptions[1:] {
+		for _, user := range []string{"", "secret"} {
+			c := newPdfCrypt(method, user, "owner", p, id, newFileRand(9, 1))
+			opened, ok := authenticatePdfCrypt(method, []byte(user), c.o, c.u, c.ue, c.p, id)
+			if !ok {
+				t.Errorf("%s: user password %q was rejected", method, user)
+				continue
+			}
+			if _, ok := authenticatePdfCrypt(method, []byte(user+"x"), c.o, c.u, c.ue, c.p, id); ok {
+				t.Errorf("%s: wrong password %q was accepted", method, user+"x")
+			}
+
+			for _, num := range []int{1, 7, 300} {
+				for _, data := range plain {
+					sealed := c.encrypt(num, data)
+					if len(data) > 4 && bytes.Contains(sealed, data) {
+						t.Errorf("%s: object %d was not encrypted", method, num)
+					}
+					got, err := opened.decrypt(num, sealed)
+					if err != nil || !bytes.Equal(got, data) {
+						t.Errorf("%s: object %d decrypted to %q, %v, want %q", method, num, got, err, data)
+					}
+					// Revision 6 uses the file key for every object
+					if c.revision == 6 || len(data) == 0 {
+						continue
+					}
+					if other, err := opened.decrypt(num+1, sealed); err == nil && bytes.Equal(other, data) {
+						t.Errorf("%s: object %d decrypted with the key of object %d", method, num, num+1)
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+)
+
+// pdfSectionParagraphs is the number of paragraphs in each section when
+// the document has an outline
+const pdfSectionParagraphs = 4
+
+// pdfProducer is the /Producer of the document information, which verify
+// checks to know that strings decrypt
+const pdfProducer = "generator PDF writer"
+
+// pdfInfo is the document information, written both as the /Info
+// dictionary and as XMP metadata
+type pdfInfo struct {
+	title, author, subject, keywords string
+	created, modified                time.Time
+}
+
+func newPdfInfo(r *rand.Rand, title string) pdfInfo {
+	created := randomDate(r).Add(time.Duration(r.IntN(86400)) * time.Second)
+	keywords := make([]string, 3+r.IntN(4))
+	for i := range keywords {
+		keywords[i] = randomWord(r)
+	}
+	return pdfInfo{
+		title:    title,
+		author:   strings.Title(randomWord(r) + " " + randomWord(r)),
+		subject:  strings.TrimSuffix(randomSentence(r), "."),
+		keywords: strings.Join(keywords, ", "),
+		created:  created,
+		modified: created.Add(time.Duration(r.IntN(30*86400)) * time.Second),
+	}
+}
+
+// dict returns the /Info dictionary, to be written as object num
+func (info *pdfInfo) dict(pw *pdfWriter, num int) string {
+	return fmt.Sprintf("<< /Title %s /Author %s /Subject %s /Keywords %s /Creator %s /Producer %s /CreationDate %s /ModDate %s >>",
+		pw.text(num, info.title), pw.text(num, info.author), pw.text(num, info.subject), pw.text(num, info.keywords),
+		pw.text(num, "generator"), pw.text(num, pdfProducer),
+		pw.text(num, pdfDate(info.created)), pw.text(num, pdfDate(info.modified)))
+}
+
+// xmp returns the same information as an XMP packet for the /Metadata
+// stream of the catalog. The values are plain words, so need no escaping.
+func (info *pdfInfo) xmp() []byte {
+	var b strings.Builder
+	b.WriteString("<?xpacket begin=\"\ufeff\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n")
+	b.WriteString("<x:xmpmeta xmlns:x=\"adobe:ns:meta/\">\n")
+	b.WriteString("<rdf:RDF xmlns:rdf=\"http://www.w3.org/1999/02/22-rdf-syntax-ns#\">\n")
+	b.WriteString("<rdf:Description rdf:about=\"\" xmlns:dc=\"http://purl.org/dc/elements/1.1/\" xmlns:xmp=\"http://ns.adobe.com/xap/1.0/\" xmlns:pdf=\"http://ns.adobe.com/pdf/1.3/\">\n")
+	b.WriteString("<dc:format>application/pdf</dc:format>\n")
+	fmt.Fprintf(&b, "<dc:title><rdf:Alt><rdf:li xml:lang=\"x-default\">%s</rdf:li></rdf:Alt></dc:title>\n", info.title)
+	fmt.Fprintf(&b, "<dc:creator><rdf:Seq><rdf:li>%s</rdf:li></rdf:Seq></dc:creator>\n", info.author)
+	fmt.Fprintf(&b, "<dc:description><rdf:Alt><rdf:li xml:lang=\"x-default\">%s</rdf:li></rdf:Alt></dc:description>\n", info.subject)
+	fmt.Fprintf(&b, "<pdf:Keywords>%s</pdf:Keywords>\n<pdf:Producer>%s</pdf:Producer>\n", info.keywords, pdfProducer)
+	fmt.Fprintf(&b, "<xmp:CreatorTool>generator</xmp:CreatorTool>\n<xmp:CreateDate>%s</xmp:CreateDate>\n<xmp:ModifyDate>%s</xmp:ModifyDate>\n",
+		info.created.Format(time.RFC3339), info.modified.Format(time.RFC3339))
+	b.WriteString("</rdf:Description>\n</rdf:RDF>\n</x:xmpmeta>\n<?xpacket end=\"w\"?>")
+	return []byte(b.String())
+}
+
+// write writes the /Info dictionary and the XMP metadata stream
+func (info *pdfInfo) write(pw *pdfWriter, num, xmp int) {
+	pw.object(num, info.dict(pw, num))
+	pw.stream(xmp, " /Type /Metadata /Subtype /XML", info.xmp())
+}
+
+// pdfDate formats a time as a PDF date string
+func pdfDate(t time.Time) string {
+	return "D:" + t.UTC().Format("20060102150405") + "+00'00'"
+}
+
+// pdfHeading is a section heading and where it is: its page object and
+// the top of its line
+type pdfHeading struct {
+	title string
+	page  int
+	top   int
+}
+
+// writeOutline writes the outline root num and one item per heading,
+// pointing at the heading's line
+func writeOutline(pw *pdfWriter, num int, headings []pdfHeading) {
+	items := make([]int, len(headings))
+	for i := range items {
+		items[i] = pw.alloc()
+	}
+	if len(items) == 0 {
+		pw.object(num, "<< /Type /Outlines /Count 0 >>")
+		return
+	}
+	pw.object(num, fmt.Sprintf("<< /Type /Outlines /First %d 0 R /Last %d 0 R /Count %d >>",
+		items[0], items[len(items)-1], len(items)))
+	for i, h := range headings {
+		links := ""
+		if i > 0 {
+			links += fmt.Sprintf(" /Prev %d 0 R", items[i-1])
+		}
+		if i < len(items)-1 {
+			links += fmt.Sprintf(" /Next %d 0 R", items[i+1])
+		}
+		pw.object(items[i], fmt.Sprintf("<< /Title %s /Parent %d 0 R%s /Dest [%d 0 R /XYZ %d %d null] >>",
+			pw.text(items[i], h.title), num, links, h.page, pdfMargin, h.top))
+	}
+}
+
+// writeAnnotations writes the annotations of a page and returns their
+// numbers: a sticky note in the right margin, a web link over the header
+// and, after the first page, a link over the footer to the previous page
+func writeAnnotations(pw *pdfWriter, r *rand.Rand, width, height, prevPage int) []int {
+	note, uri := pw.alloc(), pw.alloc()
+	pw.object(note, fmt.Sprintf("<< /Type /Annot /Subtype /Text /Rect [%d %d %d %d] /Contents %s /T %s /Name /Comment >>",
+		width-40, height-110, width-20, height-90,
+		pw.text(note, randomSentence(r)), pw.text(note, strings.Title(randomWord(r)+" "+randomWord(r)))))
+	pw.object(uri, fmt.Sprintf("<< /Type /Annot /Subtype /Link /Rect [%d %d %d %d] /Border [0 0 0] /A << /S /URI /URI %s >> >>",
+		pdfMargin, height-44, pdfMargin+200, height-30,
+		pw.text(uri, "https://www."+strings.ToLower(randomWord(r))+".com/"+strings.ToLower(randomWord(r)))))
+	if prevPage == 0 {
+		return []int{note, uri}
+	}
+	link := pw.alloc()
+	pw.object(link, fmt.Sprintf("<< /Type /Annot /Subtype /Link /Rect [%d %d %d %d] /Border [0 0 0] /Dest [%d 0 R /Fit] >>",
+		width/2-20, 30, width/2+30, 46, prevPage))
+	return []int{note, uri, link}
+}
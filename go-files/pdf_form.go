@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+)
+
+// Form field flags
+const (
+	pdfFieldRadio = 1<<15 | 1<<14 // radio button that cannot be toggled off
+	pdfFieldCombo = 1 << 17       // dropdown list
+)
+
+// Form page layout in points
+const (
+	pdfFormRow           = 32  // vertical distance between fields
+	pdfFormLeft          = 200 // left edge of the fields
+	pdfFormButton        = 14  // width and height of checkboxes and radio buttons
+	pdfFormChoiceOptions = 4   // options of radio groups and dropdowns
+)
+
+// pdfFormField is the ground truth of a form field, as recorded in the
+// manifest. Value is empty when the form is not filled in.
+type pdfFormField struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"` // text, checkbox, radio or dropdown
+	Value   string   `json:"value"`
+	Options []string `json:"options,omitempty"`
+}
+
+// pdfForm is an interactive form (AcroForm) spread over form pages, one
+// per person, with the fields of the CSV generator's person records
+type pdfForm struct {
+	acroForm int
+	check    int // appearance of a checked button
+	box      int // appearance of an unchecked button
+	fields   []int
+	prefill  bool
+	truth    []pdfFormField
+}
+
+// newPdfForm reserves the AcroForm dictionary and writes the button
+// appearances shared by all checkboxes and radio buttons
+func newPdfForm(pw *pdfWriter, prefill bool) *pdfForm {
+	f := &pdfForm{acroForm: pw.alloc(), check: pw.alloc(), box: pw.alloc(), prefill: prefill}
+	dict := fmt.Sprintf(" /Type /XObject /Subtype /Form /BBox [0 0 %d %d]", pdfFormButton, pdfFormButton)
+	frame := fmt.Sprintf("0 G 1 w 0.5 0.5 %d %d re S", pdfFormButton-1, pdfFormButton-1)
+	pw.stream(f.check, dict, []byte(frame+" 0 g 3 3 8 8 re f"))
+	pw.stream(f.box, dict, []byte(frame))
+	return f
+}
+
+// page writes the fields of form number index, drawing their labels into
+// content, and returns the widget annotations of the page
+func (f *pdfForm) page(pw *pdfWriter, r *rand.Rand, content *bytes.Buffer, index, page, height int) []int {
+	var annots []int
+	row := 0
+	top := func() int { return height - 120 - row*pdfFormRow }
+	label := func(text string) {
+		fmt.Fprintf(content, "BT\n/F1 11 Tf\n%d %d Td\n(%s) Tj\nET\n", pdfMargin, top(), escapePdfString(text))
+	}
+	fmt.Fprintf(content, "BT\n/F2 16 Tf\n%d %d Td\n(Personnel Record %d) Tj\nET\n", pdfMargin, height-85, index)
+
+	// A field's value is only set when the form is filled in
+	value := func(v string) string {
+		if f.prefill {
+			return v
+		}
+		return ""
+	}
+	field := func(name, kind, v string, options []string) (int, string) {
+		num := pw.alloc()
+		f.fields = append(f.fields, num)
+		fullName := fmt.Sprintf("%s_%d", name, index)
+		f.truth = append(f.truth, pdfFormField{Name: fullName, Type: kind, Value: value(v), Options: options})
+		return num, fmt.Sprintf("/T %s /TU %s", pw.text(num, fullName), pw.text(num, strings.Title(strings.ReplaceAll(name, "_", " "))))
+	}
+	widget := func(width, fieldHeight int) string {
+		return fmt.Sprintf("/Type /Annot /Subtype /Widget /F 4 /P %d 0 R /Rect [%d %d %d %d]",
+			page, pdfFormLeft, top()-4, pdfFormLeft+width, top()-4+fieldHeight)
+	}
+
+	for _, name := range []string{"name", "email", "phone", "age", "salary", "date"} {
+		label(strings.Title(name))
+		v := csvColumns[name](r, index)
+		num, entries := field(name, "text", v, nil)
+		filled := ""
+		if f.prefill {
+			filled = " /V " + pw.text(num, v)
+		}
+		pw.object(num, fmt.Sprintf("<< %s /FT /Tx %s%s /DA %s /MK << /BC [0 0 0] >> >>",
+			widget(250, 18), entries, filled, pw.text(num, "/Helv 10 Tf 0 g")))
+		annots = append(annots, num)
+		row++
+	}
+
+	// Checkbox
+	label("Active")
+	active := csvColumns["active"](r, index) == "true"
+	state := "Off"
+	if active && f.prefill {
+		state = "Yes"
+	}
+	num, entries := field("active", "checkbox", map[bool]string{true: "Yes", false: "Off"}[active], nil)
+	pw.object(num, fmt.Sprintf("<< %s /FT /Btn %s /V /%s /AS /%s /AP << /N << /Yes %d 0 R /Off %d 0 R >> >> >>",
+		widget(pdfFormButton, pdfFormButton), entries, state, state, f.check, f.box))
+	annots = append(annots, num)
+	row++
+
+	// Radio group: the person's department among other departments. The
+	// group is a field whose kids are the buttons' widgets.
+	label("Department")
+	department := csvColumns["department"](r, index)
+	options := choiceOptions(r, department)
+	num, entries = field("department", "radio", department, options)
+	selected := "Off"
+	if f.prefill {
+		selected = department
+	}
+	kids := make([]int, len(options))
+	for i, option := range options {
+		kids[i] = pw.alloc()
+		state := "Off"
+		if option == selected {
+			state = option
+		}
+		x := pdfFormLeft + i*90
+		pw.object(kids[i], fmt.Sprintf("<< /Type /Annot /Subtype /Widget /F 4 /P %d 0 R /Parent %d 0 R /Rect [%d %d %d %d] /AS /%s /AP << /N << /%s %d 0 R /Off %d 0 R >> >> >>",
+			page, num, x, top()-4, x+pdfFormButton, top()-4+pdfFormButton, state, option, f.check, f.box))
+		fmt.Fprintf(content, "BT\n/F1 9 Tf\n%d %d Td\n(%s) Tj\nET\n", x+pdfFormButton+4, top(), option)
+	}
+	pw.object(num, fmt.Sprintf("<< /FT /Btn /Ff %d %s /V /%s /Kids [%s] >>", pdfFieldRadio, entries, selected, pdfRefs(kids)))
+	annots = append(annots, kids...)
+	row++
+
+	// Dropdown: the person's city among other cities
+	label("City")
+	city := csvColumns["city"](r, index)
+	options = choiceOptions(r, city)
+	num, entries = field("city", "dropdown", city, options)
+	opts := make([]string, len(options))
+	for i, option := range options {
+		opts[i] = pw.text(num, option)
+	}
+	filled := ""
+	if f.prefill {
+		filled = " /V " + pw.text(num, city)
+	}
+	pw.object(num, fmt.Sprintf("<< %s /FT /Ch /Ff %d %s /Opt [%s]%s /DA %s /MK << /BC [0 0 0] >> >>",
+		widget(250, 18), pdfFieldCombo, entries, strings.Join(opts, " "), filled, pw.text(num, "/Helv 10 Tf 0 g")))
+	annots = append(annots, num)
+	return annots
+}
+
+// write writes the AcroForm dictionary, once all form pages are written.
+// Viewers generate the appearance of text fields and dropdowns. Like
+// every string, the default appearances are encrypted with their object.
+func (f *pdfForm) write(pw *pdfWriter, font int) {
+	pw.object(f.acroForm, fmt.Sprintf("<< /Fields [%s] /NeedAppearances true /DA %s /DR << /Font << /Helv %d 0 R >> >> >>",
+		pdfRefs(f.fields), pw.text(f.acroForm, "/Helv 0 Tf 0 g"), font))
+}
+
+// choiceOptions returns value and other random words, shuffled
+func choiceOptions(r *rand.Rand, value string) []string {
+	options := []string{value}
+	for len(options) < pdfFormChoiceOptions {
+		if word := randomWord(r); !slices.Contains(options, word) {
+			options = append(options, word)
+		}
+	}
+	r.Shuffle(len(options), func(i, j int) {
+		options[i], options[j] = options[j], options[i]
+	})
+	return options
+}
@@ -0,0 +1,378 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// pdfObjectsPerStream is the number of objects collected in each object stream
+const pdfObjectsPerStream = 100
+
+// pdfLayout selects how a PDF file is laid out
+type pdfLayout struct {
+	xrefStreams   bool      // PDF 1.5 cross-reference streams instead of tables
+	objectStreams bool      // objects other than streams are collected in object streams
+	compress      bool      // FlateDecode object and cross-reference streams
+	crypt         *pdfCrypt // encrypts strings and streams, if set
+}
+
+// pdfXrefEntry locates an object: at offset in the file or, when stream is
+// set, as the index-th object of that object stream
+type pdfXrefEntry struct {
+	offset int64
+	stream int
+	index  int
+}
+
+// pdfWriter writes numbered objects in sections, each ending in a
+// cross-reference table or stream and a trailer. Sections after the first
+// are incremental updates.
+type pdfWriter struct {
+	*sizedWriter
+	pdfLayout
+
+	objects  []pdfXrefEntry // indexed by object number; object 0 is the free list head
+	section  []int          // objects written since the last cross-reference section
+	prevXref int64          // offset of the last cross-reference section, 0 before the first
+	encrypt  int            // the encryption dictionary, if encrypted
+	info     int            // the document information dictionary, if any
+
+	// The object stream being filled, if any, with the numbers and
+	// bodies of its objects
+	objStream int
+	objNums   []int
+	objData   bytes.Buffer
+	objStarts []int
+}
+
+func newPdfWriter(w io.Writer, layout pdfLayout) *pdfWriter {
+	pw := &pdfWriter{
+		sizedWriter: newSizedWriter(w, -1),
+		pdfLayout:   layout,
+		objects:     []pdfXrefEntry{{}},
+		section:     []int{0},
+	}
+	// Cross-reference and object streams need PDF 1.5, AES-128 PDF 1.6
+	// and AES-256 PDF 1.7 with Adobe's extension level 8
+	version := "1.4"
+	if layout.xrefStreams || layout.objectStreams {
+		pw.xrefStreams = true
+		version = "1.5"
+	}
+	if layout.crypt != nil && layout.crypt.revision >= 4 {
+		version = map[int]string{4: "1.6", 6: "1.7"}[layout.crypt.revision]
+	}
+	pw.WriteString("%PDF-" + version + "\n")
+	pw.WriteString("%âãÏÓ\n")
+
+	// The encryption dictionary itself is never encrypted, so it is
+	// kept out of object streams
+	if layout.crypt != nil {
+		pw.encrypt = pw.alloc()
+		pw.directObject(pw.encrypt, layout.crypt.dict())
+	}
+	return pw
+}
+
+// alloc reserves the next object number, so an object can be referenced
+// before it is written
+func (pw *pdfWriter) alloc() int {
+	pw.objects = append(pw.objects, pdfXrefEntry{})
+	return len(pw.objects) - 1
+}
+
+// release gives back the object numbers from num on, which must not have
+// been written
+func (pw *pdfWriter) release(num int) {
+	pw.objects = pw.objects[:num]
+}
+
+// size returns the bytes written so far plus an estimate for the objects
+// waiting in the object stream
+func (pw *pdfWriter) size() int64 {
+	if pw.objStream == 0 {
+		return pw.Len()
+	}
+	return pw.Len() + int64(pw.objData.Len()+12*len(pw.objNums)+80)
+}
+
+// object writes object num, or adds it to the current object stream
+func (pw *pdfWriter) object(num int, body string) {
+	if !pw.objectStreams {
+		pw.directObject(num, body)
+		return
+	}
+
+	pw.section = append(pw.section, num)
+	if pw.objStream == 0 {
+		pw.objStream = pw.alloc()
+	}
+	pw.objects[num] = pdfXrefEntry{stream: pw.objStream, index: len(pw.objNums)}
+	pw.objNums = append(pw.objNums, num)
+	pw.objStarts = append(pw.objStarts, pw.objData.Len())
+	pw.objData.WriteString(body)
+	pw.objData.WriteByte('\n')
+	if len(pw.objNums) == pdfObjectsPerStream {
+		pw.flushObjects()
+	}
+}
+
+// directObject writes object num at the current offset
+func (pw *pdfWriter) directObject(num int, body string) {
+	pw.section = append(pw.section, num)
+	pw.objects[num] = pdfXrefEntry{offset: pw.Len()}
+	pw.WriteString(fmt.Sprintf("%d 0 obj\n%s\nendobj\n", num, body))
+}
+
+// stream writes object num as a stream, encrypted if configured. dict
+// holds any dictionary entries besides /Length.
+func (pw *pdfWriter) stream(num int, dict string, data []byte) {
+	pw.section = append(pw.section, num)
+	if pw.crypt != nil {
+		data = pw.crypt.encrypt(num, data)
+	}
+	pw.writeStream(num, dict, data)
+}
+
+func (pw *pdfWriter) writeStream(num int, dict string, data []byte) {
+	pw.objects[num] = pdfXrefEntry{offset: pw.Len()}
+	pw.WriteString(fmt.Sprintf("%d 0 obj\n<< /Length %d%s >>\nstream\n", num, len(data), dict))
+	pw.Write(data)
+	pw.WriteString("\nendstream\nendobj\n")
+}
+
+// text returns s as a string in object num, encrypted if configured.
+// Objects go into object streams, which are encrypted as a whole, so
+// their strings are never encrypted on their own.
+func (pw *pdfWriter) text(num int, s string) string {
+	if pw.crypt == nil || pw.objectStreams {
+		return "(" + escapePdfString(s) + ")"
+	}
+	return fmt.Sprintf("<%x>", pw.crypt.encrypt(num, []byte(s)))
+}
+
+// flushObjects writes the current object stream: pairs of object number
+// and offset, followed by the objects
+func (pw *pdfWriter) flushObjects() {
+	if pw.objStream == 0 {
+		return
+	}
+	pairs := make([]string, len(pw.objNums))
+	for i, num := range pw.objNums {
+		pairs[i] = fmt.Sprintf("%d %d", num, pw.objStarts[i])
+	}
+	header := strings.Join(pairs, " ") + "\n"
+	data := append([]byte(header), pw.objData.Bytes()...)
+
+	dict := fmt.Sprintf(" /Type /ObjStm /N %d /First %d", len(pw.objNums), len(header))
+	if pw.compress {
+		dict += " /Filter /FlateDecode"
+		data = flateEncode(data)
+	}
+	pw.stream(pw.objStream, dict, data)
+
+	pw.objStream = 0
+	pw.objNums, pw.objStarts = pw.objNums[:0], pw.objStarts[:0]
+	pw.objData.Reset()
+}
+
+// endSection writes the cross-reference section for the objects written
+// since the last one, and the trailer. With sizeBytes >= 0, this is the
+// last section and the file is padded to sizeBytes if there is room.
+func (pw *pdfWriter) endSection(root int, sizeBytes int64) (int64, error) {
+	pw.flushObjects()
+	sort.Ints(pw.section)
+	var xrefOffset int64
+	if pw.xrefStreams {
+		xrefOffset = pw.writeXrefStream(root, sizeBytes)
+	} else {
+		xrefOffset = pw.writeXrefTable(root, sizeBytes)
+	}
+	pw.prevXref = xrefOffset
+	pw.section = pw.section[:0]
+	return pw.Len(), pw.err
+}
+
+// trailerEntries are the trailer entries shared by tables and streams
+func (pw *pdfWriter) trailerEntries(root int) string {
+	entries := fmt.Sprintf("/Size %d /Root %d 0 R", len(pw.objects), root)
+	if pw.prevXref > 0 {
+		entries += fmt.Sprintf(" /Prev %d", pw.prevXref)
+	}
+	if pw.info > 0 {
+		entries += fmt.Sprintf(" /Info %d 0 R", pw.info)
+	}
+	if pw.crypt != nil {
+		entries += fmt.Sprintf(" /Encrypt %d 0 R /ID [<%x> <%x>]", pw.encrypt, pw.crypt.id, pw.crypt.id)
+	}
+	return entries
+}
+
+// writeXrefTable writes a classic cross-reference table and trailer. The
+// padding is a comment before the table, which keeps startxref within the
+// last kilobyte where readers look for it.
+func (pw *pdfWriter) writeXrefTable(root int, sizeBytes int64) int64 {
+	subsections := pdfSubsections(pw.section)
+	xrefSize := int64(len("xref\n") + 20*len(pw.section)) // entries are 20 bytes each
+	for _, sub := range subsections {
+		xrefSize += int64(len(fmt.Sprintf("%d %d\n", sub[0], sub[1])))
+	}
+	trailer := func(xrefOffset int64) string {
+		return fmt.Sprintf("trailer\n<< %s >>\nstartxref\n%d\n%%%%EOF\n", pw.trailerEntries(root), xrefOffset)
+	}
+
+	// The trailer grows with the digits of the offset, so the padding
+	// is shrunk until both fit. A byte that cannot be placed because
+	// the offset gained a digit becomes a final newline.
+	padSize := int64(0)
+	if sizeBytes >= 0 {
+		room := sizeBytes - pw.Len() - xrefSize
+		padSize = room - int64(len(trailer(pw.Len())))
+		for padSize > 0 && padSize+int64(len(trailer(pw.Len()+padSize))) > room {
+			padSize--
+		}
+		pw.writePadding(padSize)
+	}
+
+	xrefOffset := pw.Len()
+	pw.WriteString("xref\n")
+	for _, sub := range subsections {
+		pw.WriteString(fmt.Sprintf("%d %d\n", sub[0], sub[1]))
+		for num := sub[0]; num < sub[0]+sub[1]; num++ {
+			if num == 0 {
+				pw.WriteString("0000000000 65535 f \n")
+			} else {
+				pw.WriteString(fmt.Sprintf("%010d 00000 n \n", pw.objects[num].offset))
+			}
+		}
+	}
+	pw.WriteString(trailer(xrefOffset))
+	if padSize > 0 && pw.Len() < sizeBytes {
+		pw.WriteString("\n")
+	}
+	return xrefOffset
+}
+
+// writeXrefStream writes a cross-reference stream, which is also the
+// trailer. Each entry is a type (0 free, 1 at an offset, 2 in an object
+// stream) and two big-endian fields. Compressed streams use the PNG Up
+// predictor like most PDF writers. Cross-reference streams are never
+// encrypted. The padding is a comment between the stream and startxref,
+// since the stream depends on its own offset.
+func (pw *pdfWriter) writeXrefStream(root int, sizeBytes int64) int64 {
+	num := pw.alloc()
+	pw.section = append(pw.section, num)
+	xrefOffset := pw.Len()
+	pw.objects[num] = pdfXrefEntry{offset: xrefOffset}
+
+	width := 1
+	for limit := max(xrefOffset, int64(len(pw.objects))); limit >= 1<<(8*width); {
+		width++
+	}
+	columns := 1 + width + 2
+	var rows bytes.Buffer
+	row := make([]byte, columns)
+	prev := make([]byte, columns)
+	field := make([]byte, 8)
+	for _, n := range pw.section {
+		entry := pw.objects[n]
+		switch {
+		case n == 0:
+			row[0] = 0
+			binary.BigEndian.PutUint64(field, 0)
+			binary.BigEndian.PutUint16(row[1+width:], 65535)
+		case entry.stream > 0:
+			row[0] = 2
+			binary.BigEndian.PutUint64(field, uint64(entry.stream))
+			binary.BigEndian.PutUint16(row[1+width:], uint16(entry.index))
+		default:
+			row[0] = 1
+			binary.BigEndian.PutUint64(field, uint64(entry.offset))
+			binary.BigEndian.PutUint16(row[1+width:], 0)
+		}
+		copy(row[1:1+width], field[8-width:])
+		if pw.compress {
+			rows.WriteByte(2) // Up: each byte is stored as the difference to the byte above
+			for i := range row {
+				rows.WriteByte(row[i] - prev[i])
+			}
+			copy(prev, row)
+		} else {
+			rows.Write(row)
+		}
+	}
+
+	var index []string
+	for _, sub := range pdfSubsections(pw.section) {
+		index = append(index, fmt.Sprintf("%d %d", sub[0], sub[1]))
+	}
+	dict := fmt.Sprintf(" /Type /XRef %s /W [1 %d 2] /Index [%s]", pw.trailerEntries(root), width, strings.Join(index, " "))
+	data := rows.Bytes()
+	if pw.compress {
+		dict += fmt.Sprintf(" /Filter /FlateDecode /DecodeParms << /Predictor 12 /Columns %d >>", columns)
+		data = flateEncode(data)
+	}
+	pw.writeStream(num, dict, data)
+
+	tail := fmt.Sprintf("startxref\n%d\n%%%%EOF\n", xrefOffset)
+	if sizeBytes >= 0 {
+		pw.writePadding(sizeBytes - pw.Len() - int64(len(tail)))
+	}
+	pw.WriteString(tail)
+	return xrefOffset
+}
+
+// writePadding writes a comment line of n bytes, or a newline for n == 1
+func (pw *pdfWriter) writePadding(n int64) {
+	if n >= 2 {
+		pw.WriteString("%")
+		writeRepeated(pw, ' ', n-2)
+		pw.WriteString("\n")
+	} else if n == 1 {
+		pw.WriteString("\n")
+	}
+}
+
+// pdfSubsections splits sorted object numbers into runs of consecutive
+// numbers, as start and count
+func pdfSubsections(nums []int) [][2]int {
+	var subs [][2]int
+	for _, num := range nums {
+		if n := len(subs); n > 0 && subs[n-1][0]+subs[n-1][1] == num {
+			subs[n-1][1]++
+		} else {
+			subs = append(subs, [2]int{num, 1})
+		}
+	}
+	return subs
+}
+
+// pdfRefs formats object numbers as a list of indirect references
+func pdfRefs(nums []int) string {
+	refs := make([]string, len(nums))
+	for i, num := range nums {
+		refs[i] = fmt.Sprintf("%d 0 R", num)
+	}
+	return strings.Join(refs, " ")
+}
+
+// flateWriters keeps zlib writers for reuse, since every page has its own
+// stream and a new writer is expensive to set up
+var flateWriters = sync.Pool{New: func() any { return zlib.NewWriter(nil) }}
+
+// flateEncode compresses data for the FlateDecode filter
+func flateEncode(data []byte) []byte {
+	var buf bytes.Buffer
+	zw := flateWriters.Get().(*zlib.Writer)
+	zw.Reset(&buf)
+	zw.Write(data)
+	zw.Close()
+	flateWriters.Put(zw)
+	return buf.Bytes()
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/adler32"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math/rand/v2"
+)
+
+// pngPaddings lists the ways PngGenerator can reach its target size. The
+// first four add an ancillary chunk; the others raise the entropy of the
+// image itself and add a tEXt chunk only for the few bytes left over.
+var pngPaddings = []string{"text", "ztxt", "itxt", "private", "noise", "dither", "larger"}
+
+// pngPrivateChunk is the type of the private padding chunk: ancillary,
+// private and safe to copy, so decoders must skip it
+const pngPrivateChunk = "gnPd"
+
+// pngPaddingKeyword is the keyword of the tEXt, zTXt and iTXt chunks
+const pngPaddingKeyword = "Comment"
+
+// pngMaxSide bounds the dimensions tried by the "larger" strategy
+const pngMaxSide = 4096
+
+func (g *PngGenerator) padding() string {
+	if g.Padding == "" {
+		return "text"
+	}
+	return g.Padding
+}
+
+// pngChunkOverhead is the size of the smallest padding chunk of a strategy
+func pngChunkOverhead(padding string) int {
+	const base = 12 // length, type and CRC
+	keyword := len(pngPaddingKeyword) + 1
+	switch padding {
+	case "ztxt":
+		return base + keyword + 1 + 2 + 5 + 4 // method, zlib header, one stored block, Adler-32
+	case "itxt":
+		return base + keyword + 2 + 1 + 1 // flag, method, empty language and translated keyword
+	case "private":
+		return base
+	}
+	return base + keyword
+}
+
+// pngPaddingChunk builds a chunk of exactly size bytes for the strategy, or
+// returns nil if size cannot hold one
+func pngPaddingChunk(r *rand.Rand, padding string, size int) []byte {
+	overhead := pngChunkOverhead(padding)
+	if size < overhead {
+		return nil
+	}
+	keyword := append([]byte(pngPaddingKeyword), 0)
+
+	switch padding {
+	case "ztxt":
+		data := append(keyword, 0) // compression method 0 (zlib)
+		n, blocks := storedZlibLayout(size - overhead + 11)
+		return pngChunk("zTXt", append(data, storedZlib(randomPadding(r, n), blocks)...))
+	case "itxt":
+		data := append(keyword, 0, 0, 0, 0) // uncompressed, no language or translated keyword
+		return pngChunk("iTXt", append(data, randomPadding(r, size-overhead)...))
+	case "private":
+		data := make([]byte, size-overhead)
+		for i := range data {
+			data[i] = byte(r.IntN(256))
+		}
+		return pngChunk(pngPrivateChunk, data)
+	}
+	// tEXt chunk format: keyword + null byte + text
+	return pngChunk("tEXt", append(keyword, randomPadding(r, size-overhead)...))
+}
+
+// randomPadding returns n random characters from charset
+func randomPadding(r *rand.Rand, n int) []byte {
+	padding := make([]byte, n)
+	for i := range padding {
+		padding[i] = charset[r.IntN(len(charset))]
+	}
+	return padding
+}
+
+// pngChunk encodes a chunk: length, type, data and a CRC over type and data
+func pngChunk(chunkType string, data []byte) []byte {
+	var chunk bytes.Buffer
+	binary.Write(&chunk, binary.BigEndian, uint32(len(data)))
+	chunk.WriteString(chunkType)
+	chunk.Write(data)
+	binary.Write(&chunk, binary.BigEndian, crc32.ChecksumIEEE(chunk.Bytes()[4:]))
+	return chunk.Bytes()
+}
+
+// insertPngChunk inserts chunk before the IEND chunk (the last 12 bytes)
+func insertPngChunk(pngData, chunk []byte) []byte {
+	if len(pngData) < 12 || len(chunk) == 0 {
+		return pngData
+	}
+	iendPos := len(pngData) - 12
+	var result bytes.Buffer
+	result.Write(pngData[:iendPos])
+	result.Write(chunk)
+	result.Write(pngData[iendPos:])
+	return result.Bytes()
+}
+
+// storedZlibLayout returns how many bytes of data, in how many stored
+// blocks, make a zlib stream of exactly size bytes. Each block costs 5
+// bytes on top of the 2 byte header and 4 byte checksum. Blocks hold up to
+// 65535 bytes but may be smaller, so every size of at least 11 bytes can
+// be reached.
+func storedZlibLayout(size int) (int, int) {
+	payload := size - 6
+	blocks := max(1, (payload+65539)/65540)
+	return payload - 5*blocks, blocks
+}
+
+// storedZlib wraps data in a zlib stream of uncompressed deflate blocks
+func storedZlib(data []byte, blocks int) []byte {
+	var buf bytes.Buffer
+	buf.Write([]byte{0x78, 0x01})
+	for i := 0; i < blocks; i++ {
+		block := data[len(data)*i/blocks : len(data)*(i+1)/blocks]
+		final := byte(0)
+		if i == blocks-1 {
+			final = 1
+		}
+		buf.WriteByte(final)
+		binary.Write(&buf, binary.LittleEndian, uint16(len(block)))
+		binary.Write(&buf, binary.LittleEndian, ^uint16(len(block)))
+		buf.Write(block)
+	}
+	binary.Write(&buf, binary.BigEndian, adler32.Checksum(data))
+	return buf.Bytes()
+}
+
+// drawPadded draws the image for the entropy strategies so that it encodes
+// to as close to sizeBytes as possible while leaving room for a tEXt chunk.
+// The other strategies get the plain image.
+func (g *PngGenerator) drawPadded(r *rand.Rand, animal AnimalPattern, bg color.RGBA, width, height, sizeBytes int) (image.Image, error) {
+	padding := g.padding()
+	if padding != "noise" && padding != "dither" && padding != "larger" {
+		return drawAnimal(animal, bg, width, height), nil
+	}
+
+	// Every attempt replays the same noise, so more noise means a larger file
+	seed := r.Uint64()
+	budget := sizeBytes - pngChunkOverhead("text")
+	draw := func(level int) *image.RGBA {
+		noise := rand.New(rand.NewPCG(seed, 0))
+		switch padding {
+		case "noise":
+			// level is the share of random pixels, in 1/1024ths
+			img := drawAnimal(animal, bg, width, height)
+			for i := 0; i < len(img.Pix); i += 4 {
+				c := noise.Uint32()
+				if noise.IntN(1024) < level {
+					img.Pix[i], img.Pix[i+1], img.Pix[i+2] = byte(c), byte(c>>8), byte(c>>16)
+				}
+			}
+			return img
+		case "dither":
+			// level is the share of pixels nudged by up to 2 per channel
+			img := drawAnimal(animal, bg, width, height)
+			for i := 0; i < len(img.Pix); i += 4 {
+				delta := noise.Uint32()
+				if noise.IntN(1024) < level {
+					for c := 0; c < 3; c++ {
+						d := int(delta>>(8*c)&0xff)%5 - 2
+						img.Pix[i+c] = byte(min(255, max(0, int(img.Pix[i+c])+d)))
+					}
+				}
+			}
+			return img
+		}
+		// larger: level is the scale factor in 1/16ths
+		return drawAnimal(animal, bg, width*level/16, height*level/16)
+	}
+
+	lo, hi := 0, 1024
+	if padding == "larger" {
+		lo, hi = 16, 16*pngMaxSide/max(width, height)
+	}
+
+	// Binary search for the largest level whose encoding fits the budget,
+	// unless even the largest one fits
+	best := draw(hi)
+	if size, err := pngEncodedSize(best); err != nil || size <= budget {
+		return best, err
+	}
+	best = draw(lo)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		img := draw(mid)
+		size, err := pngEncodedSize(img)
+		if err != nil {
+			return nil, err
+		}
+		if size <= budget {
+			lo, best = mid, img
+		} else {
+			hi = mid - 1
+		}
+	}
+	return best, nil
+}
+
+// pngEncodedSize returns the size of img encoded with default settings
+func pngEncodedSize(img image.Image) (int, error) {
+	sw := newSizedWriter(io.Discard, -1)
+	err := png.Encode(sw, img)
+	return int(sw.Len()), err
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image/png"
+	"testing"
+)
+
+func TestPngPaddingExact(t *testing.T) {
+	for _, padding := range pngPaddings {
+		for _, size := range []int64{20000, 50001} {
+			g := &PngGenerator{Padding: padding}
+			var buf bytes.Buffer
+			n, err := g.GenerateExactTo(&buf, newFileRand(3, int(size)), size)
+			if err != nil {
+				t.Fatalf("%s, %d bytes: %v", padding, size, err)
+			}
+			if n != size || int64(buf.Len()) != size {
+				t.Errorf("%s, %d bytes: reported %d, wrote %d", padding, size, n, buf.Len())
+			}
+			if err := checkPngChunks(buf.Bytes()); err != "" {
+				t.Errorf("%s, %d bytes: %s", padding, size, err)
+			}
+			if _, err := png.Decode(&buf); err != nil {
+				t.Errorf("%s, %d bytes: %v", padding, size, err)
+			}
+		}
+	}
+}
+
+// checkPngChunks walks the chunks of a PNG, checking every CRC and that
+// IEND ends the file. It returns a description of the first problem.
+func checkPngChunks(data []byte) string {
+	if !bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")) {
+		return "no PNG signature"
+	}
+	for pos := 8; pos < len(data); {
+		if len(data)-pos < 12 {
+			return "truncated chunk header"
+		}
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		end := pos + 8 + length
+		if length > len(data) || end+4 > len(data) {
+			return "chunk runs past the end of the file"
+		}
+		typ := string(data[pos+4 : pos+8])
+		if crc := binary.BigEndian.Uint32(data[end:]); crc != crc32.ChecksumIEEE(data[pos+4:end]) {
+			return typ + " chunk has a bad CRC"
+		}
+		pos = end + 4
+		if typ == "IEND" {
+			if pos != len(data) {
+				return "data after IEND"
+			}
+			return ""
+		}
+	}
+	return "no IEND chunk"
+}
@@ -0,0 +1,438 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Config holds the settings for a generation run
+type Config struct {
+	NumFiles   int
+	Extensions []ExtensionConfig
+	Seed       uint64
+	Only       int // generate only this file index when > 0
+	Workers    int
+	ExactSize  bool
+	OutDir     string
+	Names      *nameTemplate
+	Force      bool         // overwrite existing files
+	Tree       *TreeOptions // nil writes every file into OutDir
+	Layout     *treeLayout  // built from Tree by execute
+	TotalSize  int64        // byte budget; NumFiles is then a cap (0 for none)
+	Manifests  []string     // manifest files to write, .jsonl or .csv
+
+	plan  []int   // extension index per file, set when extensions have counts
+	sizes []int64 // size per file, set by planBudget
+}
+
+// ExtensionConfig holds the settings for one extension of a run
+type ExtensionConfig struct {
+	Name    string
+	Count   int // fixed number of files, 0 to pick by weight only
+	Weight  int
+	Sizes   sizeDist
+	Options json.RawMessage // generator options, see configureGenerator
+}
+
+// uniformExtensions gives every extension the same weight and sizes
+func uniformExtensions(names []string, sizes sizeDist) []ExtensionConfig {
+	exts := make([]ExtensionConfig, len(names))
+	for i, name := range names {
+		exts[i] = ExtensionConfig{Name: name, Weight: 1, Sizes: sizes}
+	}
+	return exts
+}
+
+// sizeSummary describes the file sizes of the run
+func (cfg *Config) sizeSummary() string {
+	summary := cfg.Extensions[0].Sizes.String()
+	lo, hi := cfg.Extensions[0].Sizes.Range()
+	shared := true
+	for _, ext := range cfg.Extensions[1:] {
+		extLo, extHi := ext.Sizes.Range()
+		lo, hi = min(lo, extLo), max(hi, extHi)
+		shared = shared && ext.Sizes.String() == summary
+	}
+	if shared {
+		return summary
+	}
+	return fmt.Sprintf("between %s and %s", formatSize(lo), formatSize(hi))
+}
+
+// fileResult describes one generated file
+type fileResult struct {
+	Index     int
+	Filename  string
+	Extension string
+	Generator string
+	Requested int64 // requested size in bytes
+	Size      int64 // bytes actually written
+	SHA256    string
+	Metadata  map[string]any
+}
+
+// generateFile generates and writes the file with the given index. names
+// holds the names of the run's files so far.
+func generateFile(cfg *Config, index int, names *nameRegistry) (fileResult, error) {
+	// Every random choice for this file comes from its own stream
+	r := newFileRand(cfg.Seed, index)
+	ext, size := pickFile(cfg, r, index)
+	if cfg.sizes != nil {
+		size = cfg.sizes[index-1]
+	}
+
+	generator := NewGenerator(ext.Name)
+	if err := configureGenerator(generator, ext.Options); err != nil {
+		return fileResult{}, err
+	}
+	res := fileResult{
+		Index:     index,
+		Extension: generator.Extension(),
+		Generator: strings.TrimPrefix(fmt.Sprintf("%T", generator), "*main."),
+		Requested: size,
+	}
+	dir := ""
+	if cfg.Layout != nil {
+		dir = cfg.Layout.Dir(index)
+	}
+
+	// Refuse to overwrite before generating when the name is already known
+	if !cfg.Force && cfg.Names.static() {
+		name, err := cfg.Names.Render(nameFields{Index: index, Ext: res.Extension})
+		if err != nil {
+			return res, err
+		}
+		if err := checkNotExists(filepath.Join(cfg.OutDir, dir, name)); err != nil {
+			return res, err
+		}
+	}
+
+	var generate func(w io.Writer) (int64, error)
+	if eg, ok := generator.(ExactGenerator); ok && cfg.ExactSize {
+		generate = func(w io.Writer) (int64, error) {
+			return eg.GenerateExactTo(w, r, res.Requested)
+		}
+	} else if sg, ok := generator.(StreamGenerator); ok {
+		// Stream straight to disk when the generator supports it
+		generate = func(w io.Writer) (int64, error) {
+			return sg.GenerateTo(w, r, res.Requested)
+		}
+	} else {
+		generate = func(w io.Writer) (int64, error) {
+			content, err := generator.Generate(r, int(res.Requested))
+			if err != nil {
+				return 0, err
+			}
+			n, err := w.Write(content)
+			return int64(n), err
+		}
+	}
+
+	// Content goes to a temporary file first, since the final name may
+	// depend on what was generated
+	tmpName, size, sum, err := writeStreamed(cfg.OutDir, generate)
+	if err != nil {
+		return res, err
+	}
+	res.Size, res.SHA256 = size, sum
+
+	fields := nameFields{Index: index, Ext: res.Extension, Size: res.Size, Rand: r}
+	if mp, ok := generator.(MetadataProvider); ok {
+		res.Metadata = mp.Metadata()
+		fields.Animal, _ = res.Metadata["animal"].(string)
+	}
+	name, err := cfg.Names.Render(fields)
+	if err != nil {
+		os.Remove(tmpName)
+		return res, err
+	}
+	res.Filename = filepath.Join(cfg.OutDir, dir, name)
+
+	err = names.claim(res.Filename, index)
+	if err == nil {
+		err = commitFile(tmpName, res.Filename, cfg.Force)
+	}
+	if err != nil {
+		os.Remove(tmpName)
+		return res, err
+	}
+	return res, nil
+}
+
+// pickFile draws the extension and size of a file. It is the first use of
+// the file's stream, so the budget planner can replay it.
+func pickFile(cfg *Config, r *rand.Rand, index int) (ExtensionConfig, int64) {
+	// Pick the extension from the plan, or a random one by weight
+	var ext ExtensionConfig
+	if cfg.plan != nil {
+		ext = cfg.Extensions[cfg.plan[index-1]]
+	} else {
+		ext = cfg.Extensions[pickWeighted(r, cfg.Extensions)]
+	}
+	// Pick the size from the extension's distribution
+	return ext, ext.Sizes.Pick(r)
+}
+
+// writeStreamed creates a temporary file in dir and lets generate write
+// into it, so the content never has to be held in memory. It returns the
+// name of the temporary file, and the size and hex SHA-256 of the
+// content. The temporary file is removed when anything fails.
+func writeStreamed(dir string, generate func(w io.Writer) (int64, error)) (string, int64, string, error) {
+	f, err := os.CreateTemp(dir, ".generator-*.tmp")
+	if err != nil {
+		return "", 0, "", fmt.Errorf("creating file: %w", err)
+	}
+	n, sum, err := writeHashed(f, generate)
+	if closeErr := f.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("writing file: %w", closeErr)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", n, "", err
+	}
+	return f.Name(), n, sum, nil
+}
+
+// writeHashed lets generate write into f and returns the size and hex
+// SHA-256 of the content
+func writeHashed(f *os.File, generate func(w io.Writer) (int64, error)) (int64, string, error) {
+	// CreateTemp makes the file private, but generated files are meant to
+	// be shared
+	if err := f.Chmod(0644); err != nil {
+		return 0, "", fmt.Errorf("creating file: %w", err)
+	}
+	w := bufio.NewWriterSize(f, 256*1024)
+	h := sha256.New()
+	n, err := generate(io.MultiWriter(w, h))
+	if err != nil {
+		return n, "", fmt.Errorf("generating content: %w", err)
+	}
+	if err := w.Flush(); err != nil {
+		return n, "", fmt.Errorf("writing file: %w", err)
+	}
+	return n, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// checkNotExists fails when filename already exists
+func checkNotExists(filename string) error {
+	if _, err := os.Stat(filename); err == nil {
+		return fmt.Errorf("%s already exists (use --force to overwrite)", filename)
+	}
+	return nil
+}
+
+// nameRegistry holds the names of a run's files, so that a name template
+// that gives two files the same name fails even with --force rather than
+// overwriting the first
+type nameRegistry struct {
+	mu    sync.Mutex
+	names map[string]int // file index by name
+}
+
+// claim records filename as the name of the file with the given index,
+// unless another file has it
+func (n *nameRegistry) claim(filename string, index int) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if other, ok := n.names[filename]; ok && other != index {
+		return fmt.Errorf("files %d and %d are both named %s (the name template must give every file its own name)",
+			min(index, other), max(index, other), filename)
+	}
+	if n.names == nil {
+		n.names = make(map[string]int)
+	}
+	n.names[filename] = index
+	return nil
+}
+
+// checkStaticNames renders the names of all files of the run when they
+// depend only on the index and extension, and fails before anything is
+// generated if two files would have the same name
+func checkStaticNames(cfg *Config) error {
+	var names nameRegistry
+	for i := 1; i <= cfg.NumFiles; i++ {
+		ext, _ := pickFile(cfg, newFileRand(cfg.Seed, i), i)
+		name, err := cfg.Names.Render(nameFields{Index: i, Ext: ext.Name})
+		if err != nil {
+			return err
+		}
+		dir := ""
+		if cfg.Layout != nil {
+			dir = cfg.Layout.Dir(i)
+		}
+		if err := names.claim(filepath.Join(cfg.OutDir, dir, name), i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renameMu serialises the existence check and rename in commitFile
+var renameMu sync.Mutex
+
+// commitFile moves a finished temporary file to its final name, creating
+// directories as needed. An existing file is only replaced when force is set.
+func commitFile(tmpName, filename string, force bool) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return fmt.Errorf("creating directory: %w", err)
+	}
+
+	renameMu.Lock()
+	defer renameMu.Unlock()
+	if !force {
+		if err := checkNotExists(filename); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(tmpName, filename); err != nil {
+		return fmt.Errorf("renaming file: %w", err)
+	}
+	return nil
+}
+
+// run generates all files of the run using cfg.Workers goroutines. Each
+// worker holds at most one file in memory, and since every file's content
+// depends only on (seed, index) the output matches a single-threaded run.
+// It returns the number of files and manifests that failed.
+func run(cfg *Config) int {
+	workers := max(1, cfg.Workers)
+	indices := make(chan int, workers)
+
+	var (
+		wg         sync.WaitGroup
+		totalFiles atomic.Int64
+		totalBytes atomic.Int64
+		failed     atomic.Int64
+		report     exactReport
+		volume     volumeReport
+		manifest   manifest
+		names      nameRegistry
+	)
+
+	start := time.Now()
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				res, err := generateFile(cfg, i, &names)
+				if err != nil {
+					fmt.Printf("Error for file %d: %v\n", i, err)
+					failed.Add(1)
+					continue
+				}
+				totalFiles.Add(1)
+				totalBytes.Add(res.Size)
+				if cfg.ExactSize {
+					report.Add(res.Extension, res.Filename, res.Requested, res.Size)
+				}
+				if cfg.TotalSize > 0 {
+					volume.Add(res.Extension, res.Size)
+				}
+				if len(cfg.Manifests) > 0 {
+					manifest.Add(cfg, res)
+				}
+				fmt.Printf("Created %s (size: %s)\n", res.Filename, formatSize(res.Size))
+			}
+		}()
+	}
+
+	for i := 1; i <= cfg.NumFiles; i++ {
+		if cfg.Only > 0 && i != cfg.Only {
+			continue
+		}
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	printThroughput(totalFiles.Load(), totalBytes.Load(), time.Since(start), workers)
+	if cfg.ExactSize {
+		report.Print()
+	}
+	if cfg.TotalSize > 0 {
+		volume.Print(cfg.TotalSize)
+	}
+	for _, path := range cfg.Manifests {
+		if err := manifest.Write(path); err != nil {
+			fmt.Printf("Error writing manifest %s: %v\n", path, err)
+			failed.Add(1)
+			continue
+		}
+		fmt.Printf("Manifest: %s\n", path)
+	}
+	return int(failed.Load())
+}
+
+// execute prepares the output directory and tree, then runs the generation.
+// It fails when any file or manifest could not be written.
+func execute(cfg *Config) error {
+	if err := os.MkdirAll(cfg.OutDir, 0755); err != nil {
+		return fmt.Errorf("cannot create output directory '%s': %w", cfg.OutDir, err)
+	}
+
+	// The budget decides the number of files, which the tree depends on
+	if cfg.TotalSize > 0 {
+		if err := planBudget(cfg); err != nil {
+			return err
+		}
+	}
+
+	if cfg.Tree != nil {
+		layout, err := newTreeLayout(cfg.Seed, *cfg.Tree, cfg.NumFiles)
+		if err == nil {
+			err = layout.Create(cfg.OutDir)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid tree options: %w", err)
+		}
+		cfg.Layout = layout
+		fmt.Printf("Tree: %s\n", layout.Summary())
+	}
+
+	if cfg.Names.static() && cfg.Only == 0 {
+		if err := checkStaticNames(cfg); err != nil {
+			return err
+		}
+	}
+
+	// Generate files with random extensions
+	if cfg.TotalSize > 0 {
+		fmt.Printf("Generating %s in %d files (sizes: %s, seed: %d)...\n",
+			formatSize(cfg.TotalSize), cfg.NumFiles, cfg.sizeSummary(), cfg.Seed)
+	} else {
+		fmt.Printf("Generating %d files (sizes: %s, seed: %d)...\n",
+			cfg.NumFiles, cfg.sizeSummary(), cfg.Seed)
+	}
+
+	failed := run(cfg)
+
+	fmt.Println("\nFile generation completed!")
+	if failed > 0 {
+		return fmt.Errorf("%d file(s) could not be written", failed)
+	}
+	return nil
+}
+
+// printThroughput reports aggregate statistics for the run
+func printThroughput(files, bytes int64, elapsed time.Duration, workers int) {
+	seconds := elapsed.Seconds()
+	if seconds <= 0 {
+		seconds = 1e-9
+	}
+	fmt.Printf("\nGenerated %d files (%.2f MB) in %s with %d worker(s)\n",
+		files, float64(bytes)/(1024*1024), elapsed.Round(time.Millisecond), workers)
+	fmt.Printf("Throughput: %.1f files/s, %.2f MB/s\n",
+		float64(files)/seconds, float64(bytes)/(1024*1024)/seconds)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testConfig returns a run of numFiles files of the comma-separated
+// extensions with uniform sizes, into a temporary directory
+func testConfig(t *testing.T, exts string, numFiles int, minSize, maxSize int64) *Config {
+	t.Helper()
+	sizes, err := newSizeDist("uniform", minSize, maxSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names, err := parseNameTemplate(defaultNameTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Config{
+		NumFiles:   numFiles,
+		Extensions: uniformExtensions(strings.Split(exts, ","), sizes),
+		Seed:       42,
+		Workers:    1,
+		OutDir:     t.TempDir(),
+		Names:      names,
+	}
+}
+
+// dirHashes returns the hex SHA-256 of every file under dir, by path
+// relative to dir
+func dirHashes(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	hashes := make(map[string]string)
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, p)
+		sum := sha256.Sum256(data)
+		hashes[filepath.ToSlash(rel)] = hex.EncodeToString(sum[:])
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hashes
+}
+
+func TestWorkersMatchSingleThreaded(t *testing.T) {
+	single := testConfig(t, "txt,csv,json,pdf,docx,png", 24, 1024, 32*1024)
+	if err := execute(single); err != nil {
+		t.Fatal(err)
+	}
+	parallel := testConfig(t, "txt,csv,json,pdf,docx,png", 24, 1024, 32*1024)
+	parallel.Workers = 4
+	if err := execute(parallel); err != nil {
+		t.Fatal(err)
+	}
+
+	want, got := dirHashes(t, single.OutDir), dirHashes(t, parallel.OutDir)
+	if len(want) != 24 {
+		t.Fatalf("single-threaded run wrote %d files, want 24", len(want))
+	}
+	for name, hash := range want {
+		if got[name] != hash {
+			t.Errorf("%s differs between 1 and 4 workers", name)
+		}
+	}
+}
+
+func TestExecuteFailsWhenFilesFail(t *testing.T) {
+	cfg := testConfig(t, "txt", 3, 1024, 1024)
+	if err := execute(cfg); err != nil {
+		t.Fatal(err)
+	}
+	// The files exist now, so every one of them fails without --force
+	err := execute(cfg)
+	if err == nil || !strings.Contains(err.Error(), "3 file(s)") {
+		t.Errorf("second run returned %v, want an error for 3 files", err)
+	}
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+)
+
+// sizeUnits are the units accepted in sizes, in bytes, matched in upper
+// case. Every unit is binary, so KB and KiB are the same.
+var sizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+	{"GIB", 1 << 30}, {"MIB", 1 << 20}, {"KIB", 1 << 10},
+	{"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10},
+	{"B", 1},
+}
+
+// parseSize parses a size such as "512B", "64KB", "1.5MB" or "2GB".
+// Numbers without a unit are in KB, as the size argument always was.
+func parseSize(s string) (int64, error) {
+	text := strings.ToUpper(strings.TrimSpace(s))
+	unit := int64(1024)
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(text, u.suffix) {
+			text = strings.TrimSpace(strings.TrimSuffix(text, u.suffix))
+			unit = u.bytes
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(text, 64)
+	if err != nil || !(n >= 0) || math.IsInf(n, 0) || n*float64(unit) > math.MaxInt64/2 {
+		return 0, fmt.Errorf("invalid size %q (expected e.g. 512B, 64KB, 1.5MB, 2GB)", s)
+	}
+	return int64(math.Round(n * float64(unit))), nil
+}
+
+// formatSize formats a byte count with the largest unit that fits
+func formatSize(n int64) string {
+	for _, u := range sizeUnits[:3] {
+		if n >= u.bytes {
+			rounded := math.Round(float64(n)/float64(u.bytes)*10) / 10
+			return strconv.FormatFloat(rounded, 'f', -1, 64) + " " + u.suffix
+		}
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+// byteSize is a size in a spec file, given as a number of KB or a string
+// with a unit
+type byteSize int64
+
+// UnmarshalJSON accepts 64 (KB) or "64KB", "1.5MB" and so on
+func (b *byteSize) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var n json.Number
+		if err := json.Unmarshal(data, &n); err != nil {
+			return fmt.Errorf("invalid size %s", data)
+		}
+		s = n.String()
+	}
+	n, err := parseSize(s)
+	if err != nil {
+		return err
+	}
+	*b = byteSize(n)
+	return nil
+}
+
+// sizeDist picks file sizes in bytes. Every distribution is bounded by a
+// minimum and maximum size.
+type sizeDist interface {
+	Pick(r *rand.Rand) int64
+	Range() (int64, int64)
+	String() string
+}
+
+// newSizeDist parses a distribution such as "uniform", "lognormal:median=64KB,sigma=1.5",
+// "pareto:alpha=1.2", "buckets:4KB=50,1MB=30" or "histogram:1KB-10KB=70,10KB-1MB=30"
+// and bounds it to [minSize, maxSize]
+func newSizeDist(spec string, minSize, maxSize int64) (sizeDist, error) {
+	if minSize < 1 || maxSize < minSize {
+		return nil, fmt.Errorf("invalid size range %s to %s", formatSize(minSize), formatSize(maxSize))
+	}
+	name, params, _ := strings.Cut(strings.TrimSpace(spec), ":")
+	bounds := sizeBounds{min: minSize, max: maxSize}
+
+	switch strings.ToLower(name) {
+	case "", "uniform":
+		if params != "" {
+			return nil, fmt.Errorf("uniform distribution takes no parameters")
+		}
+		return uniformDist{bounds}, nil
+
+	case "lognormal":
+		d := logNormalDist{sizeBounds: bounds, sigma: 1}
+		// Centre on the geometric mean of the range unless told otherwise
+		d.median = math.Sqrt(float64(minSize) * float64(maxSize))
+		err := parseDistParams(params, func(key, value string) error {
+			switch strings.ToLower(key) {
+			case "median":
+				n, err := parseSize(value)
+				d.median = float64(n)
+				return err
+			case "sigma":
+				return parsePositive(value, &d.sigma)
+			}
+			return fmt.Errorf("unknown lognormal parameter %q (expected median, sigma)", key)
+		})
+		return d, err
+
+	case "pareto":
+		d := paretoDist{sizeBounds: bounds, alpha: 1.16, scale: float64(minSize)}
+		err := parseDistParams(params, func(key, value string) error {
+			switch strings.ToLower(key) {
+			case "alpha":
+				return parsePositive(value, &d.alpha)
+			case "scale":
+				n, err := parseSize(value)
+				d.scale = float64(n)
+				return err
+			}
+			return fmt.Errorf("unknown pareto parameter %q (expected alpha, scale)", key)
+		})
+		if err == nil && d.scale <= 0 {
+			err = fmt.Errorf("pareto scale must be positive")
+		}
+		return d, err
+
+	case "buckets", "histogram":
+		d := &binDist{sizeBounds: bounds, name: strings.ToLower(name)}
+		err := parseDistParams(params, func(key, value string) error {
+			lo, hi, isRange := strings.Cut(key, "-")
+			if isRange != (d.name == "histogram") {
+				format := "SIZE=WEIGHT"
+				if d.name == "histogram" {
+					format = "MIN-MAX=WEIGHT"
+				}
+				return fmt.Errorf("invalid %s entry %q (expected %s)", d.name, key, format)
+			}
+			if !isRange {
+				hi = lo
+			}
+			b := sizeBin{weight: 1}
+			var err error
+			if b.lo, err = parseSize(lo); err != nil {
+				return err
+			}
+			if b.hi, err = parseSize(hi); err != nil {
+				return err
+			}
+			if b.hi < b.lo || b.lo < minSize || b.hi > maxSize {
+				return fmt.Errorf("%s entry %q is outside the size range %s to %s",
+					d.name, key, formatSize(minSize), formatSize(maxSize))
+			}
+			if value != "" {
+				if b.weight, err = strconv.Atoi(value); err != nil || b.weight < 0 {
+					return fmt.Errorf("invalid weight %q", value)
+				}
+			}
+			d.bins = append(d.bins, b)
+			d.total += b.weight
+			return nil
+		})
+		if err == nil && d.total == 0 {
+			err = fmt.Errorf("%s needs at least one entry with a positive weight", d.name)
+		}
+		return d, err
+	}
+	return nil, fmt.Errorf("unknown size distribution %q (expected uniform, lognormal, pareto, buckets or histogram)", name)
+}
+
+// parseDistParams calls set for every "key=value" of a comma-separated list.
+// The value may be empty when there is no "=".
+func parseDistParams(params string, set func(key, value string) error) error {
+	if strings.TrimSpace(params) == "" {
+		return nil
+	}
+	for _, param := range strings.Split(params, ",") {
+		key, value, _ := strings.Cut(param, "=")
+		if err := set(strings.TrimSpace(key), strings.TrimSpace(value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parsePositive(s string, v *float64) error {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f <= 0 || math.IsInf(f, 0) {
+		return fmt.Errorf("invalid value %q (expected a positive number)", s)
+	}
+	*v = f
+	return nil
+}
+
+// sizeBounds is the size range shared by every distribution
+type sizeBounds struct {
+	min, max int64
+}
+
+func (b sizeBounds) Range() (int64, int64) {
+	return b.min, b.max
+}
+
+// sample draws from a continuous distribution until a value falls inside
+// the bounds, so the shape is truncated rather than piled up at the edges
+func (b sizeBounds) sample(draw func() float64) int64 {
+	var x float64
+	for range 100 {
+		if x = draw(); x >= float64(b.min) && x < float64(b.max)+1 {
+			return int64(x)
+		}
+	}
+	return int64(math.Min(math.Max(x, float64(b.min)), float64(b.max)))
+}
+
+// uniformDist picks sizes uniformly. Ranges on whole KB stay on whole KB,
+// which keeps the sizes of earlier versions for the same seed.
+type uniformDist struct {
+	sizeBounds
+}
+
+func (d uniformDist) Pick(r *rand.Rand) int64 {
+	if d.min == d.max {
+		return d.min
+	}
+	if d.min%1024 == 0 && d.max%1024 == 0 {
+		return d.min + 1024*r.Int64N((d.max-d.min)/1024+1)
+	}
+	return d.min + r.Int64N(d.max-d.min+1)
+}
+
+func (d uniformDist) String() string {
+	return fmt.Sprintf("uniform between %s and %s", formatSize(d.min), formatSize(d.max))
+}
+
+// logNormalDist models "mostly around the median, with a long tail"
+type logNormalDist struct {
+	sizeBounds
+	median float64
+	sigma  float64
+}
+
+func (d logNormalDist) Pick(r *rand.Rand) int64 {
+	return d.sample(func() float64 {
+		return d.median * math.Exp(d.sigma*r.NormFloat64())
+	})
+}
+
+func (d logNormalDist) String() string {
+	return fmt.Sprintf("log-normal (median %s, sigma %g) between %s and %s",
+		formatSize(int64(d.median)), d.sigma, formatSize(d.min), formatSize(d.max))
+}
+
+// paretoDist models "many small files and a few huge ones"
+type paretoDist struct {
+	sizeBounds
+	alpha float64
+	scale float64
+}
+
+func (d paretoDist) Pick(r *rand.Rand) int64 {
+	return d.sample(func() float64 {
+		return d.scale / math.Pow(1-r.Float64(), 1/d.alpha)
+	})
+}
+
+func (d paretoDist) String() string {
+	return fmt.Sprintf("Pareto (alpha %g, scale %s) between %s and %s",
+		d.alpha, formatSize(int64(d.scale)), formatSize(d.min), formatSize(d.max))
+}
+
+// binDist picks a weighted bin, then a uniform size inside it. Buckets are
+// bins of a single size.
+type binDist struct {
+	sizeBounds
+	name  string
+	bins  []sizeBin
+	total int
+}
+
+type sizeBin struct {
+	lo, hi int64
+	weight int
+}
+
+func (d *binDist) Pick(r *rand.Rand) int64 {
+	n := r.IntN(d.total)
+	b := d.bins[len(d.bins)-1]
+	for _, bin := range d.bins {
+		if n < bin.weight {
+			b = bin
+			break
+		}
+		n -= bin.weight
+	}
+	if b.lo == b.hi {
+		return b.lo
+	}
+	return b.lo + r.Int64N(b.hi-b.lo+1)
+}
+
+func (d *binDist) String() string {
+	parts := make([]string, len(d.bins))
+	for i, b := range d.bins {
+		if b.lo == b.hi {
+			parts[i] = fmt.Sprintf("%s x%d", formatSize(b.lo), b.weight)
+		} else {
+			parts[i] = fmt.Sprintf("%s-%s x%d", formatSize(b.lo), formatSize(b.hi), b.weight)
+		}
+	}
+	return d.name + " (" + strings.Join(parts, ", ") + ")"
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/json"
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int64
+	}{
+		{"512B", 512},
+		{"64KB", 64 << 10},
+		{"64", 64 << 10},
+		{"1.5MB", 3 << 19},
+		{"2GB", 2 << 30},
+		{"64k", 64 << 10},
+		{"3M", 3 << 20},
+		{"1g", 1 << 30},
+		{"64KiB", 64 << 10},
+		{"2MiB", 2 << 20},
+		{"1GiB", 1 << 30},
+		{"0.5KB", 512},
+		{"1.0001KB", 1024},
+		{" 10 kb ", 10 << 10},
+		{"0B", 0},
+	}
+	for _, tt := range tests {
+		got, err := parseSize(tt.input)
+		if err != nil || got != tt.want {
+			t.Errorf("parseSize(%q) = %d, %v, want %d", tt.input, got, err, tt.want)
+		}
+	}
+
+	for _, input := range []string{"", "KB", "64XB", "64 bytes", "1,5MB", "-1KB", "NaN", "Inf", "1e300GB", "9000000000GB"} {
+		if n, err := parseSize(input); err == nil {
+			t.Errorf("parseSize(%q) = %d, want an error", input, n)
+		}
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1 KB"},
+		{3 << 19, "1.5 MB"},
+		{2<<30 + 100<<20, "2.1 GB"},
+	}
+	for _, tt := range tests {
+		if got := formatSize(tt.n); got != tt.want {
+			t.Errorf("formatSize(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestByteSizeJSON(t *testing.T) {
+	var sizes struct{ A, B, C byteSize }
+	if err := json.Unmarshal([]byte(`{"A": 64, "B": "1.5MB", "C": 0.5}`), &sizes); err != nil {
+		t.Fatal(err)
+	}
+	if sizes.A != 64<<10 || sizes.B != 3<<19 || sizes.C != 512 {
+		t.Errorf("got %d, %d, %d", sizes.A, sizes.B, sizes.C)
+	}
+	for _, input := range []string{`"64XB"`, `true`, `[1]`, `-2`} {
+		var b byteSize
+		if err := json.Unmarshal([]byte(input), &b); err == nil {
+			t.Errorf("%s parsed as %d, want an error", input, b)
+		}
+	}
+}
+
+func TestNewSizeDistErrors(t *testing.T) {
+	tests := []struct {
+		spec     string
+		min, max int64
+		want     string
+	}{
+		{"uniform", 0, 1024, "invalid size range"},
+		{"uniform", 2048, 1024, "invalid size range"},
+		{"uniform:x=1", 1024, 4096, "takes no parameters"},
+		{"zipf", 1024, 4096, "unknown size distribution"},
+		{"lognormal:mean=4KB", 1024, 4096, "unknown lognormal parameter"},
+		{"lognormal:median=4XB", 1024, 4096, "invalid size"},
+		{"lognormal:sigma=0", 1024, 4096, "positive number"},
+		{"lognormal:sigma=-1", 1024, 4096, "positive number"},
+		{"pareto:alpha=inf", 1024, 4096, "positive number"},
+		{"pareto:scale=0", 1024, 4096, "scale must be positive"},
+		{"pareto:shape=2", 1024, 4096, "unknown pareto parameter"},
+		{"buckets", 1024, 4096, "at least one entry"},
+		{"buckets:2KB=0", 1024, 4096, "at least one entry"},
+		{"buckets:2KB=-1", 1024, 4096, "invalid weight"},
+		{"buckets:2KB=x", 1024, 4096, "invalid weight"},
+		{"buckets:1KB-2KB=1", 1024, 4096, "expected SIZE=WEIGHT"},
+		{"buckets:8KB=1", 1024, 4096, "outside the size range"},
+		{"histogram:2KB=1", 1024, 4096, "expected MIN-MAX=WEIGHT"},
+		{"histogram:3KB-2KB=1", 1024, 4096, "outside the size range"},
+		{"histogram:512B-2KB=1", 1024, 4096, "outside the size range"},
+	}
+	for _, tt := range tests {
+		_, err := newSizeDist(tt.spec, tt.min, tt.max)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("newSizeDist(%q, %d, %d) error = %v, want one containing %q", tt.spec, tt.min, tt.max, err, tt.want)
+		}
+	}
+}
+
+func TestSizeDistBounds(t *testing.T) {
+	tests := []struct {
+		spec     string
+		min, max int64
+	}{
+		{"", 1024, 1 << 20},
+		{"uniform", 1000, 5000},
+		{"uniform", 4096, 4096},
+		{"lognormal", 1024, 1 << 20},
+		{"lognormal:median=1GB,sigma=3", 1024, 1 << 20},
+		{"lognormal:median=1B,sigma=0.1", 64 << 10, 1 << 20},
+		{"pareto", 1024, 100 << 20},
+		{"pareto:alpha=50,scale=1KB", 64 << 10, 1 << 20},
+		{"pareto:alpha=0.1", 1024, 8192},
+		{"buckets:4KB=50,1MB=30", 1024, 1 << 20},
+		{"histogram:1KB-10KB=70,10KB-1MB=30", 1024, 1 << 20},
+	}
+	for _, tt := range tests {
+		d, err := newSizeDist(tt.spec, tt.min, tt.max)
+		if err != nil {
+			t.Fatalf("newSizeDist(%q): %v", tt.spec, err)
+		}
+		if lo, hi := d.Range(); lo != tt.min || hi != tt.max {
+			t.Errorf("%q: range %d-%d, want %d-%d", tt.spec, lo, hi, tt.min, tt.max)
+		}
+		r := newFileRand(1, 0)
+		for range 5000 {
+			if n := d.Pick(r); n < tt.min || n > tt.max {
+				t.Fatalf("%q picked %d outside %d-%d", tt.spec, n, tt.min, tt.max)
+			}
+		}
+	}
+}
+
+func TestSizeDistShapes(t *testing.T) {
+	const draws = 20000
+	pick := func(spec string, min, max int64) map[int64]int {
+		t.Helper()
+		d, err := newSizeDist(spec, min, max)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := rand.New(rand.NewPCG(7, 7))
+		counts := make(map[int64]int)
+		for range draws {
+			counts[d.Pick(r)]++
+		}
+		return counts
+	}
+	near := func(got int, share float64) bool {
+		want := share * draws
+		return float64(got) > want*0.95 && float64(got) < want*1.05
+	}
+
+	// Whole-KB ranges stay on whole KB and reach both ends
+	counts := pick("uniform", 1024, 8192)
+	for n := range counts {
+		if n%1024 != 0 {
+			t.Errorf("uniform picked %d, not a whole KB", n)
+		}
+	}
+	if len(counts) != 8 {
+		t.Errorf("uniform picked %d distinct sizes, want 8", len(counts))
+	}
+
+	// Buckets follow their weights and never pick a zero-weight bucket
+	counts = pick("buckets:4KB=50,8KB=0,1MB=30,2KB=20", 1024, 1<<20)
+	if len(counts) != 3 || counts[8192] != 0 {
+		t.Errorf("buckets picked %v", counts)
+	}
+	if !near(counts[4096], 0.5) || !near(counts[1<<20], 0.3) || !near(counts[2048], 0.2) {
+		t.Errorf("bucket counts %v do not follow the weights 50/30/20", counts)
+	}
+
+	// Histogram bins follow their weights and stay inside the bin
+	counts = pick("histogram:1KB-10KB=70,10KB-1MB=0,100KB-200KB=30", 1024, 1<<20)
+	small, large := 0, 0
+	for n, c := range counts {
+		switch {
+		case n >= 1024 && n <= 10<<10:
+			small += c
+		case n >= 100<<10 && n <= 200<<10:
+			large += c
+		default:
+			t.Errorf("histogram picked %d outside its bins", n)
+		}
+	}
+	if !near(small, 0.7) || !near(large, 0.3) {
+		t.Errorf("histogram picked %d small and %d large, want 70/30", small, large)
+	}
+
+	// A lognormal centred on the range has its median near the centre
+	counts = pick("lognormal:median=64KB,sigma=1", 1024, 4<<20)
+	below := 0
+	for n, c := range counts {
+		if n < 64<<10 {
+			below += c
+		}
+	}
+	if !near(below, 0.5) {
+		t.Errorf("%d of %d lognormal sizes are below the median", below, draws)
+	}
+
+	// A distribution that falls almost entirely below --min-size is
+	// clamped to it rather than piling up anywhere else
+	counts = pick("pareto:alpha=50,scale=1KB", 64<<10, 1<<20)
+	if len(counts) != 1 || counts[64<<10] != draws {
+		t.Errorf("pareto below the minimum picked %v, want only the minimum", counts)
+	}
+}
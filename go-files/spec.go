@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// planStream is the PCG stream used to plan extension counts
+const planStream = ^uint64(0) - 1
+
+// Spec describes a whole generation run. It is loaded from a YAML or JSON
+// file by "generator run spec.yaml", so fixtures can be versioned with the
+// tests that use them.
+type Spec struct {
+	Seed       *uint64                  `json:"seed"`
+	Files      int                      `json:"files"`      // defaults to the sum of the extension counts
+	TotalSize  byteSize                 `json:"total_size"` // byte budget; files is then a cap
+	MinSize    byteSize                 `json:"min_size"`   // 64 (KB) or "64KB", "1.5MB", ...
+	MaxSize    byteSize                 `json:"max_size"`
+	Dist       string                   `json:"distribution"` // see newSizeDist
+	Workers    int                      `json:"workers"`
+	ExactSize  bool                     `json:"exact_size"`
+	Out        string                   `json:"out"`
+	Name       string                   `json:"name"`
+	Force      bool                     `json:"force"`
+	Manifest   []string                 `json:"manifest"` // .jsonl or .csv files
+	Layout     *LayoutSpec              `json:"layout"`
+	Extensions map[string]ExtensionSpec `json:"extensions"`
+}
+
+// LayoutSpec configures the directory layout of a spec run
+type LayoutSpec struct {
+	Tree        bool      `json:"tree"`
+	Depth       *intRange `json:"depth"`
+	Fanout      *intRange `json:"fanout"`
+	FilesPerDir *intRange `json:"files_per_dir"`
+	DirNames    string    `json:"dir_names"`
+}
+
+// ExtensionSpec configures one extension of a spec run. Count fixes the
+// number of files; files not covered by counts are picked by Weight.
+type ExtensionSpec struct {
+	Count   int             `json:"count"`
+	Weight  int             `json:"weight"`
+	MinSize byteSize        `json:"min_size"` // the sizes default to the run's
+	MaxSize byteSize        `json:"max_size"`
+	Dist    string          `json:"distribution"`
+	Options json.RawMessage `json:"options"` // generator specific, e.g. csv columns
+}
+
+// UnmarshalJSON accepts a range as a number or as an "N" / "MIN-MAX" string
+func (ir *intRange) UnmarshalJSON(data []byte) error {
+	var n int
+	if err := json.Unmarshal(data, &n); err == nil {
+		*ir = intRange{Min: n, Max: n}
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("invalid range %s (expected N or \"MIN-MAX\")", data)
+	}
+	parsed, err := parseIntRange(s)
+	if err != nil {
+		return err
+	}
+	*ir = parsed
+	return nil
+}
+
+// loadSpec reads a spec file. Files ending in .json are parsed as JSON,
+// anything else as YAML (which also accepts most JSON).
+func loadSpec(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.EqualFold(filepath.Ext(path), ".json") {
+		doc, err := parseYAML(data)
+		if err != nil {
+			return nil, err
+		}
+		if data, err = json.Marshal(doc); err != nil {
+			return nil, err
+		}
+	}
+
+	var spec Spec
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&spec); err != nil {
+		return nil, err
+	}
+	return &spec, nil
+}
+
+// Config converts the spec into a run configuration, applying defaults
+// and validating every extension and its generator options
+func (s *Spec) Config() (*Config, error) {
+	cfg := &Config{
+		NumFiles:  s.Files,
+		Workers:   max(1, s.Workers),
+		ExactSize: s.ExactSize || s.TotalSize > 0,
+		OutDir:    s.Out,
+		TotalSize: int64(s.TotalSize),
+		Force:     s.Force,
+	}
+
+	if s.Seed != nil {
+		cfg.Seed = *s.Seed
+	} else {
+		cfg.Seed = rand.Uint64()
+	}
+	if cfg.OutDir == "" {
+		cfg.OutDir = "."
+	}
+	for _, path := range s.Manifest {
+		if err := checkManifestPath(path); err != nil {
+			return nil, err
+		}
+		cfg.Manifests = append(cfg.Manifests, path)
+	}
+
+	name := s.Name
+	if name == "" {
+		name = defaultNameTemplate
+	}
+	var err error
+	if cfg.Names, err = parseNameTemplate(name); err != nil {
+		return nil, err
+	}
+
+	minSize, maxSize := int64(s.MinSize), int64(s.MaxSize)
+	if minSize == 0 {
+		minSize, _ = parseSize(defaultMinSize)
+	}
+	if maxSize == 0 {
+		maxSize = max(minSize, 100*1024)
+	}
+
+	if len(s.Extensions) == 0 {
+		return nil, fmt.Errorf("no extensions given")
+	}
+	names := make([]string, 0, len(s.Extensions))
+	for ext := range s.Extensions {
+		names = append(names, ext)
+	}
+	sort.Strings(names) // map order is random, the run must not be
+
+	counted, weighted := 0, 0
+	for _, ext := range names {
+		es := s.Extensions[ext]
+		if !slices.Contains(SupportedExtensions(), ext) {
+			return nil, fmt.Errorf("extension %q is not supported", ext)
+		}
+		if es.Count < 0 || es.Weight < 0 {
+			return nil, fmt.Errorf("extension %q: count and weight must not be negative", ext)
+		}
+
+		ec := ExtensionConfig{
+			Name:    ext,
+			Count:   es.Count,
+			Weight:  es.Weight,
+			Options: es.Options,
+		}
+		if ec.Count == 0 && ec.Weight == 0 {
+			ec.Weight = 1
+		}
+
+		extMin, extMax, dist := int64(es.MinSize), int64(es.MaxSize), es.Dist
+		if extMin == 0 {
+			extMin = minSize
+		}
+		if extMax == 0 {
+			extMax = max(extMin, maxSize)
+		}
+		if dist == "" {
+			dist = s.Dist
+		}
+		var err error
+		if ec.Sizes, err = newSizeDist(dist, extMin, extMax); err != nil {
+			return nil, fmt.Errorf("extension %q: %w", ext, err)
+		}
+		if err := configureGenerator(NewGenerator(ext), ec.Options); err != nil {
+			return nil, fmt.Errorf("extension %q: options: %w", ext, err)
+		}
+
+		counted += ec.Count
+		weighted += ec.Weight
+		cfg.Extensions = append(cfg.Extensions, ec)
+	}
+
+	if cfg.NumFiles == 0 && cfg.TotalSize == 0 {
+		cfg.NumFiles = counted
+	}
+	switch {
+	case cfg.TotalSize > 0 && counted > 0:
+		// files only caps a budget run, so counts could not be honoured
+		return nil, fmt.Errorf("extension counts cannot be combined with total_size; use weights")
+	case cfg.NumFiles < 0 || (cfg.NumFiles == 0 && cfg.TotalSize == 0):
+		return nil, fmt.Errorf("no files to generate: set files, total_size or extension counts")
+	case counted > cfg.NumFiles:
+		return nil, fmt.Errorf("extension counts add up to %d, more than the %d files requested", counted, cfg.NumFiles)
+	case counted < cfg.NumFiles && weighted == 0:
+		return nil, fmt.Errorf("extension counts only cover %d of %d files; give some extensions a weight", counted, cfg.NumFiles)
+	}
+	if counted > 0 {
+		cfg.plan = planExtensions(cfg.Seed, cfg.NumFiles, cfg.Extensions)
+	}
+
+	if s.Layout != nil && s.Layout.Tree {
+		opts := TreeOptions{
+			Depth:       intRange{Min: 1, Max: 3},
+			Fanout:      intRange{Min: 2, Max: 4},
+			FilesPerDir: intRange{Min: 1, Max: 10},
+			DirNames:    s.Layout.DirNames,
+		}
+		if s.Layout.Depth != nil {
+			opts.Depth = *s.Layout.Depth
+		}
+		if s.Layout.Fanout != nil {
+			opts.Fanout = *s.Layout.Fanout
+		}
+		if s.Layout.FilesPerDir != nil {
+			opts.FilesPerDir = *s.Layout.FilesPerDir
+		}
+		if opts.DirNames == "" {
+			opts.DirNames = "words"
+		}
+		cfg.Tree = &opts
+	}
+
+	return cfg, nil
+}
+
+// configureGenerator applies generator specific options from a spec by
+// decoding them into the generator's exported fields
+func configureGenerator(g FileGenerator, options json.RawMessage) error {
+	if len(options) == 0 || string(options) == "null" {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(options))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(g); err != nil {
+		return err
+	}
+	if v, ok := g.(interface{ Validate() error }); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
+// planExtensions assigns an extension to every file when some extensions
+// have fixed counts: the counted files first, weighted picks for the rest,
+// then shuffled so the formats are interleaved
+func planExtensions(seed uint64, numFiles int, exts []ExtensionConfig) []int {
+	r := rand.New(rand.NewPCG(seed, planStream))
+	plan := make([]int, 0, numFiles)
+	for i, ext := range exts {
+		for n := 0; n < ext.Count; n++ {
+			plan = append(plan, i)
+		}
+	}
+	for len(plan) < numFiles {
+		plan = append(plan, pickWeighted(r, exts))
+	}
+	r.Shuffle(len(plan), func(i, j int) {
+		plan[i], plan[j] = plan[j], plan[i]
+	})
+	return plan
+}
+
+// pickWeighted picks an extension index in proportion to the weights
+func pickWeighted(r *rand.Rand, exts []ExtensionConfig) int {
+	total := 0
+	for _, ext := range exts {
+		total += ext.Weight
+	}
+	n := r.IntN(total)
+	for i, ext := range exts {
+		if n < ext.Weight {
+			return i
+		}
+		n -= ext.Weight
+	}
+	return len(exts) - 1
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeSpec writes a spec file named name into a temporary directory and
+// loads it
+func writeSpec(t *testing.T, name, content string) (*Spec, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return loadSpec(path)
+}
+
+func TestSpecConfig(t *testing.T) {
+	spec, err := writeSpec(t, "spec.yaml", `
+seed: 7
+files: 12
+min_size: 2KB
+max_size: 1MB
+distribution: lognormal
+workers: 3
+layout:
+  tree: true
+  depth: 2
+  fanout: "1-3"
+extensions:
+  pdf: {count: 2, max_size: 200KB, options: {page_size: a4}}
+  csv:
+    weight: 3
+    distribution: "buckets:4KB=1,8KB=1"
+  txt:
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := spec.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Seed != 7 || cfg.NumFiles != 12 || cfg.Workers != 3 || cfg.OutDir != "." || cfg.ExactSize {
+		t.Errorf("run settings not applied: %+v", cfg)
+	}
+	if cfg.Tree == nil || cfg.Tree.Depth != (intRange{2, 2}) || cfg.Tree.Fanout != (intRange{1, 3}) || cfg.Tree.FilesPerDir != (intRange{1, 10}) {
+		t.Errorf("tree options not applied: %+v", cfg.Tree)
+	}
+
+	// Extensions are sorted by name
+	want := []struct {
+		name          string
+		count, weight int
+		min, max      int64
+		dist          string
+	}{
+		{"csv", 0, 3, 2 << 10, 1 << 20, "buckets"},
+		{"pdf", 2, 0, 2 << 10, 200 << 10, "log-normal"},
+		{"txt", 0, 1, 2 << 10, 1 << 20, "log-normal"},
+	}
+	if len(cfg.Extensions) != len(want) {
+		t.Fatalf("%d extensions, want %d", len(cfg.Extensions), len(want))
+	}
+	for i, w := range want {
+		ext := cfg.Extensions[i]
+		lo, hi := ext.Sizes.Range()
+		if ext.Name != w.name || ext.Count != w.count || ext.Weight != w.weight || !strings.HasPrefix(ext.Sizes.String(), w.dist) {
+			t.Errorf("extension %d is %s count %d weight %d %s, want %+v", i, ext.Name, ext.Count, ext.Weight, ext.Sizes, w)
+		}
+		if w.dist != "buckets" && (lo != w.min || hi != w.max) {
+			t.Errorf("%s sizes %d-%d, want %d-%d", ext.Name, lo, hi, w.min, w.max)
+		}
+	}
+	pdfs := 0
+	for _, i := range cfg.plan {
+		if cfg.Extensions[i].Name == "pdf" {
+			pdfs++
+		}
+	}
+	if len(cfg.plan) != 12 || pdfs != 2 {
+		t.Errorf("plan %v has %d PDFs, want 12 files with 2", cfg.plan, pdfs)
+	}
+}
+
+func TestSpecConfigErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+		want string
+	}{
+		{"unknown key", "files: 3\nextension: {txt: {}}\n", "unknown field"},
+		{"unknown option", "files: 3\nextensions: {csv: {options: {colums: [id]}}}\n", "unknown field"},
+		{"invalid option", "files: 3\nextensions: {csv: {options: {columns: [shoe_size]}}}\n", "extension \"csv\": options"},
+		{"unsupported extension", "files: 3\nextensions: {exe: {}}\n", "not supported"},
+		{"no extensions", "files: 3\n", "no extensions"},
+		{"no files", "extensions: {txt: {weight: 2}}\n", "no files to generate"},
+		{"negative count", "files: 3\nextensions: {txt: {count: -1}}\n", "must not be negative"},
+		{"counts and budget", "total_size: 1MB\nextensions: {txt: {count: 2}, csv: {}}\n", "cannot be combined with total_size"},
+		{"counts over files", "files: 3\nextensions: {txt: {count: 2}, csv: {count: 2}}\n", "more than the 3 files"},
+		{"counts under files", "files: 5\nextensions: {txt: {count: 2}, csv: {count: 2}}\n", "give some extensions a weight"},
+		{"bad size", "files: 3\nmax_size: 3 parsecs\nextensions: {txt: {}}\n", "invalid"},
+		{"bad distribution", "files: 3\nextensions: {txt: {distribution: zipf}}\n", "extension \"txt\""},
+		{"bad manifest", "files: 3\nmanifest: [out.xml]\nextensions: {txt: {}}\n", "unknown manifest format"},
+		{"bad name", "files: 3\nname: \"{index:q}.{ext}\"\nextensions: {txt: {}}\n", "invalid format \"q\""},
+		{"bad range", "files: 3\nlayout: {tree: true, depth: \"3-1\"}\nextensions: {txt: {}}\n", "invalid"},
+	}
+	for _, tt := range tests {
+		spec, err := writeSpec(t, "spec.yaml", tt.spec)
+		if err == nil {
+			_, err = spec.Config()
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error = %v, want one containing %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestSpecOptionsReachGenerators(t *testing.T) {
+	out := t.TempDir()
+	spec, err := writeSpec(t, "spec.json", `{
+		"seed": 3,
+		"out": "`+filepath.ToSlash(out)+`",
+		"min_size": "4KB",
+		"max_size": "16KB",
+		"manifest": ["`+filepath.ToSlash(filepath.Join(out, "manifest.jsonl"))+`"],
+		"extensions": {
+			"csv": {"count": 2, "options": {"columns": ["id", "city"]}},
+			"pdf": {"count": 2, "options": {"page_size": "A4", "headers": false}}
+		}
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := spec.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := execute(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := loadManifest(cfg.Manifests[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("%d files generated, want 4", len(entries))
+	}
+	for _, e := range entries {
+		switch e.Ext {
+		case "csv":
+			f, err := os.Open(filepath.Join(out, e.Path))
+			if err != nil {
+				t.Fatal(err)
+			}
+			header, _ := bufio.NewReader(f).ReadString('\n')
+			f.Close()
+			if header != "id,city\n" {
+				t.Errorf("%s has header %q, want the configured columns", e.Path, header)
+			}
+		case "pdf":
+			if e.Metadata["page_size"] != "a4" {
+				t.Errorf("%s has page size %v, want a4", e.Path, e.Metadata["page_size"])
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"math/rand/v2"
+)
+
+// StreamGenerator is implemented by generators that can write their output
+// incrementally, so arbitrarily large files are produced in constant memory.
+type StreamGenerator interface {
+	FileGenerator
+	GenerateTo(w io.Writer, r *rand.Rand, sizeBytes int64) (int64, error)
+}
+
+// generateBuffered implements FileGenerator.Generate for a StreamGenerator
+func generateBuffered(g StreamGenerator, r *rand.Rand, sizeBytes int) ([]byte, error) {
+	var buf bytes.Buffer
+	_, err := g.GenerateTo(&buf, r, int64(sizeBytes))
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// sizedWriter counts the bytes written to w. When limit is non-negative,
+// anything beyond limit bytes is silently dropped, which mirrors truncating
+// a buffer. The first write error is kept and all later writes are skipped.
+type sizedWriter struct {
+	w     io.Writer
+	n     int64
+	limit int64
+	err   error
+}
+
+func newSizedWriter(w io.Writer, limit int64) *sizedWriter {
+	return &sizedWriter{w: w, limit: limit}
+}
+
+func (sw *sizedWriter) Write(p []byte) (int, error) {
+	if sw.err != nil {
+		return 0, sw.err
+	}
+	size := len(p)
+	if sw.limit >= 0 && sw.n+int64(len(p)) > sw.limit {
+		p = p[:sw.limit-sw.n]
+	}
+	n, err := sw.w.Write(p)
+	sw.n += int64(n)
+	if err != nil {
+		sw.err = err
+		return n, err
+	}
+	return size, nil
+}
+
+func (sw *sizedWriter) WriteString(s string) {
+	sw.Write([]byte(s))
+}
+
+// Len returns the number of bytes written so far
+func (sw *sizedWriter) Len() int64 {
+	return sw.n
+}
+
+// Done reports whether the limit has been reached or a write failed
+func (sw *sizedWriter) Done() bool {
+	return sw.err != nil || (sw.limit >= 0 && sw.n >= sw.limit)
+}
+
+// writeRepeated writes n copies of b in fixed-size chunks
+func writeRepeated(w io.Writer, b byte, n int64) error {
+	chunk := bytes.Repeat([]byte{b}, 32*1024)
+	for n > 0 {
+		size := min(n, int64(len(chunk)))
+		if _, err := w.Write(chunk[:size]); err != nil {
+			return err
+		}
+		n -= size
+	}
+	return nil
+}
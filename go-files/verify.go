@@ -0,0 +1,669 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image/png"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// verifySummary counts the outcome of a verify run
+type verifySummary struct {
+	Passed, Failed, Skipped int
+}
+
+// verifyDir re-parses every file under dir with a parser for its format.
+// Failures are printed as they are found.
+func verifyDir(dir string, skip func(path string) bool) (verifySummary, error) {
+	var sum verifySummary
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || skip(p) || strings.HasPrefix(d.Name(), ".generator-") {
+			return nil
+		}
+
+		rel, _ := filepath.Rel(dir, p)
+		err = verifyFile(p)
+		switch {
+		case errors.Is(err, errUnknownFormat):
+			sum.Skipped++
+		case err != nil:
+			sum.Failed++
+			fmt.Printf("FAIL %s: %v\n", filepath.ToSlash(rel), err)
+		default:
+			sum.Passed++
+		}
+		return nil
+	})
+	return sum, err
+}
+
+var errUnknownFormat = errors.New("unknown format")
+
+// verifyFile checks that the file parses as the format its extension claims
+func verifyFile(name string) error {
+	switch strings.ToLower(strings.TrimPrefix(filepath.Ext(name), ".")) {
+	case "txt":
+		return verifyText(name)
+	case "csv":
+		return verifyCSV(name)
+	case "json":
+		return verifyJSON(name)
+	case "xml":
+		return verifyXML(name)
+	case "html":
+		return verifyHTML(name)
+	case "md":
+		return verifyMarkdown(name)
+	case "log":
+		return verifyLog(name)
+	case "pdf":
+		return verifyPDF(name)
+	case "docx", "xlsx", "pptx":
+		return verifyOOXML(name)
+	case "odt", "ods", "odp":
+		return verifyODF(name)
+	case "png":
+		return verifyPNG(name)
+	}
+	return errUnknownFormat
+}
+
+// openBuffered opens a file for sequential reading
+func openBuffered(name string, verify func(r *bufio.Reader) error) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return verify(bufio.NewReaderSize(f, 64*1024))
+}
+
+// verifyLines calls check for every line, which must all end in a newline
+// and be valid UTF-8
+func verifyLines(name string, check func(num int, line string) error) error {
+	return openBuffered(name, func(r *bufio.Reader) error {
+		for num := 1; ; num++ {
+			line, err := r.ReadString('\n')
+			if err == io.EOF {
+				if line != "" {
+					return fmt.Errorf("line %d: truncated (no final newline)", num)
+				}
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if !utf8.ValidString(line) {
+				return fmt.Errorf("line %d: invalid UTF-8", num)
+			}
+			if err := check(num, strings.TrimSuffix(line, "\n")); err != nil {
+				return fmt.Errorf("line %d: %w", num, err)
+			}
+		}
+	})
+}
+
+func verifyText(name string) error {
+	return openBuffered(name, func(r *bufio.Reader) error {
+		for offset := 0; ; {
+			c, size, err := r.ReadRune()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if c == utf8.RuneError && size == 1 {
+				return fmt.Errorf("invalid UTF-8 at byte %d", offset)
+			}
+			offset += size
+		}
+	})
+}
+
+func verifyCSV(name string) error {
+	return openBuffered(name, func(r *bufio.Reader) error {
+		cr := csv.NewReader(r)
+		cr.ReuseRecord = true
+		rows := 0
+		for {
+			_, err := cr.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			rows++
+		}
+		if rows == 0 {
+			return fmt.Errorf("no header row")
+		}
+		return nil
+	})
+}
+
+func verifyJSON(name string) error {
+	return openBuffered(name, func(r *bufio.Reader) error {
+		dec := json.NewDecoder(r)
+		depth, values := 0, 0
+		for {
+			tok, err := dec.Token()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			switch tok {
+			case json.Delim('['), json.Delim('{'):
+				depth++
+			case json.Delim(']'), json.Delim('}'):
+				depth--
+			}
+			if depth == 0 {
+				values++
+			}
+		}
+		if depth != 0 {
+			return fmt.Errorf("unexpected end of JSON input")
+		}
+		if values != 1 {
+			return fmt.Errorf("expected one JSON value, found %d", values)
+		}
+		return nil
+	})
+}
+
+func verifyXML(name string) error {
+	return openBuffered(name, func(r *bufio.Reader) error {
+		return checkXML(r)
+	})
+}
+
+// checkXML checks that r holds a well-formed XML document with one root
+func checkXML(r io.Reader) error {
+	dec := xml.NewDecoder(r)
+	depth, roots := 0, 0
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				roots++
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			if depth == 0 && len(bytes.TrimSpace(t)) > 0 {
+				return fmt.Errorf("text outside the root element")
+			}
+		}
+	}
+	if roots != 1 {
+		return fmt.Errorf("expected one root element, found %d", roots)
+	}
+	return nil
+}
+
+// verifyHTML parses the document with HTML rules (void elements and
+// entities) and checks that every element is closed
+func verifyHTML(name string) error {
+	return openBuffered(name, func(r *bufio.Reader) error {
+		dec := xml.NewDecoder(r)
+		dec.Strict = false
+		dec.AutoClose = xml.HTMLAutoClose
+		dec.Entity = xml.HTMLEntity
+
+		var open []string
+		sawHTML := false
+		for {
+			tok, err := dec.Token()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			switch t := tok.(type) {
+			case xml.StartElement:
+				open = append(open, t.Name.Local)
+				sawHTML = sawHTML || strings.EqualFold(t.Name.Local, "html")
+			case xml.EndElement:
+				if len(open) == 0 || !strings.EqualFold(open[len(open)-1], t.Name.Local) {
+					return fmt.Errorf("unexpected </%s>", t.Name.Local)
+				}
+				open = open[:len(open)-1]
+			}
+		}
+		if len(open) > 0 {
+			return fmt.Errorf("<%s> is never closed", open[len(open)-1])
+		}
+		if !sawHTML {
+			return fmt.Errorf("no <html> element")
+		}
+		return nil
+	})
+}
+
+// verifyMarkdown checks the text and that no code fence is left open
+func verifyMarkdown(name string) error {
+	fenceLine := 0
+	err := verifyLines(name, func(num int, line string) error {
+		if strings.HasPrefix(line, "```") {
+			if fenceLine == 0 {
+				fenceLine = num
+			} else {
+				fenceLine = 0
+			}
+		}
+		return nil
+	})
+	if err == nil && fenceLine != 0 {
+		err = fmt.Errorf("line %d: code fence is never closed", fenceLine)
+	}
+	return err
+}
+
+// logLinePattern matches the prefix written by logPrefix
+var logLinePattern = regexp.MustCompile(`^\[\d+\] \[(` + strings.Join(logLevels, "|") + `)\] \S+: `)
+
+func verifyLog(name string) error {
+	return verifyLines(name, func(num int, line string) error {
+		if !logLinePattern.MatchString(line) {
+			return fmt.Errorf("not a log entry: %.40q", line)
+		}
+		return nil
+	})
+}
+
+// verifyPNG walks the chunks checking their CRCs and order, then decodes
+// the image data
+func verifyPNG(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+
+	signature := make([]byte, 8)
+	if _, err := io.ReadFull(r, signature); err != nil || string(signature) != "\x89PNG\r\n\x1a\n" {
+		return fmt.Errorf("missing PNG signature")
+	}
+	for first := true; ; first = false {
+		var header [8]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return fmt.Errorf("truncated before IEND")
+		}
+		length := binary.BigEndian.Uint32(header[:4])
+		chunkType := string(header[4:])
+		if first && chunkType != "IHDR" {
+			return fmt.Errorf("first chunk is %s, not IHDR", chunkType)
+		}
+
+		crc := crc32.NewIEEE()
+		crc.Write(header[4:])
+		var data bytes.Buffer
+		body := io.Writer(crc)
+		if chunkType == "zTXt" {
+			body = io.MultiWriter(crc, &data) // kept to check the compressed text
+		}
+		if _, err := io.CopyN(body, r, int64(length)); err != nil {
+			return fmt.Errorf("%s chunk: truncated", chunkType)
+		}
+		var stored [4]byte
+		if _, err := io.ReadFull(r, stored[:]); err != nil {
+			return fmt.Errorf("%s chunk: truncated", chunkType)
+		}
+		if binary.BigEndian.Uint32(stored[:]) != crc.Sum32() {
+			return fmt.Errorf("%s chunk: CRC mismatch", chunkType)
+		}
+		if chunkType == "zTXt" {
+			if err := checkZtxt(data.Bytes()); err != nil {
+				return fmt.Errorf("zTXt chunk: %w", err)
+			}
+		}
+		if chunkType == "IEND" {
+			break
+		}
+	}
+	if _, err := r.ReadByte(); err != io.EOF {
+		return fmt.Errorf("data after IEND")
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	_, err = png.Decode(bufio.NewReader(f))
+	return err
+}
+
+// checkZtxt checks the keyword and compressed text of a zTXt chunk
+func checkZtxt(data []byte) error {
+	keyword, rest, found := bytes.Cut(data, []byte{0})
+	if !found || len(keyword) == 0 || len(keyword) > 79 || len(rest) == 0 || rest[0] != 0 {
+		return fmt.Errorf("invalid keyword or compression method")
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(rest[1:]))
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(io.Discard, zr)
+	return err
+}
+
+// verifyOOXML checks an Office Open XML package: every entry must
+// decompress with a valid CRC, every XML part must be well-formed, every
+// part must have a content type and every internal relationship must
+// point at an existing part
+func verifyOOXML(name string) error {
+	zr, err := zip.OpenReader(name)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	parts := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		parts[f.Name] = f
+	}
+	if parts["[Content_Types].xml"] == nil {
+		return fmt.Errorf("missing [Content_Types].xml")
+	}
+
+	var types struct {
+		Defaults []struct {
+			Extension string `xml:"Extension,attr"`
+		} `xml:"Default"`
+		Overrides []struct {
+			PartName string `xml:"PartName,attr"`
+		} `xml:"Override"`
+	}
+	var defaults, overrides []string
+	for _, f := range zr.File {
+		if err := verifyZipEntry(f); err != nil {
+			return err
+		}
+		switch {
+		case f.Name == "[Content_Types].xml":
+			if err := decodeZipXML(f, &types); err != nil {
+				return err
+			}
+			for _, d := range types.Defaults {
+				defaults = append(defaults, strings.ToLower(d.Extension))
+			}
+			for _, o := range types.Overrides {
+				overrides = append(overrides, o.PartName)
+			}
+		case strings.HasSuffix(f.Name, ".rels"):
+			if err := checkRelationships(f, parts); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, f := range zr.File {
+		if f.Name == "[Content_Types].xml" || strings.HasSuffix(f.Name, "/") {
+			continue
+		}
+		ext := strings.ToLower(strings.TrimPrefix(path.Ext(f.Name), "."))
+		if !slices.Contains(overrides, "/"+f.Name) && !slices.Contains(defaults, ext) {
+			return fmt.Errorf("%s: no content type", f.Name)
+		}
+	}
+	return nil
+}
+
+// verifyODF checks an OpenDocument package: the first entry must be the
+// mimetype of the format, stored uncompressed without extra fields, every
+// entry must decompress with a valid CRC, every XML part must be
+// well-formed, and META-INF/manifest.xml must list every other entry, each
+// of which must exist
+func verifyODF(name string) error {
+	zr, err := zip.OpenReader(name)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	mimetype := odfMimetypes[strings.ToLower(strings.TrimPrefix(filepath.Ext(name), "."))]
+	if len(zr.File) == 0 || zr.File[0].Name != "mimetype" {
+		return fmt.Errorf("first entry is not mimetype")
+	}
+	if first := zr.File[0]; first.Method != zip.Store || len(first.Extra) > 0 {
+		return fmt.Errorf("mimetype is compressed or has extra fields")
+	}
+	rc, err := zr.File[0].Open()
+	if err != nil {
+		return fmt.Errorf("mimetype: %w", err)
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return fmt.Errorf("mimetype: %w", err)
+	}
+	if string(data) != mimetype {
+		return fmt.Errorf("mimetype is %q, want %q", data, mimetype)
+	}
+
+	var manifest struct {
+		Entries []struct {
+			Path      string `xml:"full-path,attr"`
+			MediaType string `xml:"media-type,attr"`
+		} `xml:"file-entry"`
+	}
+	parts := make(map[string]bool)
+	for _, f := range zr.File {
+		if err := verifyZipEntry(f); err != nil {
+			return err
+		}
+		if f.Name == "META-INF/manifest.xml" {
+			if err := decodeZipXML(f, &manifest); err != nil {
+				return err
+			}
+		}
+		parts[f.Name] = true
+	}
+	if !parts["META-INF/manifest.xml"] {
+		return fmt.Errorf("missing META-INF/manifest.xml")
+	}
+
+	listed := make(map[string]bool)
+	for _, e := range manifest.Entries {
+		switch {
+		case e.Path == "/":
+			if e.MediaType != mimetype {
+				return fmt.Errorf("manifest: media type %q, want %q", e.MediaType, mimetype)
+			}
+		case !strings.HasSuffix(e.Path, "/") && !parts[e.Path]:
+			return fmt.Errorf("manifest: %s does not exist", e.Path)
+		}
+		listed[e.Path] = true
+	}
+	if !listed["/"] {
+		return fmt.Errorf("manifest: no entry for the package")
+	}
+	for _, f := range zr.File {
+		if f.Name != "mimetype" && f.Name != "META-INF/manifest.xml" && !strings.HasSuffix(f.Name, "/") && !listed[f.Name] {
+			return fmt.Errorf("%s: not in the manifest", f.Name)
+		}
+	}
+	return nil
+}
+
+// verifyZipEntry reads a zip entry to the end, which checks its CRC, and
+// parses it when it is XML
+func verifyZipEntry(f *zip.File) error {
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("%s: %w", f.Name, err)
+	}
+	defer rc.Close()
+	if strings.HasSuffix(f.Name, ".xml") || strings.HasSuffix(f.Name, ".rels") {
+		err = checkXML(rc)
+	} else {
+		_, err = io.Copy(io.Discard, rc)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", f.Name, err)
+	}
+	return nil
+}
+
+// decodeZipXML unmarshals a zip entry
+func decodeZipXML(f *zip.File, v any) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if err := xml.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("%s: %w", f.Name, err)
+	}
+	return nil
+}
+
+// checkRelationships checks that the internal targets of a .rels part exist
+func checkRelationships(f *zip.File, parts map[string]*zip.File) error {
+	var rels struct {
+		Relationships []struct {
+			Target     string `xml:"Target,attr"`
+			TargetMode string `xml:"TargetMode,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodeZipXML(f, &rels); err != nil {
+		return err
+	}
+
+	// "word/_rels/document.xml.rels" describes "word/document.xml", whose
+	// relative targets resolve against "word/"
+	base := path.Dir(path.Dir(f.Name))
+	for _, rel := range rels.Relationships {
+		if rel.TargetMode == "External" {
+			continue
+		}
+		target := path.Join(base, rel.Target)
+		if strings.HasPrefix(rel.Target, "/") {
+			target = strings.TrimPrefix(rel.Target, "/")
+		}
+		if parts[target] == nil {
+			return fmt.Errorf("%s: relationship target %s does not exist", f.Name, rel.Target)
+		}
+	}
+	return nil
+}
+
+// loadManifest reads a manifest written by --manifest
+func loadManifest(name string) ([]manifestEntry, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []manifestEntry
+	if strings.EqualFold(filepath.Ext(name), ".csv") {
+		records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 || !slices.Equal(records[0], manifestColumns) {
+			return nil, fmt.Errorf("unexpected CSV manifest header")
+		}
+		for _, rec := range records[1:] {
+			e := manifestEntry{Path: rec[1], Ext: rec[2], SHA256: rec[4], Generator: rec[5]}
+			e.Index, _ = strconv.Atoi(rec[0])
+			if e.Size, err = strconv.ParseInt(rec[3], 10, 64); err != nil {
+				return nil, fmt.Errorf("invalid size %q for %s", rec[3], e.Path)
+			}
+			if e.Seed, err = strconv.ParseUint(rec[6], 10, 64); err != nil {
+				return nil, fmt.Errorf("invalid seed %q for %s", rec[6], e.Path)
+			}
+			if rec[7] != "" {
+				if err := json.Unmarshal([]byte(rec[7]), &e.Metadata); err != nil {
+					return nil, fmt.Errorf("invalid metadata for %s: %v", e.Path, err)
+				}
+			}
+			entries = append(entries, e)
+		}
+		return entries, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var e manifestEntry
+		if err := dec.Decode(&e); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// verifyManifest checks that every file in the manifest exists under dir
+// with the recorded size and SHA-256. It returns the number of mismatches.
+func verifyManifest(dir string, entries []manifestEntry) int {
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Index < entries[j].Index })
+	failed := 0
+	for _, e := range entries {
+		if err := checkManifestEntry(dir, e); err != nil {
+			fmt.Printf("FAIL %s: manifest: %v\n", e.Path, err)
+			failed++
+		}
+	}
+	return failed
+}
+
+func checkManifestEntry(dir string, e manifestEntry) error {
+	f, err := os.Open(filepath.Join(dir, filepath.FromSlash(e.Path)))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return err
+	}
+	if size != e.Size {
+		return fmt.Errorf("size is %d bytes, manifest says %d", size, e.Size)
+	}
+	if sum := hex.EncodeToString(h.Sum(nil)); sum != e.SHA256 {
+		return fmt.Errorf("SHA-256 is %s, manifest says %s", sum, e.SHA256)
+	}
+	return nil
+}
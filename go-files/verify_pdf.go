@@ -0,0 +1,607 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// verifyPDF checks the header and trailer, then follows the chain of
+// cross-reference tables and streams and checks that every object is where
+// they say it is, at an offset or in an object stream. The strings of the
+// document information must read as text once decrypted.
+func verifyPDF(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	header := make([]byte, 8)
+	if _, err := f.ReadAt(header, 0); err != nil || !bytes.HasPrefix(header, []byte("%PDF-1.")) {
+		return fmt.Errorf("missing %%PDF header")
+	}
+
+	tail := make([]byte, min(info.Size(), 1024))
+	if _, err := f.ReadAt(tail, info.Size()-int64(len(tail))); err != nil {
+		return err
+	}
+	if !bytes.HasSuffix(bytes.TrimRight(tail, "\r\n "), []byte("%%EOF")) {
+		return fmt.Errorf("missing %%%%EOF marker")
+	}
+	i := bytes.LastIndex(tail, []byte("startxref"))
+	if i < 0 {
+		return fmt.Errorf("missing startxref")
+	}
+	fields := bytes.Fields(tail[i+len("startxref"):])
+	if len(fields) == 0 {
+		return fmt.Errorf("missing startxref offset")
+	}
+	offset, err := strconv.ParseInt(string(fields[0]), 10, 64)
+	if err != nil || offset <= 0 || offset >= info.Size() {
+		return fmt.Errorf("invalid startxref offset %q", fields[0])
+	}
+
+	// Follow the chain of cross-reference sections. The newest one comes
+	// first and the trailer's /Prev points at the previous one.
+	var sections []*pdfXrefSection
+	seen := make(map[int64]bool)
+	for offset > 0 {
+		if seen[offset] {
+			return fmt.Errorf("cross-reference loop at offset %d", offset)
+		}
+		seen[offset] = true
+
+		section, err := readPDFXref(f, offset, info.Size())
+		if err != nil {
+			return err
+		}
+		sections = append(sections, section)
+		offset = section.prev
+	}
+	size := sections[0].size
+	if !sections[0].hasRoot {
+		return fmt.Errorf("trailer has no /Root")
+	}
+
+	// Encrypted object streams can only be read when the document opens
+	// without a password; otherwise only their framing is checked
+	var crypt *pdfCrypt
+	locked := false
+	if sections[0].encrypt > 0 {
+		if crypt, err = readPDFEncryption(f, sections, info.Size()); err != nil {
+			return err
+		}
+		locked = crypt == nil
+	}
+
+	// Object streams are read once, from their newest version
+	streams := make(map[int][]int)
+	objectStream := func(num int) ([]int, error) {
+		if nums, ok := streams[num]; ok {
+			return nums, nil
+		}
+		for _, section := range sections {
+			if offset, ok := section.objects[num]; ok {
+				nums, _, err := readPDFObjectStream(f, num, offset, info.Size(), crypt)
+				streams[num] = nums
+				return nums, err
+			}
+		}
+		return nil, fmt.Errorf("object stream %d is missing", num)
+	}
+
+	for _, section := range sections {
+		for num, objOffset := range section.objects {
+			if num >= size {
+				return fmt.Errorf("object %d is beyond the trailer /Size %d", num, size)
+			}
+			if err := checkPDFObject(f, num, objOffset); err != nil {
+				return err
+			}
+		}
+		for num, entry := range section.compressed {
+			if num >= size {
+				return fmt.Errorf("object %d is beyond the trailer /Size %d", num, size)
+			}
+			if locked {
+				continue
+			}
+			nums, err := objectStream(entry.stream)
+			if err != nil {
+				return err
+			}
+			if entry.index >= len(nums) || nums[entry.index] != num {
+				return fmt.Errorf("object %d is not at index %d of object stream %d", num, entry.index, entry.stream)
+			}
+		}
+	}
+	if sections[0].info > 0 && !locked {
+		return checkPDFInfo(f, sections, info.Size(), crypt)
+	}
+	return nil
+}
+
+// pdfDictString matches "/Key <hex string>" or "/Key (literal string)"
+var pdfDictString = regexp.MustCompile(`/(\w+)\s*(<[0-9A-Fa-f]*>|\((?:[^()\\]|\\.)*\))`)
+
+// checkPDFInfo checks that the strings of the document information are
+// printable text and its /Producer is the one the generator writes.
+// Strings of objects at an offset are decrypted with their object's key;
+// those in an object stream were decrypted with the stream.
+func checkPDFInfo(f *os.File, sections []*pdfXrefSection, fileSize int64, crypt *pdfCrypt) error {
+	num := sections[0].info
+	var dict string
+	encrypted := false
+	for _, section := range sections {
+		if offset, ok := section.objects[num]; ok {
+			buf := make([]byte, min(64*1024, fileSize-offset))
+			f.ReadAt(buf, offset)
+			end := bytes.Index(buf, []byte("endobj"))
+			if end < 0 {
+				return fmt.Errorf("document information %d is not at offset %d", num, offset)
+			}
+			dict, encrypted = string(buf[:end]), crypt != nil
+			break
+		}
+		if entry, ok := section.compressed[num]; ok {
+			for _, s := range sections {
+				if offset, ok := s.objects[entry.stream]; ok {
+					_, bodies, err := readPDFObjectStream(f, entry.stream, offset, fileSize, crypt)
+					if err != nil {
+						return err
+					}
+					dict = bodies[entry.index]
+					break
+				}
+			}
+			break
+		}
+	}
+	if dict == "" {
+		return fmt.Errorf("document information %d is missing", num)
+	}
+
+	for _, m := range pdfDictString.FindAllStringSubmatch(dict, -1) {
+		value := pdfStringValue(m[2])
+		if encrypted {
+			var err error
+			if value, err = crypt.decrypt(num, value); err != nil {
+				return fmt.Errorf("document information /%s: %v", m[1], err)
+			}
+		}
+		for _, c := range value {
+			if c < 32 || c >= 127 {
+				return fmt.Errorf("document information /%s is not text: %q", m[1], value)
+			}
+		}
+		if m[1] == "Producer" && string(value) != pdfProducer {
+			return fmt.Errorf("document information /Producer is %q, expected %q", value, pdfProducer)
+		}
+	}
+	return nil
+}
+
+// pdfStringValue returns the bytes of a hex or literal string
+func pdfStringValue(s string) []byte {
+	if strings.HasPrefix(s, "<") {
+		value, _ := hex.DecodeString(s[1 : len(s)-1])
+		return value
+	}
+	var value []byte
+	for i := 1; i < len(s)-1; i++ {
+		if s[i] == '\\' {
+			i++
+		}
+		value = append(value, s[i])
+	}
+	return value
+}
+
+// pdfXrefSection is one cross-reference table or stream and its trailer
+type pdfXrefSection struct {
+	objects    map[int]int64        // object number to offset
+	compressed map[int]pdfXrefEntry // object number to object stream and index
+	size       int
+	hasRoot    bool
+	prev       int64
+	info       int    // the document information dictionary, if any
+	encrypt    int    // the encryption dictionary, if encrypted
+	id         []byte // the first element of /ID
+}
+
+// readPDFXref parses the cross-reference table or stream at offset
+func readPDFXref(f *os.File, offset, fileSize int64) (*pdfXrefSection, error) {
+	r := bufio.NewReader(io.NewSectionReader(f, offset, fileSize-offset))
+	line, _ := r.ReadString('\n')
+	if strings.TrimSpace(line) != "xref" {
+		return readPDFXrefStream(f, offset, fileSize)
+	}
+
+	section := &pdfXrefSection{objects: make(map[int]int64)}
+	var trailer strings.Builder
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("truncated cross-reference table")
+		}
+		line = strings.TrimSpace(line)
+		if rest, ok := strings.CutPrefix(line, "trailer"); ok {
+			trailer.WriteString(rest)
+			break
+		}
+		var start, count int
+		if _, err := fmt.Sscanf(line, "%d %d", &start, &count); err != nil {
+			return nil, fmt.Errorf("invalid cross-reference subsection %q", line)
+		}
+		entry := make([]byte, 20)
+		for n := start; n < start+count; n++ {
+			if _, err := io.ReadFull(r, entry); err != nil {
+				return nil, fmt.Errorf("truncated cross-reference table")
+			}
+			var objOffset int64
+			var gen int
+			var kind byte
+			if _, err := fmt.Sscanf(string(entry[:18]), "%010d %05d %c", &objOffset, &gen, &kind); err != nil {
+				return nil, fmt.Errorf("invalid cross-reference entry %q", entry)
+			}
+			if kind == 'n' {
+				section.objects[n] = objOffset
+			}
+		}
+	}
+
+	for {
+		line, err := r.ReadString('\n')
+		if strings.HasPrefix(strings.TrimSpace(line), "startxref") || err != nil {
+			break
+		}
+		trailer.WriteString(line)
+	}
+	if err := section.readTrailer(trailer.String()); err != nil {
+		return nil, err
+	}
+	return section, nil
+}
+
+// readTrailer takes /Size, /Prev, /Root, /Info, /Encrypt and /ID from a trailer
+// dictionary
+func (section *pdfXrefSection) readTrailer(dict string) error {
+	numbers := pdfDictNumbers(dict)
+	size, ok := numbers["Size"]
+	if !ok {
+		return fmt.Errorf("trailer has no /Size")
+	}
+	section.size = int(size)
+	section.prev = numbers["Prev"]
+	section.hasRoot = strings.Contains(dict, "/Root")
+	if m := pdfInfoRef.FindStringSubmatch(dict); m != nil {
+		section.info, _ = strconv.Atoi(m[1])
+	}
+	if m := pdfEncryptRef.FindStringSubmatch(dict); m != nil {
+		section.encrypt, _ = strconv.Atoi(m[1])
+		m = pdfTrailerID.FindStringSubmatch(dict)
+		if m == nil {
+			return fmt.Errorf("encrypted document has no /ID")
+		}
+		section.id, _ = hex.DecodeString(m[1])
+	}
+	return nil
+}
+
+// readPDFEncryption reads the encryption dictionary of the standard
+// security handler and authenticates with the empty user password. It
+// returns nil if the document needs a password.
+func readPDFEncryption(f *os.File, sections []*pdfXrefSection, fileSize int64) (*pdfCrypt, error) {
+	num := sections[0].encrypt
+	var dict string
+	for _, section := range sections {
+		if offset, ok := section.objects[num]; ok {
+			buf := make([]byte, min(4096, fileSize-offset))
+			f.ReadAt(buf, offset)
+			end := bytes.Index(buf, []byte("endobj"))
+			if end < 0 {
+				return nil, fmt.Errorf("encryption dictionary %d is not at offset %d", num, offset)
+			}
+			dict = string(buf[:end])
+			break
+		}
+	}
+	if !strings.Contains(dict, "/Filter /Standard") {
+		return nil, fmt.Errorf("encryption dictionary %d is missing or not for the standard security handler", num)
+	}
+
+	numbers := pdfDictNumbers(dict)
+	method, ok := map[int64]string{1: "rc4-40", 2: "rc4-128", 4: "aes-128", 5: "aes-256"}[numbers["V"]]
+	if !ok {
+		return nil, fmt.Errorf("unsupported encryption version %d", numbers["V"])
+	}
+	strs := make(map[string][]byte)
+	for _, m := range pdfDictHex.FindAllStringSubmatch(dict, -1) {
+		strs[m[1]], _ = hex.DecodeString(m[2])
+	}
+	if len(strs["O"]) < 32 || len(strs["U"]) < 32 {
+		return nil, fmt.Errorf("encryption dictionary %d has no valid /O and /U", num)
+	}
+	crypt, ok := authenticatePdfCrypt(method, nil, strs["O"], strs["U"], strs["UE"], int32(numbers["P"]), sections[0].id)
+	if !ok {
+		return nil, nil
+	}
+	return crypt, nil
+}
+
+// readPDFXrefStream parses the cross-reference stream at offset. Each
+// entry is a type and two fields, big-endian with the widths in /W.
+func readPDFXrefStream(f *os.File, offset, fileSize int64) (*pdfXrefSection, error) {
+	_, dict, data, err := readPDFStream(f, offset, fileSize, nil)
+	if err != nil {
+		return nil, fmt.Errorf("no cross-reference table or stream at offset %d: %v", offset, err)
+	}
+	if m := pdfTypePattern.FindStringSubmatch(dict); m == nil || m[1] != "XRef" {
+		return nil, fmt.Errorf("object at offset %d is not a cross-reference stream", offset)
+	}
+
+	section := &pdfXrefSection{objects: make(map[int]int64), compressed: make(map[int]pdfXrefEntry)}
+	if err := section.readTrailer(dict); err != nil {
+		return nil, err
+	}
+	arrays := pdfDictArrays(dict)
+	widths := arrays["W"]
+	if len(widths) != 3 {
+		return nil, fmt.Errorf("cross-reference stream has no valid /W")
+	}
+	index, ok := arrays["Index"]
+	if !ok {
+		index = []int64{0, int64(section.size)}
+	}
+	if len(index)%2 != 0 {
+		return nil, fmt.Errorf("cross-reference stream has an odd /Index")
+	}
+
+	rowSize := int(widths[0] + widths[1] + widths[2])
+	field := func(row []byte, i int) int64 {
+		start := 0
+		for _, w := range widths[:i] {
+			start += int(w)
+		}
+		var v int64
+		for _, b := range row[start : start+int(widths[i])] {
+			v = v<<8 | int64(b)
+		}
+		return v
+	}
+	for i := 0; i < len(index); i += 2 {
+		for n := index[i]; n < index[i]+index[i+1]; n++ {
+			if len(data) < rowSize {
+				return nil, fmt.Errorf("truncated cross-reference stream")
+			}
+			row := data[:rowSize]
+			data = data[rowSize:]
+			kind := int64(1) // the default when the type has no width
+			if widths[0] > 0 {
+				kind = field(row, 0)
+			}
+			switch kind {
+			case 0:
+			case 1:
+				section.objects[int(n)] = field(row, 1)
+			case 2:
+				section.compressed[int(n)] = pdfXrefEntry{stream: int(field(row, 1)), index: int(field(row, 2))}
+			default:
+				return nil, fmt.Errorf("invalid cross-reference stream entry type %d", kind)
+			}
+		}
+	}
+	return section, nil
+}
+
+// readPDFObjectStream returns the numbers and bodies of the objects in
+// the object stream num at offset, in order, decrypting it with crypt if
+// set
+func readPDFObjectStream(f *os.File, num int, offset, fileSize int64, crypt *pdfCrypt) ([]int, []string, error) {
+	got, dict, data, err := readPDFStream(f, offset, fileSize, crypt)
+	if err != nil || got != num {
+		return nil, nil, fmt.Errorf("object stream %d is not at offset %d", num, offset)
+	}
+	if m := pdfTypePattern.FindStringSubmatch(dict); m == nil || m[1] != "ObjStm" {
+		return nil, nil, fmt.Errorf("object %d is not an object stream", num)
+	}
+	numbers := pdfDictNumbers(dict)
+	n, first := int(numbers["N"]), numbers["First"]
+	if first <= 0 || first > int64(len(data)) {
+		return nil, nil, fmt.Errorf("object stream %d has an invalid /First", num)
+	}
+
+	fields := strings.Fields(string(data[:first]))
+	if len(fields) != 2*n {
+		return nil, nil, fmt.Errorf("object stream %d lists %d numbers, expected %d", num, len(fields), 2*n)
+	}
+	nums := make([]int, n)
+	starts := make([]int64, n+1)
+	last := int64(-1)
+	for i := range nums {
+		var objOffset int64
+		nums[i], err = strconv.Atoi(fields[2*i])
+		if err == nil {
+			objOffset, err = strconv.ParseInt(fields[2*i+1], 10, 64)
+		}
+		if err != nil || objOffset <= last || first+objOffset >= int64(len(data)) {
+			return nil, nil, fmt.Errorf("object stream %d has an invalid entry %d", num, i)
+		}
+		starts[i] = first + objOffset
+		last = objOffset
+	}
+	starts[n] = int64(len(data))
+	bodies := make([]string, n)
+	for i := range bodies {
+		bodies[i] = string(data[starts[i]:starts[i+1]])
+	}
+	return nums, bodies, nil
+}
+
+// pdfStreamLength matches a direct or indirect /Length
+var pdfStreamLength = regexp.MustCompile(`/Length\s+(\d+)(\s+\d+\s+R)?`)
+
+// readPDFStream reads the stream object at offset and returns its number,
+// dictionary and decoded data, decrypted first if crypt is set. Only
+// direct lengths and the FlateDecode filter, with or without a PNG
+// predictor, are supported.
+func readPDFStream(f *os.File, offset, fileSize int64, crypt *pdfCrypt) (int, string, []byte, error) {
+	r := bufio.NewReader(io.NewSectionReader(f, offset, fileSize-offset))
+	var head strings.Builder
+	for !strings.HasSuffix(strings.TrimRight(head.String(), "\r\n"), "stream") {
+		line, err := r.ReadString('\n')
+		head.WriteString(line)
+		if err != nil || head.Len() > 64*1024 {
+			return 0, "", nil, fmt.Errorf("no stream at offset %d", offset)
+		}
+	}
+	var num, gen int
+	if _, err := fmt.Sscanf(head.String(), "%d %d obj", &num, &gen); err != nil {
+		return 0, "", nil, fmt.Errorf("no object at offset %d", offset)
+	}
+	dict := strings.TrimSuffix(strings.TrimRight(head.String(), "\r\n"), "stream")
+
+	m := pdfStreamLength.FindStringSubmatch(dict)
+	if m == nil || m[2] != "" {
+		return 0, "", nil, fmt.Errorf("object %d has no direct /Length", num)
+	}
+	length, _ := strconv.Atoi(m[1])
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return 0, "", nil, fmt.Errorf("object %d: truncated stream", num)
+	}
+	end := make([]byte, 12)
+	n, _ := io.ReadFull(r, end)
+	if !bytes.HasPrefix(bytes.TrimLeft(end[:n], "\r\n "), []byte("endstream")) {
+		return 0, "", nil, fmt.Errorf("object %d: stream does not end at its /Length", num)
+	}
+	if crypt != nil {
+		var err error
+		if data, err = crypt.decrypt(num, data); err != nil {
+			return 0, "", nil, fmt.Errorf("object %d: %v", num, err)
+		}
+	}
+
+	if !strings.Contains(dict, "/Filter") {
+		return num, dict, data, nil
+	}
+	if !strings.Contains(dict, "/FlateDecode") {
+		return 0, "", nil, fmt.Errorf("object %d: unsupported stream filter", num)
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return 0, "", nil, fmt.Errorf("object %d: %v", num, err)
+	}
+	if data, err = io.ReadAll(zr); err != nil {
+		return 0, "", nil, fmt.Errorf("object %d: %v", num, err)
+	}
+
+	numbers := pdfDictNumbers(dict)
+	if numbers["Predictor"] >= 10 {
+		if data, err = unpredictPNG(data, int(numbers["Columns"])); err != nil {
+			return 0, "", nil, fmt.Errorf("object %d: %v", num, err)
+		}
+	}
+	return num, dict, data, nil
+}
+
+// unpredictPNG reverses the PNG predictors None, Sub and Up on rows of
+// columns bytes, each preceded by its predictor
+func unpredictPNG(data []byte, columns int) ([]byte, error) {
+	if columns <= 0 || len(data)%(columns+1) != 0 {
+		return nil, fmt.Errorf("predicted data does not fit %d columns", columns)
+	}
+	var out []byte
+	prev := make([]byte, columns)
+	for len(data) > 0 {
+		predictor, row := data[0], append([]byte(nil), data[1:columns+1]...)
+		data = data[columns+1:]
+		for i := range row {
+			switch predictor {
+			case 0:
+			case 1:
+				if i > 0 {
+					row[i] += row[i-1]
+				}
+			case 2:
+				row[i] += prev[i]
+			default:
+				return nil, fmt.Errorf("unsupported PNG predictor %d", predictor)
+			}
+		}
+		out = append(out, row...)
+		prev = row
+	}
+	return out, nil
+}
+
+// checkPDFObject checks that "num gen obj" starts at offset
+func checkPDFObject(f *os.File, num int, offset int64) error {
+	buf := make([]byte, 32)
+	n, _ := f.ReadAt(buf, offset)
+	var gotNum, gen int
+	if _, err := fmt.Sscanf(string(buf[:n]), "%d %d obj", &gotNum, &gen); err != nil || gotNum != num {
+		return fmt.Errorf("object %d is not at offset %d", num, offset)
+	}
+	return nil
+}
+
+// pdfTypePattern matches the /Type of a dictionary
+var pdfTypePattern = regexp.MustCompile(`/Type\s*/(\w+)`)
+
+// pdfDictNumber matches "/Key 123" or "/Key -123", but not the start of
+// "/Key 1 0 R"
+var pdfDictNumber = regexp.MustCompile(`/(\w+)\s+(-?\d+)(\s+\d+\s+R)?`)
+
+// pdfDictHex matches "/Key <hex string>"
+var pdfDictHex = regexp.MustCompile(`/(\w+)\s*<([0-9A-Fa-f]*)>`)
+
+// pdfInfoRef, pdfEncryptRef and pdfTrailerID match the /Info and /Encrypt
+// references and the first element of the /ID of a trailer
+var (
+	pdfInfoRef    = regexp.MustCompile(`/Info\s+(\d+)\s+\d+\s+R`)
+	pdfEncryptRef = regexp.MustCompile(`/Encrypt\s+(\d+)\s+\d+\s+R`)
+	pdfTrailerID  = regexp.MustCompile(`/ID\s*\[\s*<([0-9A-Fa-f]*)>`)
+)
+
+// pdfDictArray matches "/Key [...]"
+var pdfDictArray = regexp.MustCompile(`/(\w+)\s*\[([^\]]*)\]`)
+
+// pdfDictNumbers returns the integer values of a dictionary, including
+// those of nested dictionaries
+func pdfDictNumbers(dict string) map[string]int64 {
+	numbers := make(map[string]int64)
+	for _, m := range pdfDictNumber.FindAllStringSubmatch(dict, -1) {
+		if m[3] == "" {
+			numbers[m[1]], _ = strconv.ParseInt(m[2], 10, 64)
+		}
+	}
+	return numbers
+}
+
+// pdfDictArrays returns the arrays of integers of a dictionary
+func pdfDictArrays(dict string) map[string][]int64 {
+	arrays := make(map[string][]int64)
+	for _, m := range pdfDictArray.FindAllStringSubmatch(dict, -1) {
+		var values []int64
+		for _, field := range strings.Fields(m[2]) {
+			if v, err := strconv.ParseInt(field, 10, 64); err == nil {
+				values = append(values, v)
+			}
+		}
+		arrays[m[1]] = values
+	}
+	return arrays
+}
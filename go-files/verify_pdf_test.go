@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifyPdfOptions(t *testing.T) {
+	for _, encryption := range pdfEncryptions {
+		for _, objectStreams := range []bool{false, true} {
+			for _, forms := range []int{0, 2} {
+				g := &PdfGenerator{
+					Encryption:    encryption,
+					ObjectStreams: objectStreams,
+					Compress:      objectStreams,
+					Info:          true,
+					Forms:         forms,
+					Prefill:       forms > 0,
+					Outline:       true,
+					Annotations:   true,
+					Updates:       forms / 2,
+				}
+				name := fmt.Sprintf("%s objstm=%v forms=%d", encryption, objectStreams, forms)
+				data, err := g.Generate(newFileRand(11, forms+1), 24*1024)
+				if err != nil {
+					t.Fatalf("%s: %v", name, err)
+				}
+				file := filepath.Join(t.TempDir(), "file.pdf")
+				if err := os.WriteFile(file, data, 0644); err != nil {
+					t.Fatal(err)
+				}
+				if err := verifyFile(file); err != nil {
+					t.Errorf("%s: %v", name, err)
+				}
+			}
+		}
+	}
+}
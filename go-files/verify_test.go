@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestVerifyGeneratedFiles(t *testing.T) {
+	for _, exact := range []bool{false, true} {
+		cfg := testConfig(t, strings.Join(SupportedExtensions(), ","), 3*len(SupportedExtensions()), 1024, 48*1024)
+		cfg.ExactSize = exact
+		for i := range cfg.Extensions {
+			cfg.Extensions[i].Count = 3
+		}
+		cfg.plan = planExtensions(cfg.Seed, cfg.NumFiles, cfg.Extensions)
+		if err := execute(cfg); err != nil {
+			t.Fatal(err)
+		}
+		sum, err := verifyDir(cfg.OutDir, func(string) bool { return false })
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sum.Failed != 0 || sum.Skipped != 0 || sum.Passed != cfg.NumFiles {
+			t.Errorf("exact %v: %+v, want %d passed", exact, sum, cfg.NumFiles)
+		}
+	}
+}
+
+func TestVerifyCorruptFiles(t *testing.T) {
+	startxref := regexp.MustCompile(`startxref\s+\d+`)
+	tests := []struct {
+		ext     string
+		corrupt func([]byte) []byte
+	}{
+		{"csv", func(b []byte) []byte {
+			// Cut the last record after its first field
+			last := bytes.LastIndexByte(b[:len(b)-1], '\n')
+			return b[:last+1+bytes.IndexByte(b[last+1:], ',')+1]
+		}},
+		{"json", func(b []byte) []byte { return bytes.TrimRight(bytes.TrimSpace(b), "]") }},
+		{"png", func(b []byte) []byte { b[len(b)/2] ^= 0x40; return b }},
+		{"docx", func(b []byte) []byte { return b[:len(b)/2] }},
+		{"pdf", func(b []byte) []byte { return startxref.ReplaceAll(b, []byte("startxref\n17")) }},
+		{"md", func(b []byte) []byte { return append(b, "\n```go\nfunc main() {}\n"...) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ext, func(t *testing.T) {
+			data, err := NewGenerator(tt.ext).Generate(newFileRand(5, 1), 16*1024)
+			if err != nil {
+				t.Fatal(err)
+			}
+			name := filepath.Join(t.TempDir(), "file."+tt.ext)
+			if err := os.WriteFile(name, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := verifyFile(name); err != nil {
+				t.Fatalf("intact file: %v", err)
+			}
+			if err := os.WriteFile(name, tt.corrupt(data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := verifyFile(name); err == nil {
+				t.Error("corrupt file passed")
+			}
+		})
+	}
+}
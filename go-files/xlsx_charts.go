@@ -0,0 +1,285 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// xlsxChartKinds are the charts the summary sheet can have
+var xlsxChartKinds = []string{"bar", "line", "pie"}
+
+// Column labels of the employee and sales sheets, which are also the
+// column names of their tables
+var (
+	xlsxEmployeeColumns = []string{"ID", "Name", "Email", "Department", "Start Date", "Salary", "Bonus"}
+	xlsxSalesColumns    = []string{"Date", "Employee ID", "Employee", "Region", "Amount", "Discount", "Net"}
+)
+
+// Layout of the summary's department block, which the bar and pie charts
+// plot, and of the charts to its right
+const (
+	xlsxDepartmentRow = xlsxFirstRow + 8 // header row of the department block, below the metrics
+	xlsxChartColumn   = 4                // column E, where the charts start
+	xlsxChartWidth    = 8                // columns per chart
+	xlsxChartHeight   = 16               // rows per chart
+)
+
+// Relationship types and content types of the table and chart parts, and
+// the elements that link a sheet to its table or drawing
+const (
+	xlsxRelTable       = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/table"
+	xlsxRelDrawing     = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/drawing"
+	xlsxRelChart       = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/chart"
+	xlsxTableContent   = "application/vnd.openxmlformats-officedocument.spreadsheetml.table+xml"
+	xlsxDrawingContent = "application/vnd.openxmlformats-officedocument.drawing+xml"
+	xlsxChartContent   = "application/vnd.openxmlformats-officedocument.drawingml.chart+xml"
+	xlsxTablePart      = "\n  <tableParts count=\"1\"><tablePart r:id=\"rId1\"/></tableParts>"
+	xlsxDrawingPart    = "\n  <drawing r:id=\"rId1\"/>"
+)
+
+// xlsxDepartment is a row of the summary's department block
+type xlsxDepartment struct {
+	name      string
+	headcount int
+	payroll   int
+}
+
+// xlsxSeries is the data of a chart: one series of values with category
+// labels, both referred to by range and cached
+type xlsxSeries struct {
+	title, name, nameRef string
+	catsRef, valuesRef   string
+	cats, values         []string
+}
+
+// departments returns the headcount and payroll of every department
+func (wb *xlsxWorkbook) departments() []xlsxDepartment {
+	departments := make([]xlsxDepartment, len(wb.departmentNames))
+	for i, name := range wb.departmentNames {
+		departments[i].name = name
+		for _, e := range wb.employees {
+			if e.department == name {
+				departments[i].headcount++
+				departments[i].payroll += e.salary
+			}
+		}
+	}
+	return departments
+}
+
+// departmentRows is the summary's department block, with COUNTIF and SUMIF
+// formulas over the employees
+func (wb *xlsxWorkbook) departmentRows() string {
+	first, last := xlsxFirstRow, xlsxFirstRow+len(wb.employees)-1
+	var b strings.Builder
+	b.WriteString(xlsxRow(xlsxDepartmentRow,
+		wb.shared(0, xlsxDepartmentRow, "Department", xlsxStyleHeader),
+		wb.shared(1, xlsxDepartmentRow, "Headcount", xlsxStyleHeader),
+		wb.shared(2, xlsxDepartmentRow, "Payroll", xlsxStyleHeader)))
+	for i, d := range wb.departments() {
+		row := xlsxDepartmentRow + 1 + i
+		b.WriteString(xlsxRow(row,
+			wb.shared(0, row, d.name, xlsxStyleDefault),
+			xlsxFormula(1, row, fmt.Sprintf("COUNTIF(Employees!$D$%d:$D$%d,A%d)", first, last, row),
+				strconv.Itoa(d.headcount), xlsxStyleDefault),
+			xlsxFormula(2, row, fmt.Sprintf("SUMIF(Employees!$D$%d:$D$%d,A%d,Employees!$F$%d:$F$%d)", first, last, row, first, last),
+				strconv.Itoa(d.payroll), xlsxStyleCurrency)))
+	}
+	return b.String()
+}
+
+// chartSeries returns the data of a chart of the given kind: payroll by
+// department for bar charts, headcount by department for pie charts and
+// salary by employee for line charts
+func (wb *xlsxWorkbook) chartSeries(kind string) xlsxSeries {
+	if kind == "line" {
+		first, last := xlsxFirstRow, xlsxFirstRow+len(wb.employees)-1
+		s := xlsxSeries{
+			title:     "Salary by Employee",
+			name:      "Salary",
+			nameRef:   fmt.Sprintf("Employees!$F$%d", xlsxFirstRow-1),
+			catsRef:   fmt.Sprintf("Employees!$B$%d:$B$%d", first, last),
+			valuesRef: fmt.Sprintf("Employees!$F$%d:$F$%d", first, last),
+		}
+		for _, e := range wb.employees {
+			s.cats = append(s.cats, e.name)
+			s.values = append(s.values, strconv.Itoa(e.salary))
+		}
+		return s
+	}
+
+	first, last := xlsxDepartmentRow+1, xlsxDepartmentRow+len(wb.departmentNames)
+	column := "C"
+	s := xlsxSeries{title: "Payroll by Department", name: "Payroll"}
+	if kind == "pie" {
+		column = "B"
+		s.title, s.name = "Employees by Department", "Headcount"
+	}
+	s.nameRef = fmt.Sprintf("Summary!$%s$%d", column, xlsxDepartmentRow)
+	s.catsRef = fmt.Sprintf("Summary!$A$%d:$A$%d", first, last)
+	s.valuesRef = fmt.Sprintf("Summary!$%s$%d:$%s$%d", column, first, column, last)
+	for _, d := range wb.departments() {
+		s.cats = append(s.cats, d.name)
+		if kind == "pie" {
+			s.values = append(s.values, strconv.Itoa(d.headcount))
+		} else {
+			s.values = append(s.values, strconv.Itoa(d.payroll))
+		}
+	}
+	return s
+}
+
+// chart is xl/charts/chart<n>.xml, a chart of the given kind with a title
+// and a legend. Bar and line charts have a category and a value axis.
+func (wb *xlsxWorkbook) chart(kind string) string {
+	s := wb.chartSeries(kind)
+	var b strings.Builder
+	fmt.Fprintf(&b, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart" xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+  <c:roundedCorners val="0"/>
+  <c:chart>
+    <c:title><c:tx><c:rich><a:bodyPr/><a:p><a:r><a:t>%s</a:t></a:r></a:p></c:rich></c:tx><c:overlay val="0"/></c:title>
+    <c:autoTitleDeleted val="0"/>
+    <c:plotArea>
+      <c:layout/>`, s.title)
+	switch kind {
+	case "bar":
+		b.WriteString(`
+      <c:barChart><c:barDir val="col"/><c:grouping val="clustered"/><c:varyColors val="0"/>`)
+	case "line":
+		b.WriteString(`
+      <c:lineChart><c:grouping val="standard"/><c:varyColors val="0"/>`)
+	case "pie":
+		b.WriteString(`
+      <c:pieChart><c:varyColors val="1"/>`)
+	}
+
+	// The series, with its name, categories and values cached
+	fmt.Fprintf(&b, `
+        <c:ser><c:idx val="0"/><c:order val="0"/>
+          <c:tx><c:strRef><c:f>%s</c:f><c:strCache><c:ptCount val="1"/><c:pt idx="0"><c:v>%s</c:v></c:pt></c:strCache></c:strRef></c:tx>`,
+		s.nameRef, s.name)
+	if kind == "line" {
+		b.WriteString(`
+          <c:marker><c:symbol val="circle"/></c:marker>`)
+	}
+	fmt.Fprintf(&b, `
+          <c:cat><c:strRef><c:f>%s</c:f><c:strCache><c:ptCount val="%d"/>`, s.catsRef, len(s.cats))
+	for i, cat := range s.cats {
+		fmt.Fprintf(&b, `<c:pt idx="%d"><c:v>%s</c:v></c:pt>`, i, xmlEscape(cat))
+	}
+	fmt.Fprintf(&b, `</c:strCache></c:strRef></c:cat>
+          <c:val><c:numRef><c:f>%s</c:f><c:numCache><c:formatCode>General</c:formatCode><c:ptCount val="%d"/>`, s.valuesRef, len(s.values))
+	for i, value := range s.values {
+		fmt.Fprintf(&b, `<c:pt idx="%d"><c:v>%s</c:v></c:pt>`, i, value)
+	}
+	b.WriteString(`</c:numCache></c:numRef></c:val>`)
+	if kind == "line" {
+		b.WriteString(`<c:smooth val="0"/>`)
+	}
+	b.WriteString(`
+        </c:ser>`)
+
+	switch kind {
+	case "bar":
+		b.WriteString(`
+        <c:gapWidth val="150"/><c:axId val="1"/><c:axId val="2"/>
+      </c:barChart>`)
+	case "line":
+		b.WriteString(`
+        <c:marker val="1"/><c:axId val="1"/><c:axId val="2"/>
+      </c:lineChart>`)
+	case "pie":
+		b.WriteString(`
+        <c:firstSliceAng val="0"/>
+      </c:pieChart>`)
+	}
+	if kind != "pie" {
+		b.WriteString(`
+      <c:catAx><c:axId val="1"/><c:scaling><c:orientation val="minMax"/></c:scaling><c:delete val="0"/><c:axPos val="b"/><c:numFmt formatCode="General" sourceLinked="0"/><c:tickLblPos val="nextTo"/><c:crossAx val="2"/><c:crosses val="autoZero"/><c:auto val="1"/><c:lblAlgn val="ctr"/><c:lblOffset val="100"/></c:catAx>
+      <c:valAx><c:axId val="2"/><c:scaling><c:orientation val="minMax"/></c:scaling><c:delete val="0"/><c:axPos val="l"/><c:majorGridlines/><c:numFmt formatCode="General" sourceLinked="1"/><c:tickLblPos val="nextTo"/><c:crossAx val="1"/><c:crosses val="autoZero"/><c:crossBetween val="between"/></c:valAx>`)
+	}
+	b.WriteString(`
+    </c:plotArea>
+    <c:legend><c:legendPos val="r"/><c:overlay val="0"/></c:legend>
+    <c:plotVisOnly val="1"/>
+    <c:dispBlanksAs val="gap"/>
+  </c:chart>
+</c:chartSpace>`)
+	return b.String()
+}
+
+// xlsxDrawing is xl/drawings/drawing1.xml, which places the charts below
+// each other to the right of the summary. Chart i is relationship
+// rId<i+1> of the drawing.
+func xlsxDrawing(charts []string) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<xdr:wsDr xmlns:xdr="http://schemas.openxmlformats.org/drawingml/2006/spreadsheetDrawing" xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main">`)
+	for i, kind := range charts {
+		top := 1 + i*xlsxChartHeight
+		fmt.Fprintf(&b, `
+  <xdr:twoCellAnchor>
+    <xdr:from><xdr:col>%d</xdr:col><xdr:colOff>0</xdr:colOff><xdr:row>%d</xdr:row><xdr:rowOff>0</xdr:rowOff></xdr:from>
+    <xdr:to><xdr:col>%d</xdr:col><xdr:colOff>0</xdr:colOff><xdr:row>%d</xdr:row><xdr:rowOff>0</xdr:rowOff></xdr:to>
+    <xdr:graphicFrame macro="">
+      <xdr:nvGraphicFramePr><xdr:cNvPr id="%d" name="Chart %d" descr="%s chart"/><xdr:cNvGraphicFramePr/></xdr:nvGraphicFramePr>
+      <xdr:xfrm><a:off x="0" y="0"/><a:ext cx="0" cy="0"/></xdr:xfrm>
+      <a:graphic><a:graphicData uri="http://schemas.openxmlformats.org/drawingml/2006/chart"><c:chart xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" r:id="rId%d"/></a:graphicData></a:graphic>
+    </xdr:graphicFrame>
+    <xdr:clientData/>
+  </xdr:twoCellAnchor>`,
+			xlsxChartColumn, top, xlsxChartColumn+xlsxChartWidth, top+xlsxChartHeight-1, i+2, i+1, kind, i+1)
+	}
+	b.WriteString("\n</xdr:wsDr>")
+	return b.String()
+}
+
+// xlsxTable is xl/tables/table<id>.xml, a table with a header row and the
+// given columns over ref, with autofilter buttons and banded rows
+func xlsxTable(id int, name, ref string, columns []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<table %s id="%d" name="%s" displayName="%s" ref="%s" totalsRowShown="0">
+  <autoFilter ref="%s"/>
+  <tableColumns count="%d">`, xlsxMainNS, id, name, name, ref, ref, len(columns))
+	for i, column := range columns {
+		fmt.Fprintf(&b, `<tableColumn id="%d" name="%s"/>`, i+1, column)
+	}
+	b.WriteString(`</tableColumns>
+  <tableStyleInfo name="TableStyleMedium2" showFirstColumn="0" showLastColumn="0" showRowStripes="1" showColumnStripes="0"/>
+</table>`)
+	return b.String()
+}
+
+// employeeTable is the table over the employees, without the total row
+func (wb *xlsxWorkbook) employeeTable() string {
+	ref := fmt.Sprintf("A%d:G%d", xlsxFirstRow-1, xlsxFirstRow+len(wb.employees)-1)
+	return xlsxTable(1, "EmployeeTable", ref, xlsxEmployeeColumns)
+}
+
+// salesTable is the table over sales sheet i, without the total row. A
+// table needs a data row, so one over a sheet without sales takes in the
+// total row.
+func salesTable(i int, t xlsxTotals) string {
+	name := "SalesTable"
+	if i > 0 {
+		name += strconv.Itoa(i + 1)
+	}
+	ref := fmt.Sprintf("A%d:G%d", xlsxFirstRow-1, xlsxFirstRow+max(t.rows, 1)-1)
+	return xlsxTable(2+i, name, ref, xlsxSalesColumns)
+}
+
+// xlsxRels is a relationships part with targets rId1 and on of one type
+func xlsxRels(relType string, targets ...string) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i, target := range targets {
+		fmt.Fprintf(&b, `
+  <Relationship Id="rId%d" Type="%s" Target="%s"/>`, i+1, relType, target)
+	}
+	b.WriteString("\n</Relationships>")
+	return b.String()
+}
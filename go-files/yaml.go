@@ -0,0 +1,365 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// parseYAML parses the subset of YAML used by spec files into the same
+// generic values encoding/json produces: map[string]any, []any, string,
+// bool, nil and numbers. Supported are block mappings and sequences,
+// flow collections ([a, b] and {k: v}), quoted and plain scalars, and
+// comments. Anchors, tags and multi-line scalars are not supported.
+func parseYAML(data []byte) (any, error) {
+	p := &yamlParser{}
+	for i, raw := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		text := stripYAMLComment(raw)
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" || trimmed == "---" {
+			continue
+		}
+		if strings.ContainsRune(text[:len(text)-len(strings.TrimLeft(text, " \t"))], '\t') {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", i+1)
+		}
+		indent := len(text) - len(strings.TrimLeft(text, " "))
+		p.lines = append(p.lines, yamlLine{num: i + 1, indent: indent, text: strings.TrimRight(text[indent:], " ")})
+	}
+	if len(p.lines) == 0 {
+		return nil, nil
+	}
+
+	value, err := p.parseNode(p.lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", p.lines[p.pos].num)
+	}
+	return value, nil
+}
+
+type yamlLine struct {
+	num    int
+	indent int
+	text   string
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+// parseNode parses the block starting at the current line
+func (p *yamlParser) parseNode(indent int) (any, error) {
+	if isYAMLSequenceItem(p.lines[p.pos].text) {
+		return p.parseSequence(indent)
+	}
+	return p.parseMapping(indent)
+}
+
+// parseSequence parses "- item" lines at the given indentation
+func (p *yamlParser) parseSequence(indent int) (any, error) {
+	items := []any{}
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent != indent || !isYAMLSequenceItem(line.text) {
+			break
+		}
+		rest := strings.TrimLeft(line.text[1:], " ")
+		switch {
+		case rest == "":
+			// The item is the nested block on the following lines
+			p.pos++
+			value, err := p.parseNested(indent)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, value)
+		case isYAMLSequenceItem(rest) || isYAMLMappingEntry(rest):
+			// "- key: value" starts a mapping indented to the key, and
+			// "- - item" a sequence indented to the inner dash
+			offset := len(line.text) - len(rest)
+			p.lines[p.pos] = yamlLine{num: line.num, indent: indent + offset, text: rest}
+			value, err := p.parseNode(indent + offset)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, value)
+		default:
+			value, err := parseYAMLValue(rest, line.num)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, value)
+			p.pos++
+		}
+	}
+	return items, nil
+}
+
+// parseMapping parses "key: value" lines at the given indentation
+func (p *yamlParser) parseMapping(indent int) (any, error) {
+	m := map[string]any{}
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent != indent {
+			if line.indent > indent {
+				return nil, fmt.Errorf("line %d: unexpected indentation", line.num)
+			}
+			break
+		}
+		if !isYAMLMappingEntry(line.text) {
+			return nil, fmt.Errorf("line %d: expected \"key: value\"", line.num)
+		}
+		key, rest := splitYAMLMappingEntry(line.text)
+		if _, dup := m[key]; dup {
+			return nil, fmt.Errorf("line %d: duplicate key %q", line.num, key)
+		}
+		p.pos++
+
+		if rest != "" {
+			value, err := parseYAMLValue(rest, line.num)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = value
+			continue
+		}
+
+		// A sequence may sit at the same indentation as its key
+		if p.pos < len(p.lines) && p.lines[p.pos].indent == indent && isYAMLSequenceItem(p.lines[p.pos].text) {
+			value, err := p.parseSequence(indent)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = value
+			continue
+		}
+		value, err := p.parseNested(indent)
+		if err != nil {
+			return nil, err
+		}
+		m[key] = value
+	}
+	return m, nil
+}
+
+// parseNested parses the block indented deeper than parent, or returns nil
+// if there is none
+func (p *yamlParser) parseNested(parent int) (any, error) {
+	if p.pos >= len(p.lines) || p.lines[p.pos].indent <= parent {
+		return nil, nil
+	}
+	return p.parseNode(p.lines[p.pos].indent)
+}
+
+func isYAMLSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+func isYAMLMappingEntry(text string) bool {
+	if text == "" || text[0] == '[' || text[0] == '{' {
+		return false
+	}
+	key, _ := splitYAMLMappingEntry(text)
+	return key != ""
+}
+
+// splitYAMLMappingEntry splits "key: value" at the first colon that is
+// followed by a space or ends the line, outside of quotes
+func splitYAMLMappingEntry(text string) (string, string) {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ':' && (i+1 == len(text) || text[i+1] == ' '):
+			key := strings.TrimSpace(text[:i])
+			if unquoted, err := parseYAMLScalar(key, 0); err == nil {
+				if s, ok := unquoted.(string); ok {
+					key = s
+				}
+			}
+			return key, strings.TrimSpace(text[i+1:])
+		}
+	}
+	return "", ""
+}
+
+// stripYAMLComment removes a trailing "# comment" outside of quotes
+func stripYAMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// parseYAMLValue parses an inline value: a flow collection or a scalar
+func parseYAMLValue(text string, lineNum int) (any, error) {
+	if text == "|" || text == ">" || strings.HasPrefix(text, "|") || strings.HasPrefix(text, ">") ||
+		strings.HasPrefix(text, "&") || strings.HasPrefix(text, "*") || strings.HasPrefix(text, "!") {
+		return nil, fmt.Errorf("line %d: unsupported YAML syntax %q", lineNum, text)
+	}
+	if text[0] == '[' || text[0] == '{' {
+		value, rest, err := parseYAMLFlow(text, lineNum)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(rest) != "" {
+			return nil, fmt.Errorf("line %d: unexpected %q after flow collection", lineNum, rest)
+		}
+		return value, nil
+	}
+	return parseYAMLScalar(text, lineNum)
+}
+
+// parseYAMLFlow parses a flow collection at the start of text and returns
+// the unparsed remainder
+func parseYAMLFlow(text string, lineNum int) (any, string, error) {
+	open := text[0]
+	closing := byte(']')
+	if open == '{' {
+		closing = '}'
+	}
+	text = strings.TrimSpace(text[1:])
+
+	var items []any
+	m := map[string]any{}
+	for {
+		if text == "" {
+			return nil, "", fmt.Errorf("line %d: unclosed %q", lineNum, string(open))
+		}
+		if text[0] == closing {
+			text = text[1:]
+			break
+		}
+
+		// Parse one entry, which may itself be a flow collection
+		var key string
+		if open == '{' {
+			end := strings.IndexByte(text, ':')
+			if end < 0 {
+				return nil, "", fmt.Errorf("line %d: expected \"key: value\" in %q", lineNum, text)
+			}
+			k, err := parseYAMLScalar(strings.TrimSpace(text[:end]), lineNum)
+			if err != nil {
+				return nil, "", err
+			}
+			key = fmt.Sprint(k)
+			text = strings.TrimSpace(text[end+1:])
+		}
+
+		var value any
+		if text != "" && (text[0] == '[' || text[0] == '{') {
+			v, rest, err := parseYAMLFlow(text, lineNum)
+			if err != nil {
+				return nil, "", err
+			}
+			value, text = v, strings.TrimSpace(rest)
+		} else {
+			end := flowScalarEnd(text, closing)
+			v, err := parseYAMLScalar(strings.TrimSpace(text[:end]), lineNum)
+			if err != nil {
+				return nil, "", err
+			}
+			value, text = v, text[end:]
+		}
+
+		if open == '{' {
+			m[key] = value
+		} else {
+			items = append(items, value)
+		}
+
+		text = strings.TrimSpace(text)
+		if strings.HasPrefix(text, ",") {
+			text = strings.TrimSpace(text[1:])
+		} else if text == "" || text[0] != closing {
+			return nil, "", fmt.Errorf("line %d: expected ',' or %q", lineNum, string(closing))
+		}
+	}
+
+	if open == '{' {
+		return m, text, nil
+	}
+	if items == nil {
+		items = []any{}
+	}
+	return items, text, nil
+}
+
+// flowScalarEnd returns the end of a scalar inside a flow collection
+func flowScalarEnd(text string, closing byte) int {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ',' || c == closing:
+			return i
+		}
+	}
+	return len(text)
+}
+
+// parseYAMLScalar converts a plain or quoted scalar
+func parseYAMLScalar(text string, lineNum int) (any, error) {
+	if text == "" {
+		return nil, nil
+	}
+	switch text[0] {
+	case '"':
+		s, err := strconv.Unquote(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid quoted string %s", lineNum, text)
+		}
+		return s, nil
+	case '\'':
+		if len(text) < 2 || text[len(text)-1] != '\'' {
+			return nil, fmt.Errorf("line %d: invalid quoted string %s", lineNum, text)
+		}
+		return strings.ReplaceAll(text[1:len(text)-1], "''", "'"), nil
+	}
+
+	switch text {
+	case "~", "null", "Null", "NULL":
+		return nil, nil
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	}
+	if i, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return i, nil
+	}
+	if u, err := strconv.ParseUint(text, 10, 64); err == nil {
+		return u, nil
+	}
+	if f, err := strconv.ParseFloat(text, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+		return f, nil
+	}
+	return text, nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  any
+	}{
+		{"empty", "# only a comment\n---\n", nil},
+		{"block mapping", "a: 1\nb:\n  c: x\n  d: true\ne:\n", map[string]any{
+			"a": int64(1), "b": map[string]any{"c": "x", "d": true}, "e": nil,
+		}},
+		{"sequence under key", "list:\n- a\n- 2.5\nnext: ~\n", map[string]any{
+			"list": []any{"a", 2.5}, "next": nil,
+		}},
+		{"sequence of mappings", "items:\n  - name: a\n    size: 64KB\n  -\n    name: b\n  - - nested\n", map[string]any{
+			"items": []any{
+				map[string]any{"name": "a", "size": "64KB"},
+				map[string]any{"name": "b"},
+				[]any{"nested"},
+			},
+		}},
+		{"flow collections", "a: [1, two, \"th,ree\"]\nb: {x: 1, y: [a, b], z: {}}\nc: []\n", map[string]any{
+			"a": []any{int64(1), "two", "th,ree"},
+			"b": map[string]any{"x": int64(1), "y": []any{"a", "b"}, "z": map[string]any{}},
+			"c": []any{},
+		}},
+		{"quoted scalars", "a: \"tab\\there\"\nb: 'it''s'\nc: \"# not a comment\"\n\"d: e\": 'x: y' # a comment\n", map[string]any{
+			"a": "tab\there", "b": "it's", "c": "# not a comment", "d: e": "x: y",
+		}},
+		{"plain scalars", "a: NULL\nb: False\nc: 18446744073709551615\nd: -3\ne: 1e3\nf: http://x/#y\ng: .inf\n", map[string]any{
+			"a": nil, "b": false, "c": uint64(18446744073709551615), "d": int64(-3), "e": 1000.0,
+			"f": "http://x/#y", "g": ".inf",
+		}},
+		{"top-level sequence", "- 1\n- {a: b}\n", []any{int64(1), map[string]any{"a": "b"}}},
+		{"windows line endings", "a: 1\r\nb: [x]\r\n", map[string]any{"a": int64(1), "b": []any{"x"}}},
+	}
+	for _, tt := range tests {
+		got, err := parseYAML([]byte(tt.input))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %#v, want %#v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseYAMLErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string // start of the error
+	}{
+		{"a: 1\n  b: 2\n", "line 2: unexpected indentation"},
+		{"a:\n\tb: 1\n", "line 2: tabs are not allowed"},
+		{"a: 1\n# comment\na: 2\n", "line 3: duplicate key"},
+		{"a: 1\nb: [1, 2\n", "line 2: expected ',' or \"]\""},
+		{"a: [1,\n", "line 1: unclosed"},
+		{"a: {x 1}\n", "line 1: expected \"key: value\""},
+		{"a: [1 2] x\n", "line 1: unexpected"},
+		{"a: 1\nb: 2\njust text\n", "line 3: expected \"key: value\""},
+		{"a: |\n  text\n", "line 1: unsupported YAML syntax"},
+		{"a: &anchor 1\n", "line 1: unsupported YAML syntax"},
+		{"\n\na: \"bad\\q\"\n", "line 3: invalid quoted string"},
+		{"a: 'open\n", "line 1: invalid quoted string"},
+		{"- a\nb: 1\n", "line 2: unexpected indentation"},
+	}
+	for _, tt := range tests {
+		_, err := parseYAML([]byte(tt.input))
+		if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+			t.Errorf("parseYAML(%q) error = %v, want %q", tt.input, err, tt.want)
+		}
+	}
+}